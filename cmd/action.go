@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ankit-arora/act/pkg/common"
+	"github.com/ankit-arora/act/pkg/model"
+)
+
+// printActionOutputs prints the outputs of the action run with --action, one `name=value` per line
+func printActionOutputs(plan *model.Plan) common.Executor {
+	return func(ctx context.Context) error {
+		if common.Dryrun(ctx) {
+			return nil
+		}
+		for _, stage := range plan.Stages {
+			for _, run := range stage.Runs {
+				outputs := make(map[string]string)
+				if raw := run.Job().Outputs["outputs"]; raw != "" {
+					if err := json.Unmarshal([]byte(raw), &outputs); err != nil {
+						return fmt.Errorf("failed to read outputs of action: %w", err)
+					}
+				}
+
+				names := make([]string, 0, len(outputs))
+				for name := range outputs {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					fmt.Printf("%s=%s\n", name, outputs[name])
+				}
+			}
+		}
+		return nil
+	}
+}
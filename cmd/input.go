@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -9,35 +12,74 @@ import (
 // Input contains the input for the root command
 type Input struct {
 	actor                 string
+	repositoryOwner       string
 	workdir               string
 	workflowsPath         string
 	autodetectEvent       bool
 	eventPath             string
 	reuseContainers       bool
+	pruneFailed           bool
+	containerPoolSize     int
+	jobContainerName      string
+	workspaceVolume       string
 	bindWorkdir           bool
+	bindWorkdirSubpath    string
+	containerWorkdir      string
 	secrets               []string
 	envs                  []string
 	platforms             []string
 	dryrun                bool
 	forcePull             bool
+	pullImages            []string
 	forceRebuild          bool
+	buildArgs             []string
 	noOutput              bool
+	logPrefixJobName      bool
+	remoteHost            string
+	remoteIdentityFile    string
+	printStepOutputs      bool
 	envfile               string
 	secretfile            string
+	vars                  []string
+	varfile               string
 	insecureSecrets       bool
+	strictSecrets         bool
 	defaultBranch         string
+	defaultImage          string
 	privileged            bool
 	usernsMode            string
+	containerUser         string
 	containerArchitecture string
+	strictPlatform        bool
 	containerDaemonSocket string
+	dockerHost            string
 	noWorkflowRecurse     bool
 	useGitIgnore          bool
+	copyExcludes          []string
+	skipGitDir            bool
 	githubInstance        string
+	githubComActions      []string
+	githubComActionsToken string
 	containerCapAdd       []string
 	containerCapDrop      []string
+	containerCapPresets   []string
+	containerInit         bool
+	containerStopTimeout  time.Duration
 	autoRemove            bool
+	incrementRunNumber    bool
 	artifactServerPath    string
 	artifactServerPort    string
+	action                string
+	actionInputs          []string
+	actionRunsOn          string
+	inputs                []string
+	inputsFile            string
+	runnerTemp            string
+	runnerToolCache       string
+	isolateRunnerDirs     bool
+	cacheToolInstalls     []string
+	validate              bool
+	validateActions       bool
 }
 
 func (i *Input) resolve(path string) string {
@@ -64,6 +106,11 @@ func (i *Input) Secretfile() string {
 	return i.resolve(i.secretfile)
 }
 
+// Varfile returns path to configuration variables
+func (i *Input) Varfile() string {
+	return i.resolve(i.varfile)
+}
+
 // Workdir returns path to workdir
 func (i *Input) Workdir() string {
 	return i.resolve(".")
@@ -74,6 +121,63 @@ func (i *Input) WorkflowsPath() string {
 	return i.resolve(i.workflowsPath)
 }
 
+// ActionInputs returns the inputs for the action run with --action
+func (i *Input) ActionInputs() map[string]string {
+	inputs := make(map[string]string)
+	for _, input := range i.actionInputs {
+		parts := strings.SplitN(input, "=", 2)
+		if len(parts) == 2 {
+			inputs[parts[0]] = parts[1]
+		} else {
+			inputs[parts[0]] = ""
+		}
+	}
+	return inputs
+}
+
+// Inputs returns the inputs of the workflow_dispatch event
+func (i *Input) Inputs() map[string]string {
+	inputs := make(map[string]string)
+	for _, input := range i.inputs {
+		parts := strings.SplitN(input, "=", 2)
+		if len(parts) == 2 {
+			inputs[parts[0]] = parts[1]
+		} else {
+			inputs[parts[0]] = ""
+		}
+	}
+	return inputs
+}
+
+// InputsFile returns path to the JSON file of inputs
+func (i *Input) InputsFile() string {
+	return i.resolve(i.inputsFile)
+}
+
+// BuildArgs returns the build args of the images, like docker build an arg without value is taken from the environment
+func (i *Input) BuildArgs() map[string]string {
+	args := make(map[string]string)
+	for _, arg := range i.buildArgs {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) == 2 {
+			args[parts[0]] = parts[1]
+		} else if value, ok := os.LookupEnv(parts[0]); ok {
+			args[parts[0]] = value
+		}
+	}
+	return args
+}
+
+// PullPolicies returns the per image pull policies, images are pulled unless the value is false
+func (i *Input) PullPolicies() map[string]bool {
+	policies := make(map[string]bool)
+	for _, image := range i.pullImages {
+		parts := strings.SplitN(image, "=", 2)
+		policies[parts[0]] = len(parts) == 1 || parts[1] != "false"
+	}
+	return policies
+}
+
 // EventPath returns the path to events file
 func (i *Input) EventPath() string {
 	return i.resolve(i.eventPath)
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/ankit-arora/act/pkg/model"
+	"github.com/ankit-arora/act/pkg/runner"
 )
 
 func printList(plan *model.Plan) error {
@@ -105,3 +106,28 @@ func printList(plan *model.Plan) error {
 	}
 	return nil
 }
+
+// printSteps prints the steps of every job in order, with whether their if lets them run
+func printSteps(jobs []runner.PlannedJob) error {
+	for i, job := range jobs {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Stage %d  %s (%s)  %s\n", job.Stage, job.Name, job.JobID, job.Workflow)
+		for n, step := range job.Steps {
+			action := "run: " + step.Run
+			if step.Uses != "" {
+				action = "uses: " + step.Uses
+			}
+			line := fmt.Sprintf("  %d. %s", n+1, action)
+			if step.Name != "" {
+				line = fmt.Sprintf("  %d. %s  %s", n+1, step.Name, action)
+			}
+			if step.If != "" {
+				line = fmt.Sprintf("%s  [if: %s => %s]", line, step.If, step.Enabled)
+			}
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/andreaskoch/go-fswatch"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/ankit-arora/act/pkg/artifacts"
 	"github.com/ankit-arora/act/pkg/common"
+	"github.com/ankit-arora/act/pkg/container"
 	"github.com/ankit-arora/act/pkg/model"
 	"github.com/ankit-arora/act/pkg/runner"
 )
@@ -38,38 +40,77 @@ func Execute(ctx context.Context, version string) {
 	rootCmd.Flags().BoolP("watch", "w", false, "watch the contents of the local repo and run when files change")
 	rootCmd.Flags().BoolP("list", "l", false, "list workflows")
 	rootCmd.Flags().BoolP("graph", "g", false, "draw workflows")
+	rootCmd.Flags().BoolVar(&input.validate, "validate", false, "validate the workflows and exit")
+	rootCmd.Flags().BoolVar(&input.validateActions, "validate-actions", false, "warn about mistakes in the action.yml of the actions when they are loaded, e.g. outputs referencing steps that don't exist")
 	rootCmd.Flags().StringP("job", "j", "", "run job")
 	rootCmd.Flags().StringArrayVarP(&input.secrets, "secret", "s", []string{}, "secret to make available to actions with optional value (e.g. -s mysecret=foo or -s mysecret)")
+	rootCmd.Flags().StringArrayVarP(&input.vars, "var", "", []string{}, "variable to make available to actions with optional value (e.g. --var myvar=foo or --var myvar)")
 	rootCmd.Flags().StringArrayVarP(&input.envs, "env", "", []string{}, "env to make available to actions with optional value (e.g. --env myenv=foo or --env myenv)")
 	rootCmd.Flags().StringArrayVarP(&input.platforms, "platform", "P", []string{}, "custom image to use per platform (e.g. -P ubuntu-18.04=nektos/act-environments-ubuntu:18.04)")
 	rootCmd.Flags().BoolVarP(&input.reuseContainers, "reuse", "r", false, "don't remove container(s) on successfully completed workflow(s) to maintain state between runs")
+	rootCmd.Flags().BoolVarP(&input.pruneFailed, "prune-failed", "", false, "remove the container(s) kept with --reuse or --container-pool-size whose last run failed, and exit")
+	rootCmd.Flags().IntVarP(&input.containerPoolSize, "container-pool-size", "", 0, "number of warm job container(s) kept alive per image between runs, they are reset instead of recreated")
+	rootCmd.Flags().StringVar(&input.jobContainerName, "job-container-name", "", "template of the job container names, expressions are interpolated (e.g. --job-container-name 'act-${{ github.run_id }}-${{ github.job }}-${{ matrix.os }}')")
 	rootCmd.Flags().BoolVarP(&input.bindWorkdir, "bind", "b", false, "bind working directory to container, rather than copy")
+	rootCmd.Flags().StringVarP(&input.bindWorkdirSubpath, "bind-subpath", "", "", "subdirectory of the working directory to bind as the workspace with --bind, the job runs as if it was the root of the repository (e.g. --bind-subpath services/api)")
+	rootCmd.Flags().StringVarP(&input.containerWorkdir, "container-workdir", "", "", "path of the workspace inside the containers, GITHUB_WORKSPACE is set to it (default the path of the working directory)")
+	rootCmd.Flags().StringVarP(&input.workspaceVolume, "reuse-workspace-volume", "", "", "name of a docker volume that keeps the workspace across runs, ignored with --bind")
 	rootCmd.Flags().BoolVarP(&input.forcePull, "pull", "p", false, "pull docker image(s) even if already present")
+	rootCmd.Flags().StringArrayVarP(&input.pullImages, "pull-image", "", []string{}, "pull the docker image even if already present, overrides --pull for that image (e.g. --pull-image node:16 or --pull-image node:16=false)")
 	rootCmd.Flags().BoolVarP(&input.forceRebuild, "rebuild", "", false, "rebuild local action docker image(s) even if already present")
+	rootCmd.Flags().StringArrayVarP(&input.buildArgs, "build-arg", "", []string{}, "build arg for the images of docker actions and Dockerfile platforms, the value may reference secrets to mask it (e.g. --build-arg NPM_TOKEN=${{ secrets.NPM_TOKEN }})")
 	rootCmd.Flags().BoolVarP(&input.autodetectEvent, "detect-event", "", false, "Use first event type from workflow as event that triggered the workflow")
 	rootCmd.Flags().StringVarP(&input.eventPath, "eventpath", "e", "", "path to event JSON file")
 	rootCmd.Flags().StringVar(&input.defaultBranch, "defaultbranch", "", "the name of the main branch")
+	rootCmd.Flags().StringVar(&input.defaultImage, "default-image", "", "image of the jobs whose runs-on labels aren't mapped with -P, instead of skipping them (e.g. --default-image node:16-buster-slim)")
 	rootCmd.Flags().BoolVar(&input.privileged, "privileged", false, "use privileged mode")
 	rootCmd.Flags().StringVar(&input.usernsMode, "userns", "", "user namespace to use")
+	rootCmd.Flags().StringVar(&input.containerUser, "container-user", "", "user[:group] the steps of the job containers run as, so files created in a bound workdir belong to the host user (e.g. --container-user 1000:1000)")
 	rootCmd.Flags().BoolVar(&input.useGitIgnore, "use-gitignore", true, "Controls whether paths specified in .gitignore should be copied into container")
+	rootCmd.Flags().BoolVar(&input.skipGitDir, "skip-git-dir", false, "don't copy .git into the container with the workspace, GITHUB_SHA and GITHUB_REF are still set but git commands in the job fail")
+	rootCmd.Flags().StringArrayVarP(&input.copyExcludes, "copy-exclude", "", []string{}, "pattern in .gitignore syntax of paths not to copy into the container with the workspace (e.g. --copy-exclude .git --copy-exclude node_modules)")
 	rootCmd.Flags().StringArrayVarP(&input.containerCapAdd, "container-cap-add", "", []string{}, "kernel capabilities to add to the workflow containers (e.g. --container-cap-add SYS_PTRACE)")
+	rootCmd.Flags().StringArrayVarP(&input.containerCapPresets, "cap-preset", "", []string{}, "named set of kernel capabilities to add to the workflow containers: debug, docker, fuse, network or time (e.g. --cap-preset docker)")
 	rootCmd.Flags().StringArrayVarP(&input.containerCapDrop, "container-cap-drop", "", []string{}, "kernel capabilities to remove from the workflow containers (e.g. --container-cap-drop SYS_PTRACE)")
+	rootCmd.Flags().BoolVar(&input.containerInit, "container-init", true, "run an init process inside the workflow containers that reaps zombie processes")
+	rootCmd.Flags().DurationVarP(&input.containerStopTimeout, "container-stop-timeout", "", 10*time.Second, "time the workflow containers have to exit after SIGTERM before they are killed when they are removed")
+	rootCmd.Flags().StringVarP(&input.runnerTemp, "runner-temp", "", "", "path of RUNNER_TEMP in the workflow containers (default \"/tmp\")")
+	rootCmd.Flags().StringVarP(&input.runnerToolCache, "runner-tool-cache", "", "", "path of RUNNER_TOOL_CACHE in the workflow containers (default \"/opt/hostedtoolcache\")")
+	rootCmd.Flags().BoolVar(&input.isolateRunnerDirs, "isolate-runner-dirs", false, "mount volumes of the job at RUNNER_TEMP and RUNNER_TOOL_CACHE instead of sharing the act-toolcache volume between runs, they are removed with the job container")
+	rootCmd.Flags().StringArrayVarP(&input.cacheToolInstalls, "cache-tool-install", "", []string{}, "toolchain installed into the shared tool cache before the jobs run, one of go, node or python with a version (e.g. --cache-tool-install node@16)")
+	rootCmd.Flags().StringVar(&input.action, "action", "", "run a single action against the working directory and print its outputs (e.g. --action actions/setup-node@v2)")
+	rootCmd.Flags().StringVar(&input.actionRunsOn, "action-runs-on", "ubuntu-latest", "runs-on label of the job running the action of --action or installing the toolchains of --cache-tool-install, its image is looked up like the one of a workflow job")
+	rootCmd.Flags().StringArrayVarP(&input.actionInputs, "with", "", []string{}, "input for the action run with --action (e.g. --with node-version=16)")
+	rootCmd.Flags().StringArrayVarP(&input.inputs, "input", "", []string{}, "input of the workflow_dispatch event (e.g. --input name=value)")
+	rootCmd.Flags().StringVarP(&input.inputsFile, "input-file", "", "", "JSON file of inputs of the workflow_dispatch event or the action run with --action, overridden by --input and --with")
 	rootCmd.Flags().BoolVar(&input.autoRemove, "rm", false, "automatically remove container(s)/volume(s) after a workflow(s) failure")
+	rootCmd.Flags().BoolVar(&input.incrementRunNumber, "increment-run-number", false, "give every run a new GITHUB_RUN_ID and GITHUB_RUN_NUMBER from a counter per repository and workflow kept in the cache dir, instead of 1")
 	rootCmd.PersistentFlags().StringVarP(&input.actor, "actor", "a", "nektos/act", "user that triggered the event")
+	rootCmd.PersistentFlags().StringVarP(&input.repositoryOwner, "repository-owner", "", "", "owner of the repository, overrides GITHUB_REPOSITORY_OWNER and the owner of the git remote")
 	rootCmd.PersistentFlags().StringVarP(&input.workflowsPath, "workflows", "W", "./.github/workflows/", "path to workflow file(s)")
 	rootCmd.PersistentFlags().BoolVarP(&input.noWorkflowRecurse, "no-recurse", "", false, "Flag to disable running workflows from subdirectories of specified path in '--workflows'/'-W' flag")
 	rootCmd.PersistentFlags().StringVarP(&input.workdir, "directory", "C", ".", "working directory")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
-	rootCmd.PersistentFlags().BoolVarP(&input.noOutput, "quiet", "q", false, "disable logging of output from steps")
+	rootCmd.PersistentFlags().BoolVarP(&input.noOutput, "quiet", "q", false, "disable logging of output from steps, only the status of the jobs and steps is logged")
+	rootCmd.PersistentFlags().BoolVarP(&input.logPrefixJobName, "log-prefix-job-name", "", false, "prefix the output of the steps with the job name in colored terminals too, to tell apart jobs running in parallel")
+	rootCmd.PersistentFlags().StringVarP(&input.remoteHost, "remote-host", "", "", "run self-hosted jobs (-P ubuntu-latest=-self-hosted) on this host over ssh instead of locally (e.g. --remote-host runner@build-box:22), the key of the host must be in ~/.ssh/known_hosts")
+	rootCmd.PersistentFlags().StringVarP(&input.remoteIdentityFile, "remote-identity-file", "", "", "private key to log in to --remote-host with, the keys of the ssh-agent are tried as well")
+	rootCmd.PersistentFlags().BoolVarP(&input.printStepOutputs, "print-step-outputs", "", false, "log the outputs of every step once it completed, secrets in them are masked")
 	rootCmd.PersistentFlags().BoolVarP(&input.dryrun, "dryrun", "n", false, "dryrun mode")
-	rootCmd.PersistentFlags().StringVarP(&input.secretfile, "secret-file", "", ".secrets", "file with list of secrets to read from (e.g. --secret-file .secrets)")
+	rootCmd.PersistentFlags().StringVarP(&input.secretfile, "secret-file", "", ".secrets", "file with list of secrets to read from, their names are upper cased like the ones of --secret (e.g. --secret-file .secrets)")
+	rootCmd.PersistentFlags().StringVarP(&input.varfile, "var-file", "", ".vars", "file with list of configuration variables to read from (e.g. --var-file .vars)")
 	rootCmd.PersistentFlags().BoolVarP(&input.insecureSecrets, "insecure-secrets", "", false, "NOT RECOMMENDED! Doesn't hide secrets while printing logs.")
+	rootCmd.PersistentFlags().BoolVarP(&input.strictSecrets, "strict-secrets", "", false, "fail a job before it starts if its expressions reference secrets or env variables that aren't provided, instead of evaluating them to empty strings")
 	rootCmd.PersistentFlags().StringVarP(&input.envfile, "env-file", "", ".env", "environment file to read and use as env in the containers")
 	rootCmd.PersistentFlags().StringVarP(&input.containerArchitecture, "container-architecture", "", "", "Architecture which should be used to run containers, e.g.: linux/amd64. If not specified, will use host default architecture. Requires Docker server API Version 1.41+. Ignored on earlier Docker server platforms.")
-	rootCmd.PersistentFlags().StringVarP(&input.containerDaemonSocket, "container-daemon-socket", "", "/var/run/docker.sock", "Path to Docker daemon socket which will be mounted to containers")
+	rootCmd.PersistentFlags().BoolVarP(&input.strictPlatform, "strict-platform", "", false, "fail if an image isn't available for the container architecture instead of falling back to the native platform of the image")
+	rootCmd.PersistentFlags().StringVarP(&input.dockerHost, "docker-host", "", "", "address of the Docker daemon, overrides DOCKER_HOST (e.g. tcp://docker:2376)")
+	rootCmd.PersistentFlags().StringVarP(&input.containerDaemonSocket, "container-daemon-socket", "", "/var/run/docker.sock", "Path to Docker daemon socket which will be mounted to containers, - disables the mount")
 	rootCmd.PersistentFlags().StringVarP(&input.githubInstance, "github-instance", "", "github.com", "GitHub instance to use. Don't use this if you are not using GitHub Enterprise Server.")
+	rootCmd.PersistentFlags().StringArrayVarP(&input.githubComActions, "github-com-action", "", []string{}, "pattern of actions to fetch from github.com instead of the GitHub Enterprise Server set with --github-instance (e.g. --github-com-action 'actions/*')")
+	rootCmd.PersistentFlags().StringVarP(&input.githubComActionsToken, "github-com-token", "", "", "token to fetch the actions of --github-com-action with, the GITHUB_TOKEN of the GitHub Enterprise Server isn't sent to github.com")
 	rootCmd.PersistentFlags().StringVarP(&input.artifactServerPath, "artifact-server-path", "", "", "Defines the path where the artifact server stores uploads and retrieves downloads from. If not specified the artifact server will not start.")
-	rootCmd.PersistentFlags().StringVarP(&input.artifactServerPort, "artifact-server-port", "", "34567", "Defines the port where the artifact server listens (will only bind to localhost).")
+	rootCmd.PersistentFlags().StringVarP(&input.artifactServerPort, "artifact-server-port", "", "34567", "Defines the port where the artifact server listens (will only bind to localhost), 0 picks a free port.")
 	rootCmd.SetArgs(args())
 
 	if err := rootCmd.Execute(); err != nil {
@@ -165,6 +206,10 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			l.Warnf(" \U000026A0 You are using Apple M1 chip and you have not specified container architecture, you might encounter issues while running act. If so, try running it with '--container-architecture linux/amd64'. \U000026A0 \n")
 		}
 
+		if input.pruneFailed {
+			return container.NewDockerPruneFailedExecutor()(common.WithDryrun(ctx, input.dryrun))
+		}
+
 		log.Debugf("Loading environment from %s", input.Envfile())
 		envs := make(map[string]string)
 		if input.envs != nil {
@@ -181,13 +226,44 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 
 		log.Debugf("Loading secrets from %s", input.Secretfile())
 		secrets := newSecrets(input.secrets)
-		_ = readEnvs(input.Secretfile(), secrets)
-
-		planner, err := model.NewWorkflowPlanner(input.WorkflowsPath(), input.noWorkflowRecurse)
-		if err != nil {
+		_ = secrets.readFile(input.Secretfile())
+		if err := secrets.resolveFiles(input.resolve); err != nil {
 			return err
 		}
 
+		log.Debugf("Loading vars from %s", input.Varfile())
+		vars := newVars(input.vars)
+		_ = readEnvs(input.Varfile(), vars)
+
+		workflowsPath := input.WorkflowsPath()
+		var planner model.WorkflowPlanner
+		if input.action != "" {
+			with, err := runner.MergeInputs(input.InputsFile(), input.ActionInputs())
+			if err != nil {
+				return err
+			}
+			actionEvent := "push"
+			if len(args) > 0 {
+				actionEvent = args[0]
+			}
+			planner = model.NewSingleActionPlanner(input.action, with, input.actionRunsOn, actionEvent)
+		} else {
+			var err error
+			planner, err = runner.NewWorkflowPlanner(&runner.Config{Workdir: input.Workdir(), WorkflowsPath: workflowsPath}, input.noWorkflowRecurse)
+			if err != nil {
+				return err
+			}
+		}
+
+		// check if we should just validate the workflows
+		if input.validate {
+			if err := planner.Validate(); err != nil {
+				return err
+			}
+			log.Infof("The workflows are valid")
+			return nil
+		}
+
 		// Determine the event name
 		var eventName string
 		events := planner.GetEvents()
@@ -211,25 +287,37 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 		} else if jobID != "" {
 			log.Debugf("Planning job: %s", jobID)
 			plan = planner.PlanJob(jobID)
+		} else if input.action != "" {
+			log.Debugf("Planning action: %s", input.action)
+			plan = planner.PlanJob(model.SingleActionJobID)
 		} else {
 			log.Debugf("Planning event: %s", eventName)
 			plan = planner.PlanEvent(eventName)
 		}
 
-		// check if we should just list the workflows
-		if list, err := cmd.Flags().GetBool("list"); err != nil {
+		list, err := cmd.Flags().GetBool("list")
+		if err != nil {
 			return err
-		} else if list {
+		}
+		graph, err := cmd.Flags().GetBool("graph")
+		if err != nil {
+			return err
+		}
+
+		// check if we should just list the workflows
+		if list && !graph {
 			return printList(plan)
 		}
 
 		// check if we should just print the graph
-		if list, err := cmd.Flags().GetBool("graph"); err != nil {
-			return err
-		} else if list {
+		if graph && !list {
 			return drawGraph(plan)
 		}
 
+		if _, err := container.ExpandCapabilityPresets(input.containerCapPresets); err != nil {
+			return err
+		}
+
 		// check to see if the main branch was defined
 		defaultbranch, err := cmd.Flags().GetString("defaultbranch")
 		if err != nil {
@@ -257,28 +345,64 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 		// run the plan
 		config := &runner.Config{
 			Actor:                 input.actor,
+			RepositoryOwner:       input.repositoryOwner,
 			EventName:             eventName,
 			EventPath:             input.EventPath(),
+			Inputs:                input.Inputs(),
+			InputsFile:            input.InputsFile(),
 			DefaultBranch:         defaultbranch,
+			DefaultImage:          input.defaultImage,
 			ForcePull:             input.forcePull,
+			PullPolicies:          input.PullPolicies(),
 			ForceRebuild:          input.forceRebuild,
+			ContainerBuildArgs:    input.BuildArgs(),
 			ReuseContainers:       input.reuseContainers,
+			ContainerPoolSize:     input.containerPoolSize,
+			JobContainerName:      input.jobContainerName,
+			WorkspaceVolumeName:   input.workspaceVolume,
 			Workdir:               input.Workdir(),
+			WorkflowsPath:         workflowsPath,
 			BindWorkdir:           input.bindWorkdir,
+			BindWorkdirSubpath:    input.bindWorkdirSubpath,
+			ContainerWorkdir:      input.containerWorkdir,
 			LogOutput:             !input.noOutput,
+			Quiet:                 input.noOutput,
+			LogPrefixJobName:      input.logPrefixJobName,
+			RemoteHost:            input.remoteHost,
+			RemoteIdentityFile:    input.remoteIdentityFile,
+			PrintStepOutputs:      input.printStepOutputs,
 			Env:                   envs,
 			Secrets:               secrets,
 			InsecureSecrets:       input.insecureSecrets,
+			StrictSecrets:         input.strictSecrets,
+			Vars:                  vars,
 			Platforms:             input.newPlatforms(),
 			Privileged:            input.privileged,
 			UsernsMode:            input.usernsMode,
+			ContainerUser:         input.containerUser,
 			ContainerArchitecture: input.containerArchitecture,
+			StrictPlatform:        input.strictPlatform,
 			ContainerDaemonSocket: input.containerDaemonSocket,
+			DockerHost:            input.dockerHost,
 			UseGitIgnore:          input.useGitIgnore,
+			CopyExcludes:          input.copyExcludes,
+			SkipGitDir:            input.skipGitDir,
 			GitHubInstance:        input.githubInstance,
+			GithubComActions:      input.githubComActions,
+			GithubComActionsToken: input.githubComActionsToken,
 			ContainerCapAdd:       input.containerCapAdd,
+			ContainerCapPresets:   input.containerCapPresets,
 			ContainerCapDrop:      input.containerCapDrop,
+			ContainerInit:         input.containerInit,
+			ContainerStopTimeout:  input.containerStopTimeout,
+			RunnerTemp:            input.runnerTemp,
+			RunnerToolCache:       input.runnerToolCache,
+			IsolateRunnerDirs:     input.isolateRunnerDirs,
+			CacheToolInstalls:     input.cacheToolInstalls,
+			ActionRunsOn:          input.actionRunsOn,
 			AutoRemove:            input.autoRemove,
+			IncrementRunNumber:    input.incrementRunNumber,
+			ValidateActions:       input.validateActions,
 			ArtifactServerPath:    input.artifactServerPath,
 			ArtifactServerPort:    input.artifactServerPort,
 		}
@@ -287,7 +411,16 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			return err
 		}
 
-		cancel := artifacts.Serve(ctx, input.artifactServerPath, input.artifactServerPort)
+		// check if we should just list the steps of the jobs
+		if list && graph {
+			return printSteps(r.PlanSteps(plan))
+		}
+
+		cancel, artifactServerPort, err := artifacts.StartServer(ctx, input.artifactServerPath, input.artifactServerPort)
+		if err != nil {
+			return err
+		}
+		config.ArtifactServerPort = artifactServerPort
 
 		ctx = common.WithDryrun(ctx, input.dryrun)
 		if watch, err := cmd.Flags().GetBool("watch"); err != nil {
@@ -300,6 +433,9 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			cancel()
 			return nil
 		})
+		if input.action != "" {
+			executor = executor.Then(printActionOutputs(plan))
+		}
 		return executor(ctx)
 	}
 }
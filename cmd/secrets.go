@@ -39,3 +39,38 @@ func newSecrets(secretList []string) secrets {
 func (s secrets) AsMap() map[string]string {
 	return s
 }
+
+// resolveFiles replaces the values of the form @path with the content of the file, relative paths are
+// resolved by resolve. A trailing newline of the file is dropped, so a token saved by an editor still works.
+// A literal value starting with @ is escaped as @@, e.g. @@value is the secret @value
+func (s secrets) resolveFiles(resolve func(string) string) error {
+	for k, v := range s {
+		if !strings.HasPrefix(v, "@") {
+			continue
+		}
+		if strings.HasPrefix(v, "@@") {
+			s[k] = strings.TrimPrefix(v, "@")
+			continue
+		}
+		path := resolve(strings.TrimPrefix(v, "@"))
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read secret %s from %s: %w", k, path, err)
+		}
+		s[k] = strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r")
+	}
+	return nil
+}
+
+// readFile adds the secrets of the file at path, their names are upper cased like the ones of --secret
+// so e.g. docker_username in the file authenticates the image pulls like DOCKER_USERNAME
+func (s secrets) readFile(path string) bool {
+	fileSecrets := make(map[string]string)
+	if !readEnvs(path, fileSecrets) {
+		return false
+	}
+	for k, v := range fileSecrets {
+		s[strings.ToUpper(k)] = v
+	}
+	return true
+}
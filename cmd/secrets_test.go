@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankit-arora/act/pkg/container"
+	"github.com/ankit-arora/act/pkg/container/containertest"
+	"github.com/ankit-arora/act/pkg/model"
+	"github.com/ankit-arora/act/pkg/runner"
+)
+
+func TestSecretsReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".secrets")
+	assert.NoError(t, os.WriteFile(path, []byte("DOCKER_USERNAME=user\ndocker_password=pass\n"), 0600))
+
+	s := newSecrets([]string{"DOCKER_PASSWORD=flag"})
+	assert.True(t, s.readFile(path))
+	assert.Equal(t, map[string]string{"DOCKER_USERNAME": "user", "DOCKER_PASSWORD": "pass"}, s.AsMap())
+
+	assert.False(t, s.readFile(filepath.Join(t.TempDir(), "missing")))
+}
+
+func TestSecretsResolveFiles(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "token.txt"), []byte("file-token\n"), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "cert.pem"), []byte("-----BEGIN-----\nabc\n-----END-----\n"), 0600))
+	input := &Input{workdir: dir}
+
+	s := newSecrets([]string{"GITHUB_TOKEN=@token.txt", "CERT=@" + filepath.Join(dir, "cert.pem"), "PLAIN=value", "AT=@@token.txt"})
+	assert.NoError(t, s.resolveFiles(input.resolve))
+	assert.Equal(t, map[string]string{
+		"GITHUB_TOKEN": "file-token",
+		"CERT":         "-----BEGIN-----\nabc\n-----END-----",
+		"PLAIN":        "value",
+		"AT":           "@token.txt",
+	}, s.AsMap())
+
+	s = newSecrets([]string{"MISSING=@missing.txt"})
+	assert.Error(t, s.resolveFiles(input.resolve))
+}
+
+func TestSecretsFileCredentials(t *testing.T) {
+	fake := containertest.New()
+	container.RegisterContainerBackend("secret-file-test", fake.Factory())
+
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, ".secrets")
+	assert.NoError(t, os.WriteFile(secretFile, []byte("docker_username=user\nDOCKER_PASSWORD=pass\n"), 0600))
+	workflow := filepath.Join(dir, "push.yml")
+	assert.NoError(t, os.WriteFile(workflow, []byte(`on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    container: registry.example.com/private/image:latest
+    steps:
+      - run: echo
+`), 0600))
+
+	s := newSecrets(nil)
+	assert.True(t, s.readFile(secretFile))
+
+	planner, err := model.NewWorkflowPlanner(workflow, true)
+	assert.NoError(t, err)
+	r, err := runner.New(&runner.Config{
+		Workdir:          dir,
+		EventName:        "push",
+		Platforms:        map[string]string{"ubuntu-latest": "node:16-buster-slim"},
+		Secrets:          s.AsMap(),
+		ContainerBackend: "secret-file-test",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, r.NewPlanExecutor(planner.PlanEvent("push"))(context.Background()))
+	if assert.NotNil(t, fake.Input) {
+		assert.Equal(t, "user", fake.Input.Username)
+		assert.Equal(t, "pass", fake.Input.Password)
+	}
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+)
+
+// newVars parses the configuration variables passed with --var, variables without a value
+// are read from the environment, they are never prompted for like secrets
+func newVars(varList []string) map[string]string {
+	v := make(map[string]string)
+	for _, varPair := range varList {
+		varPairParts := strings.SplitN(varPair, "=", 2)
+		varPairParts[0] = strings.ToUpper(varPairParts[0])
+		if len(varPairParts) == 2 {
+			v[varPairParts[0]] = varPairParts[1]
+		} else {
+			v[varPairParts[0]] = os.Getenv(varPairParts[0])
+		}
+	}
+	return v
+}
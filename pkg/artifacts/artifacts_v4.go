@@ -0,0 +1,375 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+)
+
+// The v4 artifact actions talk to a twirp service and upload the zipped artifact to a signed
+// blob storage URL. Both are simulated here, blobs are stored below v4Dir and never mix with
+// the uploads of the v3 protocol.
+
+const (
+	v4ServicePath = "/twirp/github.actions.results.api.v1.ArtifactService/"
+	v4Dir         = ".v4"
+)
+
+type CreateArtifactRequest struct {
+	WorkflowRunBackendID    string `json:"workflowRunBackendId"`
+	WorkflowJobRunBackendID string `json:"workflowJobRunBackendId"`
+	Name                    string `json:"name"`
+	Version                 int    `json:"version"`
+}
+
+type CreateArtifactResponse struct {
+	Ok              bool   `json:"ok"`
+	SignedUploadURL string `json:"signedUploadUrl"`
+}
+
+type FinalizeArtifactRequest struct {
+	WorkflowRunBackendID    string      `json:"workflowRunBackendId"`
+	WorkflowJobRunBackendID string      `json:"workflowJobRunBackendId"`
+	Name                    string      `json:"name"`
+	Size                    json.Number `json:"size"`
+}
+
+type FinalizeArtifactResponse struct {
+	Ok         bool   `json:"ok"`
+	ArtifactID string `json:"artifactId"`
+}
+
+type ListArtifactsRequest struct {
+	WorkflowRunBackendID    string       `json:"workflowRunBackendId"`
+	WorkflowJobRunBackendID string       `json:"workflowJobRunBackendId"`
+	NameFilter              *string      `json:"nameFilter"`
+	IDFilter                *json.Number `json:"idFilter"`
+}
+
+type ListArtifactsResponseArtifact struct {
+	WorkflowRunBackendID    string `json:"workflowRunBackendId"`
+	WorkflowJobRunBackendID string `json:"workflowJobRunBackendId"`
+	DatabaseID              string `json:"databaseId"`
+	Name                    string `json:"name"`
+	Size                    string `json:"size"`
+	CreatedAt               string `json:"createdAt"`
+}
+
+type ListArtifactsResponse struct {
+	Artifacts []ListArtifactsResponseArtifact `json:"artifacts"`
+}
+
+type GetSignedArtifactURLRequest struct {
+	WorkflowRunBackendID    string `json:"workflowRunBackendId"`
+	WorkflowJobRunBackendID string `json:"workflowJobRunBackendId"`
+	Name                    string `json:"name"`
+}
+
+type GetSignedArtifactURLResponse struct {
+	SignedURL string `json:"signedUrl"`
+}
+
+type DeleteArtifactRequest struct {
+	WorkflowRunBackendID    string `json:"workflowRunBackendId"`
+	WorkflowJobRunBackendID string `json:"workflowJobRunBackendId"`
+	Name                    string `json:"name"`
+}
+
+type DeleteArtifactResponse struct {
+	Ok         bool   `json:"ok"`
+	ArtifactID string `json:"artifactId"`
+}
+
+// twirpError is the error body twirp clients expect
+type twirpError struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+type blockList struct {
+	Latest []string `xml:"Latest"`
+}
+
+var invalidV4PathChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+type artifactsV4 struct {
+	baseDir string
+}
+
+func artifactsV4Routes(router *httprouter.Router, baseDir string) {
+	a := artifactsV4{baseDir: baseDir}
+	router.POST(v4ServicePath+":method", a.service)
+	router.PUT("/_apis/results/upload/:runId/:name", a.upload)
+	router.GET("/_apis/results/download/:runId/:name", a.download)
+}
+
+func (a artifactsV4) runDir(runID string) string {
+	return filepath.Join(a.baseDir, v4Dir, invalidV4PathChars.ReplaceAllString(runID, "_"))
+}
+
+func (a artifactsV4) blobPath(runID string, name string) string {
+	return filepath.Join(a.runDir(runID), url.PathEscape(name)+".zip")
+}
+
+func (a artifactsV4) blocksDir(runID string, name string) string {
+	return filepath.Join(a.runDir(runID), url.PathEscape(name)+".blocks")
+}
+
+// artifactID derives a stable id from the run and the name, so no database is needed
+func artifactID(runID string, name string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(runID + "/" + name))
+	return strconv.FormatUint(uint64(h.Sum32()&0x7fffffff), 10)
+}
+
+func writeTwirpError(w http.ResponseWriter, status int, code string, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(twirpError{Code: code, Msg: msg})
+}
+
+func writeTwirpResponse(w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Errorf("Failed to write artifact response: %v", err)
+	}
+}
+
+func (a artifactsV4) service(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	var handle func(w http.ResponseWriter, req *http.Request, body []byte) error
+	switch params.ByName("method") {
+	case "CreateArtifact":
+		handle = a.createArtifact
+	case "FinalizeArtifact":
+		handle = a.finalizeArtifact
+	case "ListArtifacts":
+		handle = a.listArtifacts
+	case "GetSignedArtifactURL":
+		handle = a.getSignedArtifactURL
+	case "DeleteArtifact":
+		handle = a.deleteArtifact
+	default:
+		writeTwirpError(w, http.StatusNotFound, "bad_route", fmt.Sprintf("unknown method %s", params.ByName("method")))
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeTwirpError(w, http.StatusBadRequest, "malformed", err.Error())
+		return
+	}
+	if err := handle(w, req, body); err != nil {
+		writeTwirpError(w, http.StatusInternalServerError, "internal", err.Error())
+	}
+}
+
+func (a artifactsV4) createArtifact(w http.ResponseWriter, req *http.Request, body []byte) error {
+	var request CreateArtifactRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeTwirpError(w, http.StatusBadRequest, "malformed", err.Error())
+		return nil
+	}
+	if err := os.MkdirAll(a.runDir(request.WorkflowRunBackendID), os.ModePerm); err != nil {
+		return err
+	}
+	// a new upload replaces a previous artifact of the same name
+	_ = os.RemoveAll(a.blocksDir(request.WorkflowRunBackendID, request.Name))
+	_ = os.Remove(a.blobPath(request.WorkflowRunBackendID, request.Name))
+
+	writeTwirpResponse(w, CreateArtifactResponse{
+		Ok:              true,
+		SignedUploadURL: fmt.Sprintf("http://%s/_apis/results/upload/%s/%s?sig=act", req.Host, url.PathEscape(request.WorkflowRunBackendID), url.PathEscape(request.Name)),
+	})
+	return nil
+}
+
+func (a artifactsV4) finalizeArtifact(w http.ResponseWriter, req *http.Request, body []byte) error {
+	var request FinalizeArtifactRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeTwirpError(w, http.StatusBadRequest, "malformed", err.Error())
+		return nil
+	}
+	if _, err := os.Stat(a.blobPath(request.WorkflowRunBackendID, request.Name)); err != nil {
+		writeTwirpError(w, http.StatusNotFound, "not_found", fmt.Sprintf("artifact '%s' wasn't uploaded", request.Name))
+		return nil
+	}
+	writeTwirpResponse(w, FinalizeArtifactResponse{
+		Ok:         true,
+		ArtifactID: artifactID(request.WorkflowRunBackendID, request.Name),
+	})
+	return nil
+}
+
+func (a artifactsV4) listArtifacts(w http.ResponseWriter, req *http.Request, body []byte) error {
+	var request ListArtifactsRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeTwirpError(w, http.StatusBadRequest, "malformed", err.Error())
+		return nil
+	}
+	entries, err := os.ReadDir(a.runDir(request.WorkflowRunBackendID))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	artifacts := make([]ListArtifactsResponseArtifact, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".zip") {
+			continue
+		}
+		name, err := url.PathUnescape(strings.TrimSuffix(entry.Name(), ".zip"))
+		if err != nil {
+			continue
+		}
+		id := artifactID(request.WorkflowRunBackendID, name)
+		if request.NameFilter != nil && *request.NameFilter != name {
+			continue
+		}
+		if request.IDFilter != nil && request.IDFilter.String() != id {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, ListArtifactsResponseArtifact{
+			WorkflowRunBackendID:    request.WorkflowRunBackendID,
+			WorkflowJobRunBackendID: request.WorkflowJobRunBackendID,
+			DatabaseID:              id,
+			Name:                    name,
+			Size:                    strconv.FormatInt(info.Size(), 10),
+			CreatedAt:               info.ModTime().UTC().Format(time.RFC3339),
+		})
+	}
+	writeTwirpResponse(w, ListArtifactsResponse{Artifacts: artifacts})
+	return nil
+}
+
+func (a artifactsV4) getSignedArtifactURL(w http.ResponseWriter, req *http.Request, body []byte) error {
+	var request GetSignedArtifactURLRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeTwirpError(w, http.StatusBadRequest, "malformed", err.Error())
+		return nil
+	}
+	if _, err := os.Stat(a.blobPath(request.WorkflowRunBackendID, request.Name)); err != nil {
+		writeTwirpError(w, http.StatusNotFound, "not_found", fmt.Sprintf("artifact '%s' not found", request.Name))
+		return nil
+	}
+	writeTwirpResponse(w, GetSignedArtifactURLResponse{
+		SignedURL: fmt.Sprintf("http://%s/_apis/results/download/%s/%s?sig=act", req.Host, url.PathEscape(request.WorkflowRunBackendID), url.PathEscape(request.Name)),
+	})
+	return nil
+}
+
+func (a artifactsV4) deleteArtifact(w http.ResponseWriter, req *http.Request, body []byte) error {
+	var request DeleteArtifactRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeTwirpError(w, http.StatusBadRequest, "malformed", err.Error())
+		return nil
+	}
+	if err := os.Remove(a.blobPath(request.WorkflowRunBackendID, request.Name)); err != nil {
+		writeTwirpError(w, http.StatusNotFound, "not_found", fmt.Sprintf("artifact '%s' not found", request.Name))
+		return nil
+	}
+	writeTwirpResponse(w, DeleteArtifactResponse{
+		Ok:         true,
+		ArtifactID: artifactID(request.WorkflowRunBackendID, request.Name),
+	})
+	return nil
+}
+
+// upload simulates the blob storage API used by the azure sdk: the blob is either put at once,
+// or put in blocks (possibly in parallel) which are joined in the order of the committed block list
+func (a artifactsV4) upload(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	runID := params.ByName("runId")
+	name := params.ByName("name")
+	if err := os.MkdirAll(a.runDir(runID), os.ModePerm); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var err error
+	switch req.URL.Query().Get("comp") {
+	case "block":
+		blockID := req.URL.Query().Get("blockid")
+		err = writeBlob(filepath.Join(a.blocksDir(runID, name), url.PathEscape(blockID)), req.Body)
+	case "blocklist":
+		err = a.commitBlocks(runID, name, req.Body)
+	case "":
+		err = writeBlob(a.blobPath(runID, name), req.Body)
+	default:
+		// appendBlock, properties and metadata aren't used by the artifact actions
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (a artifactsV4) commitBlocks(runID string, name string, body io.Reader) error {
+	var blocks blockList
+	if err := xml.NewDecoder(body).Decode(&blocks); err != nil {
+		return fmt.Errorf("invalid block list: %w", err)
+	}
+	blob, err := os.Create(a.blobPath(runID, name))
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	blocksDir := a.blocksDir(runID, name)
+	for _, blockID := range blocks.Latest {
+		block, err := os.Open(filepath.Join(blocksDir, url.PathEscape(blockID)))
+		if err != nil {
+			return fmt.Errorf("block '%s' wasn't uploaded: %w", blockID, err)
+		}
+		_, err = io.Copy(blob, block)
+		block.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(blocksDir)
+}
+
+func (a artifactsV4) download(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	blob, err := os.Open(a.blobPath(params.ByName("runId"), params.ByName("name")))
+	if err != nil {
+		http.Error(w, "artifact not found", http.StatusNotFound)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	if _, err := io.Copy(w, blob); err != nil {
+		log.Errorf("Failed to download artifact: %v", err)
+	}
+}
+
+func writeBlob(filePath string, body io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, body)
+	return err
+}
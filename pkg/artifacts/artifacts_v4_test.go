@@ -0,0 +1,89 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func v4Request(t *testing.T, router *httprouter.Router, method string, url string, body string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestArtifactsV4Flow(t *testing.T) {
+	assert := assert.New(t)
+
+	router := httprouter.New()
+	artifactsV4Routes(router, t.TempDir())
+	ids := `"workflowRunBackendId":"1","workflowJobRunBackendId":"build"`
+
+	rr := v4Request(t, router, "POST", "http://localhost"+v4ServicePath+"CreateArtifact", `{`+ids+`,"name":"my artifact","version":4}`)
+	assert.Equal(http.StatusOK, rr.Code)
+	created := CreateArtifactResponse{}
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.True(created.Ok)
+	assert.Equal("http://localhost/_apis/results/upload/1/my%20artifact?sig=act", created.SignedUploadURL)
+
+	// blocks may arrive in any order, the block list defines the order of the blob
+	rr = v4Request(t, router, "PUT", created.SignedUploadURL+"&comp=block&blockid=Yg%3D%3D", "world")
+	assert.Equal(http.StatusCreated, rr.Code)
+	rr = v4Request(t, router, "PUT", created.SignedUploadURL+"&comp=block&blockid=YQ%3D%3D", "hello ")
+	assert.Equal(http.StatusCreated, rr.Code)
+	rr = v4Request(t, router, "PUT", created.SignedUploadURL+"&comp=blocklist", `<?xml version="1.0" encoding="utf-8"?><BlockList><Latest>YQ==</Latest><Latest>Yg==</Latest></BlockList>`)
+	assert.Equal(http.StatusCreated, rr.Code)
+
+	rr = v4Request(t, router, "POST", "http://localhost"+v4ServicePath+"FinalizeArtifact", `{`+ids+`,"name":"my artifact","size":"11"}`)
+	assert.Equal(http.StatusOK, rr.Code)
+	finalized := FinalizeArtifactResponse{}
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), &finalized))
+	assert.Equal(artifactID("1", "my artifact"), finalized.ArtifactID)
+
+	rr = v4Request(t, router, "POST", "http://localhost"+v4ServicePath+"ListArtifacts", `{`+ids+`,"nameFilter":"my artifact"}`)
+	assert.Equal(http.StatusOK, rr.Code)
+	list := ListArtifactsResponse{}
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), &list))
+	assert.Len(list.Artifacts, 1)
+	assert.Equal("my artifact", list.Artifacts[0].Name)
+	assert.Equal("11", list.Artifacts[0].Size)
+	assert.Equal(finalized.ArtifactID, list.Artifacts[0].DatabaseID)
+
+	rr = v4Request(t, router, "POST", "http://localhost"+v4ServicePath+"ListArtifacts", `{`+ids+`,"idFilter":"1"}`)
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), &list))
+	assert.Len(list.Artifacts, 0)
+
+	rr = v4Request(t, router, "POST", "http://localhost"+v4ServicePath+"GetSignedArtifactURL", `{`+ids+`,"name":"my artifact"}`)
+	assert.Equal(http.StatusOK, rr.Code)
+	signed := GetSignedArtifactURLResponse{}
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), &signed))
+
+	rr = v4Request(t, router, "GET", signed.SignedURL, "")
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal("hello world", rr.Body.String())
+
+	rr = v4Request(t, router, "POST", "http://localhost"+v4ServicePath+"DeleteArtifact", `{`+ids+`,"name":"my artifact"}`)
+	assert.Equal(http.StatusOK, rr.Code)
+	rr = v4Request(t, router, "GET", signed.SignedURL, "")
+	assert.Equal(http.StatusNotFound, rr.Code)
+}
+
+func TestArtifactsV4Errors(t *testing.T) {
+	router := httprouter.New()
+	artifactsV4Routes(router, t.TempDir())
+
+	rr := v4Request(t, router, "POST", "http://localhost"+v4ServicePath+"FinalizeArtifact", `{"workflowRunBackendId":"1","name":"missing"}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"code":"not_found"`)
+
+	rr = v4Request(t, router, "POST", "http://localhost"+v4ServicePath+"Unknown", `{}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"code":"bad_route"`)
+}
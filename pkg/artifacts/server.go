@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"net"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/ankit-arora/act/pkg/common"
@@ -58,13 +60,30 @@ type MkdirFsImpl struct {
 }
 
 func (fsys MkdirFsImpl) MkdirAll(path string, perm fs.FileMode) error {
+	if !fs.ValidPath(path) {
+		return &fs.PathError{Op: "mkdir", Path: path, Err: fs.ErrInvalid}
+	}
 	return os.MkdirAll(fsys.dir+"/"+path, perm)
 }
 
 func (fsys MkdirFsImpl) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
 	return os.OpenFile(fsys.dir+"/"+name, os.O_CREATE|os.O_RDWR, 0644)
 }
 
+// uploadPath returns the path an item of an artifact is stored at below the directory of the run, the
+// directories of the item are kept. It fails if the item would be stored outside of that directory, the
+// artifact paths are relative to the workspace and have been resolved by the action uploading them
+func uploadPath(runID string, itemPath string) (string, error) {
+	filePath := path.Clean(runID + "/" + strings.ReplaceAll(itemPath, "\\", "/"))
+	if itemPath == "" || !fs.ValidPath(filePath) || !strings.HasPrefix(filePath, runID+"/") {
+		return "", fmt.Errorf("invalid artifact item path '%s'", itemPath)
+	}
+	return filePath, nil
+}
+
 var gzipExtension = ".gz__"
 
 func uploads(router *httprouter.Router, fsys MkdirFS) {
@@ -92,9 +111,17 @@ func uploads(router *httprouter.Router, fsys MkdirFS) {
 			itemPath += gzipExtension
 		}
 
-		filePath := fmt.Sprintf("%s/%s", runID, itemPath)
+		filePath, err := uploadPath(runID, itemPath)
+		if err != nil {
+			log.Errorf("Rejected artifact upload: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(ResponseMessage{Message: err.Error()}); err != nil {
+				log.Error(err)
+			}
+			return
+		}
 
-		err := fsys.MkdirAll(path.Dir(filePath), os.ModePerm)
+		err = fsys.MkdirAll(path.Dir(filePath), os.ModePerm)
 		if err != nil {
 			panic(err)
 		}
@@ -239,11 +266,22 @@ func downloads(router *httprouter.Router, fsys fs.FS) {
 	})
 }
 
+// Serve starts the artifact server in the background, a port that is in use is fatal
 func Serve(ctx context.Context, artifactPath string, port string) context.CancelFunc {
+	cancel, _, err := StartServer(ctx, artifactPath, port)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cancel
+}
+
+// StartServer starts the artifact server in the background and returns the port it is bound to,
+// port 0 binds to a free port so several runs on one host don't conflict
+func StartServer(ctx context.Context, artifactPath string, port string) (context.CancelFunc, string, error) {
 	serverContext, cancel := context.WithCancel(ctx)
 
 	if artifactPath == "" {
-		return cancel
+		return cancel, port, nil
 	}
 
 	router := httprouter.New()
@@ -252,14 +290,21 @@ func Serve(ctx context.Context, artifactPath string, port string) context.Cancel
 	fs := os.DirFS(artifactPath)
 	uploads(router, MkdirFsImpl{artifactPath, fs})
 	downloads(router, fs)
+	artifactsV4Routes(router, artifactPath)
 	ip := common.GetOutboundIP().String()
 
 	server := &http.Server{Addr: fmt.Sprintf("%s:%s", ip, port), Handler: router}
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		cancel()
+		return nil, "", err
+	}
+	port = strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
 
 	// run server
 	go func() {
 		log.Infof("Start server on http://%s:%s", ip, port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 			log.Fatal(err)
 		}
 	}()
@@ -274,5 +319,5 @@ func Serve(ctx context.Context, artifactPath string, port string) context.Cancel
 		}
 	}()
 
-	return cancel
+	return cancel, port, nil
 }
@@ -104,6 +104,60 @@ func TestArtifactUploadBlob(t *testing.T) {
 	assert.Equal("content", string(memfs["1/some/file"].Data))
 }
 
+func TestArtifactUploadNestedPath(t *testing.T) {
+	var memfs = fstest.MapFS(map[string]*fstest.MapFile{})
+
+	router := httprouter.New()
+	uploads(router, MapFsImpl{memfs})
+
+	req, _ := http.NewRequest("PUT", "http://localhost/upload/1?itemPath=my-artifact/dir/sub/./file.txt", strings.NewReader("content"))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "content", string(memfs["1/my-artifact/dir/sub/file.txt"].Data))
+}
+
+func TestArtifactUploadPathTraversal(t *testing.T) {
+	for _, itemPath := range []string{"../2/file", "my-artifact/../../etc/passwd", "..%5C..%5Cfile", "", "/"} {
+		var memfs = fstest.MapFS(map[string]*fstest.MapFile{})
+
+		router := httprouter.New()
+		uploads(router, MapFsImpl{memfs})
+
+		req, _ := http.NewRequest("PUT", "http://localhost/upload/1?itemPath="+itemPath, strings.NewReader("content"))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, itemPath)
+		assert.Empty(t, memfs, itemPath)
+	}
+}
+
+func TestUploadPath(t *testing.T) {
+	filePath, err := uploadPath("1", "my-artifact/dir/file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "1/my-artifact/dir/file.txt", filePath)
+
+	filePath, err = uploadPath("1", "my-artifact/dir/../file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "1/my-artifact/file.txt", filePath)
+
+	_, err = uploadPath("1", "../../file.txt")
+	assert.Error(t, err)
+}
+
+func TestMkdirFsImplRejectsTraversal(t *testing.T) {
+	dir := t.TempDir()
+	fsys := MkdirFsImpl{filepath.Join(dir, "artifacts"), os.DirFS(dir)}
+
+	assert.Error(t, fsys.MkdirAll("../outside", os.ModePerm))
+	_, err := fsys.Open("../outside.txt")
+	assert.Error(t, err)
+	_, err = os.Stat(filepath.Join(dir, "outside.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
 func TestFinalizeArtifactUpload(t *testing.T) {
 	assert := assert.New(t)
 
@@ -294,3 +348,25 @@ func runTestJobFile(ctx context.Context, t *testing.T, tjfi TestJobFileInfo) {
 		}
 	})
 }
+
+func TestStartServerFreePort(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	cancel, port, err := StartServer(ctx, t.TempDir(), "0")
+	if !assert.NoError(err) {
+		return
+	}
+	defer cancel()
+	assert.NotEqual("0", port)
+
+	other, otherPort, err := StartServer(ctx, t.TempDir(), "0")
+	if !assert.NoError(err) {
+		return
+	}
+	defer other()
+	assert.NotEqual(port, otherPort)
+
+	_, _, err = StartServer(ctx, t.TempDir(), port)
+	assert.Error(err)
+}
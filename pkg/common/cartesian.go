@@ -1,12 +1,18 @@
 package common
 
-// CartesianProduct takes map of lists and returns list of unique tuples
+import "sort"
+
+// CartesianProduct takes map of lists and returns list of unique tuples, the order is deterministic:
+// the keys are ordered lexicographically, the first one changes slowest, and the values keep the order of their list
 func CartesianProduct(mapOfLists map[string][]interface{}) []map[string]interface{} {
-	listNames := make([]string, 0)
-	lists := make([][]interface{}, 0)
-	for k, v := range mapOfLists {
+	listNames := make([]string, 0, len(mapOfLists))
+	for k := range mapOfLists {
 		listNames = append(listNames, k)
-		lists = append(lists, v)
+	}
+	sort.Strings(listNames)
+	lists := make([][]interface{}, 0, len(listNames))
+	for _, k := range listNames {
+		lists = append(lists, mapOfLists[k])
 	}
 
 	listCart := cartN(lists...)
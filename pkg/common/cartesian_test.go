@@ -37,3 +37,19 @@ func TestCartesianProduct(t *testing.T) {
 	output = CartesianProduct(input)
 	assert.Len(output, 0)
 }
+
+func TestCartesianProductOrder(t *testing.T) {
+	input := map[string][]interface{}{
+		"os":   {"ubuntu", "windows"},
+		"node": {16, 14},
+	}
+	expected := []map[string]interface{}{
+		{"node": 16, "os": "ubuntu"},
+		{"node": 16, "os": "windows"},
+		{"node": 14, "os": "ubuntu"},
+		{"node": 14, "os": "windows"},
+	}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, expected, CartesianProduct(input))
+	}
+}
@@ -1,9 +1,12 @@
 package common
 
 import (
+	"archive/tar"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 // CopyFile copy file
@@ -73,3 +76,59 @@ func CopyDir(source string, dest string) (err error) {
 	}
 	return err
 }
+
+// ExtractTar extracts a tar archive into dest, entries outside of prefix are skipped and prefix is removed from the extracted paths
+func ExtractTar(archive io.Reader, dest string, prefix string) error {
+	dest = filepath.Clean(dest)
+	prefix = strings.Trim(prefix, "/")
+	reader := tar.NewReader(archive)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		name := strings.Trim(filepath.ToSlash(header.Name), "/")
+		if prefix != "" {
+			if name != prefix && !strings.HasPrefix(name, prefix+"/") {
+				continue
+			}
+			name = strings.TrimPrefix(strings.TrimPrefix(name, prefix), "/")
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		if target != dest && !strings.HasPrefix(target, dest+string(filepath.Separator)) {
+			return fmt.Errorf("tar entry '%s' is outside of the destination", header.Name)
+		}
+
+		mode := os.FileMode(header.Mode).Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, reader)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+				return err
+			}
+			_ = os.Remove(target)
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTar(t *testing.T, files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, body := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0644,
+			Size: int64(len(body)),
+		})
+		assert.NoError(t, err)
+		_, err = tw.Write([]byte(body))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	return &buf
+}
+
+func TestExtractTar(t *testing.T) {
+	dest := t.TempDir()
+	archive := createTar(t, map[string]string{
+		"workspace/a.txt":     "a",
+		"workspace/sub/b.txt": "b",
+		"other/c.txt":         "c",
+	})
+
+	err := ExtractTar(archive, dest, "workspace")
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dest, "a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(content))
+	content, err = os.ReadFile(filepath.Join(dest, "sub", "b.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "b", string(content))
+	assert.NoFileExists(t, filepath.Join(dest, "c.txt"))
+	assert.NoDirExists(t, filepath.Join(dest, "other"))
+}
+
+func TestExtractTarOutsideDestination(t *testing.T) {
+	archive := createTar(t, map[string]string{
+		"../escape.txt": "escape",
+	})
+
+	err := ExtractTar(archive, t.TempDir(), "")
+	assert.Error(t, err)
+}
@@ -141,6 +141,23 @@ func findGitPrettyRef(head, root, sub string) (string, error) {
 	return name, err
 }
 
+// FindGitDefaultBranch returns the default branch of the origin remote, as recorded in refs/remotes/origin/HEAD by git clone
+func FindGitDefaultBranch(file string) (string, error) {
+	gitDir, err := findGitDirectory(file)
+	if err != nil {
+		return "", err
+	}
+	bts, err := ioutil.ReadFile(filepath.Join(gitDir, "refs", "remotes", "origin", "HEAD"))
+	if err != nil {
+		return "", err
+	}
+	ref := strings.TrimSpace(string(bts))
+	if !strings.HasPrefix(ref, "ref: refs/remotes/origin/") {
+		return "", fmt.Errorf("unable to parse origin HEAD '%s'", ref)
+	}
+	return strings.TrimPrefix(ref, "ref: refs/remotes/origin/"), nil
+}
+
 // FindGithubRepo get the repo
 func FindGithubRepo(file string, githubInstance string) (string, error) {
 	url, err := findGitRemoteURL(file)
@@ -91,6 +91,22 @@ func TestFindGitRemoteURL(t *testing.T) {
 	assert.Equal(remoteURL, u)
 }
 
+func TestFindGitDefaultBranch(t *testing.T) {
+	basedir := testDir(t)
+	gitConfig()
+	require.NoError(t, gitCmd("init", basedir))
+	require.NoError(t, cleanGitHooks(basedir))
+
+	_, err := FindGitDefaultBranch(basedir)
+	assert.Error(t, err, "a repository without origin has no default branch")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(basedir, ".git", "refs", "remotes", "origin"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(basedir, ".git", "refs", "remotes", "origin", "HEAD"), []byte("ref: refs/remotes/origin/main\n"), 0644))
+	branch, err := FindGitDefaultBranch(basedir)
+	assert.NoError(t, err)
+	assert.Equal(t, "main", branch)
+}
+
 func TestGitFindRef(t *testing.T) {
 	basedir := testDir(t)
 	gitConfig()
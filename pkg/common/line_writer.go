@@ -2,21 +2,34 @@ package common
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"strings"
+	"unicode/utf8"
 )
 
 // LineHandler is a callback function for handling a line
 type LineHandler func(line string) bool
 
 type lineWriter struct {
-	buffer   bytes.Buffer
-	handlers []LineHandler
+	buffer    bytes.Buffer
+	handlers  []LineHandler
+	maxLength int
+	truncated int
 }
 
 // NewLineWriter creates a new instance of a line writer
 func NewLineWriter(handlers ...LineHandler) io.Writer {
+	return NewLimitedLineWriter(0, handlers...)
+}
+
+// NewLimitedLineWriter creates a line writer that truncates lines longer than maxLength bytes,
+// the rest of the line is dropped while it is written and replaced by a '...[truncated N bytes]' suffix.
+// A maxLength of 0 doesn't limit the lines
+func NewLimitedLineWriter(maxLength int, handlers ...LineHandler) io.Writer {
 	w := new(lineWriter)
 	w.handlers = handlers
+	w.maxLength = maxLength
 	return w
 }
 
@@ -25,11 +38,9 @@ func (lw *lineWriter) Write(p []byte) (n int, err error) {
 	written := 0
 	for {
 		line, err := pBuf.ReadString('\n')
-		w, _ := lw.buffer.WriteString(line)
-		written += w
+		written += lw.bufferLine(line, err == nil)
 		if err == nil {
-			lw.handleLine(lw.buffer.String())
-			lw.buffer.Reset()
+			lw.handleLine(lw.takeLine())
 		} else if err == io.EOF {
 			break
 		} else {
@@ -40,6 +51,42 @@ func (lw *lineWriter) Write(p []byte) (n int, err error) {
 	return written, nil
 }
 
+// bufferLine adds a chunk of the current line to the buffer, the part beyond maxLength is only counted
+func (lw *lineWriter) bufferLine(chunk string, complete bool) int {
+	if lw.maxLength <= 0 {
+		w, _ := lw.buffer.WriteString(chunk)
+		return w
+	}
+	content := strings.TrimSuffix(chunk, "\n")
+	room := lw.maxLength - lw.buffer.Len()
+	if room < 0 || lw.truncated > 0 {
+		room = 0
+	}
+	if len(content) > room {
+		// don't split a multi-byte character
+		for room > 0 && !utf8.RuneStart(content[room]) {
+			room--
+		}
+		lw.truncated += len(content) - room
+		content = content[:room]
+	}
+	lw.buffer.WriteString(content)
+	if complete {
+		lw.buffer.WriteByte('\n')
+	}
+	return len(chunk)
+}
+
+func (lw *lineWriter) takeLine() string {
+	line := lw.buffer.String()
+	lw.buffer.Reset()
+	if lw.truncated > 0 {
+		line = fmt.Sprintf("%s...[truncated %d bytes]\n", strings.TrimSuffix(line, "\n"), lw.truncated)
+		lw.truncated = 0
+	}
+	return line
+}
+
 func (lw *lineWriter) handleLine(line string) {
 	for _, h := range lw.handlers {
 		ok := h(line)
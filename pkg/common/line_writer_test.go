@@ -35,3 +35,31 @@ func TestLineWriter(t *testing.T) {
 	assert.Equal(" and another\n", lines[2])
 	assert.Equal("last line\n", lines[3])
 }
+
+func TestLimitedLineWriter(t *testing.T) {
+	lines := make([]string, 0)
+	lineWriter := NewLimitedLineWriter(8, func(s string) bool {
+		lines = append(lines, s)
+		return true
+	})
+
+	assert := assert.New(t)
+	write := func(s string) {
+		n, err := lineWriter.Write([]byte(s))
+		assert.NoError(err)
+		assert.Equal(len(s), n, s)
+	}
+
+	write("short\n")
+	write("0123456789")
+	write("abcdef\n")
+	write("exactly8\n")
+	write("ééééé\n")
+
+	assert.Equal([]string{
+		"short\n",
+		"01234567...[truncated 8 bytes]\n",
+		"exactly8\n",
+		"éééé...[truncated 2 bytes]\n",
+	}, lines)
+}
@@ -0,0 +1,22 @@
+package common
+
+import (
+	"os"
+	"sync"
+)
+
+// Stdout is os.Stdout with its writes serialized, the job loggers and the progress spinner write to it
+// so a log line isn't written while the spinner is redrawn
+var Stdout = &LockedFile{File: os.Stdout}
+
+// LockedFile serializes the writes to a file, it is still a file for terminal detection through Fd
+type LockedFile struct {
+	*os.File
+	mu sync.Mutex
+}
+
+func (f *LockedFile) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.File.Write(b)
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockedFile(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	assert.NoError(t, err)
+	defer f.Close()
+	locked := &LockedFile{File: f}
+	assert.Equal(t, f.Fd(), locked.Fd())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := locked.Write([]byte(strings.Repeat("x", 1000) + "\n"))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	content, err := os.ReadFile(f.Name())
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	assert.Len(t, lines, 10)
+	for _, line := range lines {
+		assert.Len(t, line, 1000)
+	}
+}
@@ -0,0 +1,43 @@
+package container
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DockerBackend is the name of the default container backend
+const DockerBackend = "docker"
+
+// Factory creates a reference to a container of a backend
+type Factory func(input *NewContainerInput) Container
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]Factory{}
+)
+
+// RegisterContainerBackend makes a container backend available under the name, so alternative
+// transports or mocks can be used instead of docker. Registering a name twice replaces the factory
+func RegisterContainerBackend(name string, factory Factory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[name] = factory
+}
+
+// NewContainerWithBackend creates a reference to a container of the named backend,
+// an empty name selects docker unless another factory was registered for it
+func NewContainerWithBackend(name string, input *NewContainerInput) (Container, error) {
+	if name == "" {
+		name = DockerBackend
+	}
+	backendsMu.RLock()
+	factory, ok := backends[name]
+	backendsMu.RUnlock()
+	if ok {
+		return factory(input), nil
+	}
+	if name == DockerBackend {
+		return NewContainer(input), nil
+	}
+	return nil, fmt.Errorf("unknown container backend '%s'", name)
+}
@@ -0,0 +1,25 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewContainerWithBackend(t *testing.T) {
+	var got *NewContainerInput
+	RegisterContainerBackend("test", func(input *NewContainerInput) Container {
+		got = input
+		return &HostExecutor{Path: input.WorkingDir}
+	})
+
+	input := &NewContainerInput{WorkingDir: "/tmp/workspace"}
+	c, err := NewContainerWithBackend("test", input)
+	assert.NoError(t, err)
+	assert.Equal(t, input, got)
+	assert.IsType(t, &HostExecutor{}, c)
+
+	_, err = NewContainerWithBackend("missing", input)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown container backend 'missing'")
+}
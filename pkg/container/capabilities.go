@@ -0,0 +1,50 @@
+package container
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CapabilityPresets are named sets of kernel capabilities for common needs of actions,
+// they grant less than running the containers privileged
+var CapabilityPresets = map[string][]string{
+	// docker-in-docker, mounting filesystems and creating device nodes for the nested daemon
+	"docker": {"SYS_ADMIN", "NET_ADMIN", "MKNOD", "SYS_RESOURCE"},
+	// debuggers and profilers attaching to processes
+	"debug": {"SYS_PTRACE"},
+	// changing interfaces, routes and firewall rules, sending raw packets (e.g. ping, tcpdump)
+	"network": {"NET_ADMIN", "NET_RAW"},
+	// mounting FUSE filesystems, /dev/fuse still has to be available in the container
+	"fuse": {"SYS_ADMIN", "MKNOD"},
+	// setting the system clock
+	"time": {"SYS_TIME"},
+}
+
+// ExpandCapabilityPresets returns the deduplicated capabilities of the named presets
+func ExpandCapabilityPresets(presets []string) ([]string, error) {
+	caps := make([]string, 0)
+	seen := map[string]bool{}
+	for _, preset := range presets {
+		presetCaps, ok := CapabilityPresets[strings.ToLower(preset)]
+		if !ok {
+			return nil, fmt.Errorf("unknown capability preset '%s', available presets are: %s", preset, strings.Join(capabilityPresetNames(), ", "))
+		}
+		for _, c := range presetCaps {
+			if !seen[c] {
+				seen[c] = true
+				caps = append(caps, c)
+			}
+		}
+	}
+	return caps, nil
+}
+
+func capabilityPresetNames() []string {
+	names := make([]string, 0, len(CapabilityPresets))
+	for name := range CapabilityPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,17 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandCapabilityPresets(t *testing.T) {
+	caps, err := ExpandCapabilityPresets([]string{"network", "Debug", "docker"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"NET_ADMIN", "NET_RAW", "SYS_PTRACE", "SYS_ADMIN", "MKNOD", "SYS_RESOURCE"}, caps)
+
+	_, err = ExpandCapabilityPresets([]string{"root"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown capability preset 'root', available presets are: debug, docker, fuse, network, time")
+}
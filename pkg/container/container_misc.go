@@ -1,10 +1,13 @@
 package container
 
 import (
+	"archive/tar"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/ankit-arora/act/pkg/common"
 	"golang.org/x/term"
@@ -18,25 +21,77 @@ func getEnvListFromMap(env map[string]string) []string {
 	return envList
 }
 
+// FileNotFoundError is returned by ReadFile if the requested file does not exist
+type FileNotFoundError struct {
+	Path string
+}
+
+func (e *FileNotFoundError) Error() string {
+	return fmt.Sprintf("file '%s' not found", e.Path)
+}
+
+// IsFileNotFound reports whether err is or wraps a FileNotFoundError
+func IsFileNotFound(err error) bool {
+	var notFound *FileNotFoundError
+	return errors.As(err, &notFound)
+}
+
+// WorkdirNotFoundError is returned by Exec if the working directory passed to it does not exist
+type WorkdirNotFoundError struct {
+	Path string
+}
+
+func (e *WorkdirNotFoundError) Error() string {
+	return fmt.Sprintf("working directory '%s' does not exist in the container", e.Path)
+}
+
+// readFileFromArchive returns the content of the first entry in a tar archive
+func readFileFromArchive(archive io.Reader, srcPath string) ([]byte, error) {
+	reader := tar.NewReader(archive)
+	header, err := reader.Next()
+	if err == io.EOF {
+		return nil, &FileNotFoundError{Path: srcPath}
+	} else if err != nil {
+		return nil, err
+	}
+	if header.Typeflag == tar.TypeDir {
+		return nil, fmt.Errorf("'%s' is a directory", srcPath)
+	}
+	return io.ReadAll(reader)
+}
+
+// ContainerLabel is the label of the job containers created by act, NewDockerPruneFailedExecutor only looks at them
+const ContainerLabel = "org.nektos.act"
+
+// ResultPath is the file the result of the last job run in a kept container is written to when the job ends.
+// The labels of a container are fixed when it is created, so they can't record it
+const ResultPath = "/var/run/act/result"
+
 // NewContainerInput the input for the New function
 type NewContainerInput struct {
-	Image       string
-	Username    string
-	Password    string
-	Entrypoint  []string
-	Cmd         []string
-	WorkingDir  string
-	Env         []string
-	Binds       []string
-	Mounts      map[string]string
-	Name        string
-	Stdout      io.Writer
-	Stderr      io.Writer
-	NetworkMode string
-	Privileged  bool
-	UsernsMode  string
-	Platform    string
-	Hostname    string
+	Image          string
+	Username       string
+	Password       string
+	Entrypoint     []string
+	Cmd            []string
+	WorkingDir     string
+	Env            []string
+	Binds          []string
+	Mounts         map[string]string
+	Name           string
+	Stdout         io.Writer
+	Stderr         io.Writer
+	NetworkMode    string
+	Privileged     bool
+	UsernsMode     string
+	User           string // user[:group] the commands run as unless Exec is given a user, empty uses the user of the image
+	Platform       string
+	StrictPlatform bool
+	Hostname       string
+	Init           bool
+	StopTimeout    time.Duration // time the container has to exit after SIGTERM before it is killed on removal, 0 uses the default of 10s
+	Idle           bool          // the entrypoint only keeps the container running for Exec, without Init it ignores SIGTERM and the container is removed without a stop
+	Labels         map[string]string
 }
 
 // FileEntry is a file to copy to a container
@@ -50,10 +105,22 @@ type FileEntry struct {
 type Container interface {
 	Create(capAdd []string, capDrop []string) common.Executor
 	Copy(destPath string, files ...*FileEntry) common.Executor
-	CopyDir(destPath string, srcPath string, useGitIgnore bool) common.Executor
+	CopyDir(destPath string, srcPath string, useGitIgnore bool, excludes ...string) common.Executor
+	// CopyGlob copies the files below srcPath matching any of the glob patterns to destPath, keeping their
+	// path relative to srcPath. A ** segment of a pattern matches any number of directories
+	CopyGlob(destPath string, srcPath string, patterns ...string) common.Executor
+	MkdirAll(path string, mode os.FileMode) common.Executor
+	WriteFile(path string, content []byte, mode os.FileMode) common.Executor
 	GetContainerArchive(ctx context.Context, srcPath string) (io.ReadCloser, error)
+	ReadFile(ctx context.Context, srcPath string) ([]byte, error)
+	Commit(ctx context.Context, ref string) error
+	Export(ctx context.Context, w io.Writer) error
+	// BuildImage builds an image from a directory on the host with the engine of the backend
+	BuildImage(ctx context.Context, input BuildInput) error
 	Pull(forcePull bool) common.Executor
 	Start(attach bool) common.Executor
+	// Exec runs the command with env on top of the env the container was created with, the runner passes the
+	// complete env of the step in which the env of the job < the env of the step < the directories added to GITHUB_PATH
 	Exec(command []string, cmdline string, env map[string]string, user, workdir string) common.Executor
 	UpdateFromEnv(srcPath string, env *map[string]string) common.Executor
 	UpdateFromImageEnv(env *map[string]string) common.Executor
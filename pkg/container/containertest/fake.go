@@ -0,0 +1,344 @@
+// Package containertest provides an in-memory container.Container to test code running jobs without docker
+package containertest
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ankit-arora/act/pkg/common"
+	"github.com/ankit-arora/act/pkg/container"
+)
+
+// ExecCall is a command run with Exec
+type ExecCall struct {
+	Command []string
+	Env     map[string]string
+	User    string
+	Workdir string
+	Stdin   string // read from the reader of container.WithExecStdin
+}
+
+// CopyGlobCall is a set of files copied with CopyGlob
+type CopyGlobCall struct {
+	DestPath string
+	SrcPath  string
+	Patterns []string
+}
+
+// CopyDirCall is a directory copied with CopyDir
+type CopyDirCall struct {
+	DestPath     string
+	SrcPath      string
+	UseGitIgnore bool
+	Excludes     []string
+}
+
+// FakeContainer implements container.Container in memory, it records the calls made to it
+// and serves files and env from its fields instead of a daemon
+type FakeContainer struct {
+	mu sync.Mutex
+
+	// Input is the input the container was created with through Factory
+	Input *container.NewContainerInput
+	// Files are the files in the container by path, Copy and WriteFile add to them
+	Files map[string][]byte
+	// EnvFiles is the env returned by UpdateFromEnv by path, files without an entry leave the env as it is
+	EnvFiles map[string]map[string]string
+	// ImageEnv is the env of the image added by UpdateFromImageEnv
+	ImageEnv map[string]string
+	// Path is prepended to PATH by UpdateFromPath
+	Path []string
+	// ExecHandler is called for every Exec, the call fails if it returns an error
+	ExecHandler func(call ExecCall) error
+
+	// Calls are the names of the methods called, in order
+	Calls []string
+	// ExecCalls are the commands run with Exec
+	ExecCalls []ExecCall
+	// CopyDirCalls are the directories copied with CopyDir
+	CopyDirCalls []CopyDirCall
+	// CopyGlobCalls are the files copied with CopyGlob
+	CopyGlobCalls []CopyGlobCall
+	// Dirs are the directories created with MkdirAll
+	Dirs []string
+	// Commits are the refs the container was committed to
+	Commits []string
+	// Builds are the images built with BuildImage
+	Builds []container.BuildInput
+}
+
+// New returns an empty FakeContainer
+func New() *FakeContainer {
+	return &FakeContainer{
+		Files:    map[string][]byte{},
+		EnvFiles: map[string]map[string]string{},
+		ImageEnv: map[string]string{},
+	}
+}
+
+// Factory returns a factory for container.RegisterContainerBackend that always returns this container
+func (f *FakeContainer) Factory() container.Factory {
+	return func(input *container.NewContainerInput) container.Container {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.Input = input
+		return f
+	}
+}
+
+func (f *FakeContainer) record(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls = append(f.Calls, name)
+}
+
+func (f *FakeContainer) recorder(name string) common.Executor {
+	return func(ctx context.Context) error {
+		f.record(name)
+		return nil
+	}
+}
+
+func (f *FakeContainer) Create(capAdd []string, capDrop []string) common.Executor {
+	return f.recorder("Create")
+}
+
+func (f *FakeContainer) Copy(destPath string, files ...*container.FileEntry) common.Executor {
+	return func(ctx context.Context) error {
+		f.record("Copy")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, file := range files {
+			f.Files[path.Join(destPath, file.Name)] = []byte(file.Body)
+			container.RecordCopy(ctx, 1, int64(len(file.Body)))
+		}
+		return nil
+	}
+}
+
+func (f *FakeContainer) CopyDir(destPath string, srcPath string, useGitIgnore bool, excludes ...string) common.Executor {
+	return func(ctx context.Context) error {
+		f.record("CopyDir")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.CopyDirCalls = append(f.CopyDirCalls, CopyDirCall{DestPath: destPath, SrcPath: srcPath, UseGitIgnore: useGitIgnore, Excludes: excludes})
+		return nil
+	}
+}
+
+func (f *FakeContainer) CopyGlob(destPath string, srcPath string, patterns ...string) common.Executor {
+	return func(ctx context.Context) error {
+		f.record("CopyGlob")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.CopyGlobCalls = append(f.CopyGlobCalls, CopyGlobCall{DestPath: destPath, SrcPath: srcPath, Patterns: patterns})
+		return nil
+	}
+}
+
+func (f *FakeContainer) MkdirAll(dirPath string, mode os.FileMode) common.Executor {
+	return func(ctx context.Context) error {
+		f.record("MkdirAll")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.Dirs = append(f.Dirs, dirPath)
+		return nil
+	}
+}
+
+func (f *FakeContainer) WriteFile(filePath string, content []byte, mode os.FileMode) common.Executor {
+	return func(ctx context.Context) error {
+		f.record("WriteFile")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.Files[filePath] = append([]byte{}, content...)
+		return nil
+	}
+}
+
+// GetContainerArchive returns a tar of the files below srcPath, named relative to its parent like docker does
+func (f *FakeContainer) GetContainerArchive(ctx context.Context, srcPath string) (io.ReadCloser, error) {
+	f.record("GetContainerArchive")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	found := false
+	for _, name := range f.sortedFiles() {
+		if name != srcPath && !strings.HasPrefix(name, strings.TrimSuffix(srcPath, "/")+"/") {
+			continue
+		}
+		found = true
+		content := f.Files[name]
+		if err := tw.WriteHeader(&tar.Header{
+			Name: strings.TrimPrefix(name, path.Dir(srcPath)+"/"),
+			Mode: 0644,
+			Size: int64(len(content)),
+		}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if !found {
+		return nil, &container.FileNotFoundError{Path: srcPath}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(buf), nil
+}
+
+func (f *FakeContainer) ReadFile(ctx context.Context, srcPath string) ([]byte, error) {
+	f.record("ReadFile")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	content, ok := f.Files[srcPath]
+	if !ok {
+		return nil, &container.FileNotFoundError{Path: srcPath}
+	}
+	return append([]byte{}, content...), nil
+}
+
+func (f *FakeContainer) Commit(ctx context.Context, ref string) error {
+	f.record("Commit")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Commits = append(f.Commits, ref)
+	return nil
+}
+
+// Export writes a tar of all files of the container
+func (f *FakeContainer) Export(ctx context.Context, w io.Writer) error {
+	f.record("Export")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tw := tar.NewWriter(w)
+	for _, name := range f.sortedFiles() {
+		content := f.Files[name]
+		if err := tw.WriteHeader(&tar.Header{Name: strings.TrimPrefix(name, "/"), Mode: 0644, Size: int64(len(content))}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// BuildImage records the build without building anything
+func (f *FakeContainer) BuildImage(ctx context.Context, input container.BuildInput) error {
+	f.record("BuildImage")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Builds = append(f.Builds, input)
+	return nil
+}
+
+func (f *FakeContainer) Pull(forcePull bool) common.Executor {
+	return f.recorder("Pull")
+}
+
+func (f *FakeContainer) Start(attach bool) common.Executor {
+	return f.recorder("Start")
+}
+
+func (f *FakeContainer) Exec(command []string, cmdline string, env map[string]string, user, workdir string) common.Executor {
+	return func(ctx context.Context) error {
+		f.record("Exec")
+		call := ExecCall{Command: command, Env: env, User: user, Workdir: workdir}
+		if stdin := container.ExecStdin(ctx); stdin != nil {
+			content, err := ioutil.ReadAll(stdin)
+			if err != nil {
+				return err
+			}
+			call.Stdin = string(content)
+		}
+		f.mu.Lock()
+		f.ExecCalls = append(f.ExecCalls, call)
+		handler := f.ExecHandler
+		f.mu.Unlock()
+		if handler != nil {
+			return handler(call)
+		}
+		return nil
+	}
+}
+
+func (f *FakeContainer) UpdateFromEnv(srcPath string, env *map[string]string) common.Executor {
+	return func(ctx context.Context) error {
+		f.record("UpdateFromEnv")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for k, v := range f.EnvFiles[srcPath] {
+			(*env)[k] = v
+		}
+		return nil
+	}
+}
+
+func (f *FakeContainer) UpdateFromImageEnv(env *map[string]string) common.Executor {
+	return func(ctx context.Context) error {
+		f.record("UpdateFromImageEnv")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for k, v := range f.ImageEnv {
+			if (*env)[k] == "" {
+				(*env)[k] = v
+			}
+		}
+		return nil
+	}
+}
+
+func (f *FakeContainer) UpdateFromPath(env *map[string]string) common.Executor {
+	return func(ctx context.Context) error {
+		f.record("UpdateFromPath")
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if len(f.Path) > 0 {
+			(*env)["PATH"] = strings.Join(append(append([]string{}, f.Path...), (*env)["PATH"]), ":")
+		}
+		return nil
+	}
+}
+
+func (f *FakeContainer) Remove() common.Executor {
+	return f.recorder("Remove")
+}
+
+func (f *FakeContainer) Close() common.Executor {
+	return f.recorder("Close")
+}
+
+// ExecCommands returns the commands run with Exec joined by spaces, handy for assertions
+func (f *FakeContainer) ExecCommands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	commands := make([]string, 0, len(f.ExecCalls))
+	for _, call := range f.ExecCalls {
+		commands = append(commands, strings.Join(call.Command, " "))
+	}
+	return commands
+}
+
+func (f *FakeContainer) sortedFiles() []string {
+	names := make([]string, 0, len(f.Files))
+	for name := range f.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var _ container.Container = &FakeContainer{}
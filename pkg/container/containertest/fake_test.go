@@ -0,0 +1,54 @@
+package containertest
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankit-arora/act/pkg/container"
+)
+
+func TestFakeContainer(t *testing.T) {
+	ctx := context.Background()
+	fake := New()
+	fake.EnvFiles["/var/run/act/workflow/envs.txt"] = map[string]string{"FOO": "bar"}
+
+	c, err := container.NewContainerWithBackend("fake", &container.NewContainerInput{Image: "node:16"})
+	assert.Error(t, err)
+	assert.Nil(t, c)
+
+	container.RegisterContainerBackend("fake", fake.Factory())
+	c, err = container.NewContainerWithBackend("fake", &container.NewContainerInput{Image: "node:16"})
+	assert.NoError(t, err)
+	assert.Equal(t, "node:16", fake.Input.Image)
+
+	assert.NoError(t, c.Copy("/var/run/act/", &container.FileEntry{Name: "workflow/event.json", Body: "{}"})(ctx))
+	content, err := c.ReadFile(ctx, "/var/run/act/workflow/event.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(content))
+
+	_, err = c.ReadFile(ctx, "/missing")
+	assert.True(t, container.IsFileNotFound(err))
+
+	env := map[string]string{"FOO": "baz"}
+	assert.NoError(t, c.UpdateFromEnv("/var/run/act/workflow/envs.txt", &env)(ctx))
+	assert.Equal(t, "bar", env["FOO"])
+
+	assert.NoError(t, c.Exec([]string{"echo", "hello"}, "", nil, "root", "/work")(ctx))
+	assert.Equal(t, []string{"echo hello"}, fake.ExecCommands())
+	assert.Equal(t, "root", fake.ExecCalls[0].User)
+
+	archive, err := c.GetContainerArchive(ctx, "/var/run/act/workflow")
+	assert.NoError(t, err)
+	tr := tar.NewReader(archive)
+	header, err := tr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "workflow/event.json", header.Name)
+	_, err = tr.Next()
+	assert.Equal(t, io.EOF, err)
+
+	assert.Equal(t, []string{"Copy", "ReadFile", "ReadFile", "UpdateFromEnv", "Exec", "GetContainerArchive"}, fake.Calls)
+}
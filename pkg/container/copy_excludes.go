@@ -0,0 +1,29 @@
+package container
+
+import (
+	"github.com/go-git/go-billy/v5/helper/polyfill"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	log "github.com/sirupsen/logrus"
+)
+
+// newCopyMatcher returns the matcher for the files CopyDir leaves out, the patterns of the .gitignore files
+// below srcPath if useGitIgnore is set and excludes, which use the .gitignore syntax as well. It is nil if
+// nothing is left out
+func newCopyMatcher(srcPath string, useGitIgnore bool, excludes []string) gitignore.Matcher {
+	var ps []gitignore.Pattern
+	if useGitIgnore {
+		var err error
+		ps, err = gitignore.ReadPatterns(polyfill.New(osfs.New(srcPath)), nil)
+		if err != nil {
+			log.Debugf("Error loading .gitignore: %v", err)
+		}
+	}
+	for _, exclude := range excludes {
+		ps = append(ps, gitignore.ParsePattern(exclude, nil))
+	}
+	if len(ps) == 0 {
+		return nil
+	}
+	return gitignore.NewMatcher(ps)
+}
@@ -0,0 +1,26 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCopyMatcher(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("dist/\n"), 0644))
+
+	assert.Nil(t, newCopyMatcher(dir, false, nil))
+
+	m := newCopyMatcher(dir, true, []string{".git", "*.log"})
+	assert.True(t, m.Match([]string{"dist"}, true))
+	assert.True(t, m.Match([]string{".git"}, true))
+	assert.True(t, m.Match([]string{"sub", "debug.log"}, false))
+	assert.False(t, m.Match([]string{"src", "main.go"}, false))
+
+	m = newCopyMatcher(dir, false, []string{"node_modules"})
+	assert.False(t, m.Match([]string{"dist"}, true))
+	assert.True(t, m.Match([]string{"app", "node_modules"}, true))
+}
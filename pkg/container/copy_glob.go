@@ -0,0 +1,70 @@
+package container
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// globFiles returns the files below srcPath matching any of the patterns as sorted slash separated paths
+// relative to srcPath. The patterns use the syntax of path.Match relative to srcPath, a ** segment
+// matches any number of directories, e.g. src/**/*.go
+func globFiles(srcPath string, patterns []string) ([]string, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid copy pattern '%s': %w", pattern, err)
+		}
+	}
+
+	files := make([]string, 0)
+	err := filepath.Walk(srcPath, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || (!fi.Mode().IsRegular() && fi.Mode()&os.ModeSymlink == 0) {
+			return nil
+		}
+		relpath, err := filepath.Rel(srcPath, file)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(relpath)
+		for _, pattern := range patterns {
+			if matchGlob(strings.Split(pattern, "/"), strings.Split(name, "/")) {
+				files = append(files, name)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// matchGlob matches the segments of a path against the segments of a pattern
+func matchGlob(pattern []string, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchGlob(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchGlob(pattern[1:], name[1:])
+}
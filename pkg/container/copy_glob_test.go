@@ -0,0 +1,32 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"action.yml", "README.md", "src/main.go", "src/lib/util.go", "src/lib/util_test.txt", "dist/index.js"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+
+	files, err := globFiles(dir, []string{"action.yml", "src/**/*.go"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"action.yml", "src/lib/util.go", "src/main.go"}, files)
+
+	files, err = globFiles(dir, []string{"*/index.js", "**/*.txt"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dist/index.js", "src/lib/util_test.txt"}, files)
+
+	files, err = globFiles(dir, []string{"*.go"})
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	_, err = globFiles(dir, []string{"src/[.go"})
+	assert.Error(t, err)
+}
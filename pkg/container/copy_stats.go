@@ -0,0 +1,45 @@
+package container
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type copyStatsContextKey string
+
+const copyStatsContextKeyVal = copyStatsContextKey("container.copyStats")
+
+// CopyStats counts the files and bytes Copy and CopyDir transferred into the containers
+type CopyStats struct {
+	files int64
+	bytes int64
+}
+
+// Add records files with a total size of bytes that were copied
+func (s *CopyStats) Add(files int64, bytes int64) {
+	atomic.AddInt64(&s.files, files)
+	atomic.AddInt64(&s.bytes, bytes)
+}
+
+// Files returns the number of files copied, symlinks count as files
+func (s *CopyStats) Files() int64 {
+	return atomic.LoadInt64(&s.files)
+}
+
+// Bytes returns the size of the content of the files copied
+func (s *CopyStats) Bytes() int64 {
+	return atomic.LoadInt64(&s.bytes)
+}
+
+// WithCopyStats adds a value to the context that the copies made with it are recorded in
+func WithCopyStats(ctx context.Context, stats *CopyStats) context.Context {
+	return context.WithValue(ctx, copyStatsContextKeyVal, stats)
+}
+
+// RecordCopy records a copy in the CopyStats of the context, if it has any. It is meant for
+// the implementations of Container
+func RecordCopy(ctx context.Context, files int64, bytes int64) {
+	if stats, ok := ctx.Value(copyStatsContextKeyVal).(*CopyStats); ok && stats != nil {
+		stats.Add(files, bytes)
+	}
+}
@@ -0,0 +1,38 @@
+package container
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordCopy(t *testing.T) {
+	RecordCopy(context.Background(), 1, 10)
+
+	stats := &CopyStats{}
+	ctx := WithCopyStats(context.Background(), stats)
+	RecordCopy(ctx, 1, 10)
+	RecordCopy(ctx, 2, 5)
+	assert.Equal(t, int64(3), stats.Files())
+	assert.Equal(t, int64(15), stats.Bytes())
+}
+
+func TestHostExecutorCopyStats(t *testing.T) {
+	src := t.TempDir()
+	dest := t.TempDir()
+	e := &HostExecutor{Path: dest}
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "a.txt"), []byte("hello"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("world!"), 0644))
+
+	stats := &CopyStats{}
+	ctx := WithCopyStats(context.Background(), stats)
+	assert.NoError(t, e.CopyDir(dest, src, false)(ctx))
+	assert.NoError(t, e.Copy(dest, &FileEntry{Name: "c.txt", Mode: 0644, Body: "abc"})(ctx))
+
+	assert.Equal(t, int64(3), stats.Files())
+	assert.Equal(t, int64(14), stats.Bytes())
+}
@@ -8,6 +8,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/pkg/archive"
@@ -23,14 +25,104 @@ import (
 // NewDockerBuildExecutorInput the input for the NewDockerBuildExecutor function
 type NewDockerBuildExecutorInput struct {
 	ContextDir string
+	Dockerfile string // path of the Dockerfile relative to ContextDir, defaults to Dockerfile
+	BuildArgs  map[string]string
 	Container  Container
 	ImageTag   string
 	Platform   string
 }
 
+// BuildInput the input for the BuildImage function
+type BuildInput struct {
+	ContextDir string            // directory on the host sent to the daemon as the build context
+	Dockerfile string            // path of the Dockerfile relative to ContextDir, defaults to Dockerfile
+	BuildArgs  map[string]string // values of the ARG instructions of the Dockerfile
+	Tags       []string          // tags of the built image
+	Platform   string            // OS/architecture the image is built for, empty uses the platform of the daemon
+	NoCache    bool              // don't use the build cache of the daemon
+}
+
+// BuildImage builds an image from a directory on the host, the output of the build is logged
+func BuildImage(ctx context.Context, input BuildInput) error {
+	logger := common.Logger(ctx)
+	logger.Infof("%sdocker build %s", logPrefix, strings.Join(input.buildFlags(), " "))
+	if common.Dryrun(ctx) {
+		return nil
+	}
+
+	dockerfile := input.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	buildContext, err := createBuildContext(input.ContextDir, dockerfile)
+	if err != nil {
+		return err
+	}
+	defer buildContext.Close()
+
+	return imageBuild(ctx, buildContext, types.ImageBuildOptions{
+		Tags:       input.Tags,
+		Remove:     true,
+		Platform:   input.Platform,
+		Dockerfile: filepath.ToSlash(dockerfile),
+		BuildArgs:  buildArgPointers(input.BuildArgs),
+		NoCache:    input.NoCache,
+	})
+}
+
+// BuildImage builds the image with the daemon of the container, see the BuildImage function
+func (cr *containerReference) BuildImage(ctx context.Context, input BuildInput) error {
+	return BuildImage(ctx, input)
+}
+
+func buildArgPointers(args map[string]string) map[string]*string {
+	pointers := make(map[string]*string, len(args))
+	for k, v := range args {
+		v := v
+		pointers[k] = &v
+	}
+	return pointers
+}
+
+// buildFlags returns the equivalent flags of docker build, the values of the build args are omitted
+func (input BuildInput) buildFlags() []string {
+	flags := make([]string, 0)
+	for _, tag := range input.Tags {
+		flags = append(flags, "-t", tag)
+	}
+	if input.Dockerfile != "" {
+		flags = append(flags, "-f", input.Dockerfile)
+	}
+	if input.Platform != "" {
+		flags = append(flags, "--platform", input.Platform)
+	}
+	args := make([]string, 0, len(input.BuildArgs))
+	for k := range input.BuildArgs {
+		args = append(args, k)
+	}
+	sort.Strings(args)
+	for _, arg := range args {
+		flags = append(flags, "--build-arg", arg)
+	}
+	if input.NoCache {
+		flags = append(flags, "--no-cache")
+	}
+	return append(flags, input.ContextDir)
+}
+
 // NewDockerBuildExecutor function to create a run executor for the container
 func NewDockerBuildExecutor(input NewDockerBuildExecutorInput) common.Executor {
 	return func(ctx context.Context) error {
+		if input.Container == nil {
+			return BuildImage(ctx, BuildInput{
+				ContextDir: input.ContextDir,
+				Dockerfile: input.Dockerfile,
+				BuildArgs:  input.BuildArgs,
+				Tags:       []string{input.ImageTag},
+				Platform:   input.Platform,
+			})
+		}
+
 		logger := common.Logger(ctx)
 		if input.Platform != "" {
 			logger.Infof("%sdocker build -t %s --platform %s %s", logPrefix, input.ImageTag, input.Platform, input.ContextDir)
@@ -41,41 +133,41 @@ func NewDockerBuildExecutor(input NewDockerBuildExecutorInput) common.Executor {
 			return nil
 		}
 
-		cli, err := GetDockerClient(ctx)
+		buildContext, err := input.Container.GetContainerArchive(ctx, input.ContextDir+"/.")
 		if err != nil {
 			return err
 		}
-		defer cli.Close()
-
-		logger.Debugf("Building image from '%v'", input.ContextDir)
+		defer buildContext.Close()
 
-		tags := []string{input.ImageTag}
-		options := types.ImageBuildOptions{
-			Tags:     tags,
-			Remove:   true,
-			Platform: input.Platform,
-		}
-		var buildContext io.ReadCloser
-		if input.Container != nil {
-			buildContext, err = input.Container.GetContainerArchive(ctx, input.ContextDir+"/.")
-		} else {
-			buildContext, err = createBuildContext(input.ContextDir, "Dockerfile")
-		}
-		if err != nil {
-			return err
+		dockerfile := input.Dockerfile
+		if dockerfile == "" {
+			dockerfile = "Dockerfile"
 		}
+		return imageBuild(ctx, buildContext, types.ImageBuildOptions{
+			Tags:       []string{input.ImageTag},
+			Remove:     true,
+			Platform:   input.Platform,
+			Dockerfile: filepath.ToSlash(dockerfile),
+			BuildArgs:  buildArgPointers(input.BuildArgs),
+		})
+	}
+}
 
-		defer buildContext.Close()
-
-		logger.Debugf("Creating image from context dir '%s' with tag '%s' and platform '%s'", input.ContextDir, input.ImageTag, input.Platform)
-		resp, err := cli.ImageBuild(ctx, buildContext, options)
+// imageBuild sends the build context to the daemon and logs the output of the build
+func imageBuild(ctx context.Context, buildContext io.Reader, options types.ImageBuildOptions) error {
+	logger := common.Logger(ctx)
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
 
-		err = logDockerResponse(logger, resp.Body, err != nil)
-		if err != nil {
-			return err
-		}
-		return nil
+	logger.Debugf("Creating image with tags '%s' and platform '%s'", strings.Join(options.Tags, ", "), options.Platform)
+	resp, err := cli.ImageBuild(ctx, buildContext, options)
+	if err != nil {
+		return err
 	}
+	return logDockerResponse(logger, resp.Body, false, nil)
 }
 func createBuildContext(contextDir string, relDockerfile string) (io.ReadCloser, error) {
 	log.Debugf("Creating archive for build context dir '%s' with relative dockerfile '%s'", contextDir, relDockerfile)
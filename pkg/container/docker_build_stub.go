@@ -12,9 +12,26 @@ import (
 	"github.com/ankit-arora/act/pkg/common"
 )
 
+// BuildInput the input for the BuildImage function
+type BuildInput struct {
+	ContextDir string
+	Dockerfile string
+	BuildArgs  map[string]string
+	Tags       []string
+	Platform   string
+	NoCache    bool
+}
+
+// BuildImage builds an image from a directory on the host
+func BuildImage(ctx context.Context, input BuildInput) error {
+	return errors.New("Unsupported Operation")
+}
+
 // NewDockerBuildExecutorInput the input for the NewDockerBuildExecutor function
 type NewDockerBuildExecutorInput struct {
 	ContextDir string
+	Dockerfile string // path of the Dockerfile relative to ContextDir, defaults to Dockerfile
+	BuildArgs  map[string]string
 	Container  Container
 	ImageTag   string
 	Platform   string
@@ -0,0 +1,69 @@
+//go:build linux || darwin || windows || openbsd
+// +build linux darwin windows openbsd
+
+package container
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildInputFlags(t *testing.T) {
+	input := BuildInput{
+		ContextDir: "testdata/build",
+		Dockerfile: "Dockerfile.test",
+		BuildArgs:  map[string]string{"TOKEN": "secret", "GREETING": "hello"},
+		Tags:       []string{"act-test:latest", "act-test:1"},
+		Platform:   "linux/amd64",
+		NoCache:    true,
+	}
+	assert.Equal(t, []string{
+		"-t", "act-test:latest", "-t", "act-test:1",
+		"-f", "Dockerfile.test",
+		"--platform", "linux/amd64",
+		"--build-arg", "GREETING", "--build-arg", "TOKEN",
+		"--no-cache",
+		"testdata/build",
+	}, input.buildFlags())
+
+	assert.Equal(t, []string{"testdata"}, BuildInput{ContextDir: "testdata"}.buildFlags())
+}
+
+func TestBuildArgPointers(t *testing.T) {
+	pointers := buildArgPointers(map[string]string{"A": "1", "B": "2"})
+	assert.Len(t, pointers, 2)
+	assert.Equal(t, "1", *pointers["A"])
+	assert.Equal(t, "2", *pointers["B"])
+}
+
+func TestBuildImage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+
+	// through the Container interface, the docker backend builds with the BuildImage function
+	err := NewContainer(&NewContainerInput{}).BuildImage(ctx, BuildInput{
+		ContextDir: "testdata/build",
+		Dockerfile: "Dockerfile.test",
+		BuildArgs:  map[string]string{"GREETING": "hello"},
+		Tags:       []string{"act-build-test:latest"},
+		NoCache:    true,
+	})
+	require.NoError(t, err)
+
+	cli, err := GetDockerClient(ctx)
+	require.NoError(t, err)
+	defer cli.Close()
+
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, "act-build-test:latest")
+	require.NoError(t, err)
+	assert.Contains(t, inspect.Config.Env, "GREETING=hello")
+
+	_, err = cli.ImageRemove(ctx, "act-build-test:latest", types.ImageRemoveOptions{Force: true})
+	assert.NoError(t, err)
+}
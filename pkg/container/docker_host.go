@@ -0,0 +1,99 @@
+package container
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultDockerSocket is the socket of a docker daemon running as root
+const DefaultDockerSocket = "/var/run/docker.sock"
+
+// DefaultDockerPipe is the named pipe of the docker daemon on Windows
+const DefaultDockerPipe = "//./pipe/docker_engine"
+
+// socketExists is replaced in tests
+var socketExists = func(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode()&os.ModeSocket != 0
+}
+
+type dockerHostContextKey string
+
+const dockerHostContextKeyVal = dockerHostContextKey("container.dockerHost")
+
+// WithDockerHost adds the address of the docker daemon to the context, it overrides DOCKER_HOST
+func WithDockerHost(ctx context.Context, host string) context.Context {
+	if host == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, dockerHostContextKeyVal, host)
+}
+
+// DockerHost returns the address of the docker daemon, either from the context or from DOCKER_HOST.
+// Without either it is the socket of a rootless daemon if the default socket doesn't exist, else it
+// is empty and the default local socket is used
+func DockerHost(ctx context.Context) string {
+	if host, ok := ctx.Value(dockerHostContextKeyVal).(string); ok {
+		return host
+	}
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host
+	}
+	if socket := DetectDockerSocket(""); socket != DefaultDockerSocket {
+		return "unix://" + socket
+	}
+	return ""
+}
+
+// DetectDockerSocket returns the path of the socket of the local docker daemon: the socket of dockerHost
+// if it is a unix socket or a named pipe, else the default socket if it exists, else the socket of a rootless
+// daemon in XDG_RUNTIME_DIR if that one exists. It falls back to the default socket
+func DetectDockerSocket(dockerHost string) string {
+	if strings.HasPrefix(dockerHost, "unix://") {
+		return strings.TrimPrefix(dockerHost, "unix://")
+	}
+	if strings.HasPrefix(dockerHost, "npipe://") {
+		return strings.TrimPrefix(dockerHost, "npipe://")
+	}
+	if socketExists(DefaultDockerSocket) {
+		return DefaultDockerSocket
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		if socket := filepath.Join(runtimeDir, "docker.sock"); socketExists(socket) {
+			return socket
+		}
+	}
+	return DefaultDockerSocket
+}
+
+// IsNamedPipe returns true if socket is a Windows named pipe like //./pipe/docker_engine
+func IsNamedPipe(socket string) bool {
+	return strings.HasPrefix(strings.ReplaceAll(socket, `\`, "/"), "//./pipe/")
+}
+
+// DockerSocketBind returns the bind of the daemon socket into a container of the platform. Windows containers
+// get the named pipe of the daemon, while Linux containers always get the default socket since the Linux VM of
+// Docker Desktop provides the daemon there even if the daemon is reached through a named pipe on the host
+func DockerSocketBind(socket string, platform string) string {
+	if strings.HasPrefix(platform, "windows/") {
+		if !IsNamedPipe(socket) {
+			socket = DefaultDockerPipe
+		}
+		return socket + ":" + DefaultDockerPipe
+	}
+	if IsNamedPipe(socket) {
+		socket = DefaultDockerSocket
+	}
+	return socket + ":" + DefaultDockerSocket
+}
+
+// IsRemoteDockerHost returns true if the daemon doesn't run on this machine, in which case
+// local paths like the daemon socket can't be mounted into the containers
+func IsRemoteDockerHost(host string) bool {
+	return strings.HasPrefix(host, "tcp://") ||
+		strings.HasPrefix(host, "ssh://") ||
+		strings.HasPrefix(host, "http://") ||
+		strings.HasPrefix(host, "https://")
+}
@@ -0,0 +1,101 @@
+package container
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerHost(t *testing.T) {
+	defer func(old string, ok bool) {
+		if ok {
+			os.Setenv("DOCKER_HOST", old)
+		} else {
+			os.Unsetenv("DOCKER_HOST")
+		}
+	}(os.LookupEnv("DOCKER_HOST"))
+
+	os.Setenv("DOCKER_HOST", "unix:///var/run/docker.sock")
+	ctx := context.Background()
+	assert.Equal(t, "unix:///var/run/docker.sock", DockerHost(ctx))
+	assert.Equal(t, "unix:///var/run/docker.sock", DockerHost(WithDockerHost(ctx, "")))
+	assert.Equal(t, "tcp://docker:2376", DockerHost(WithDockerHost(ctx, "tcp://docker:2376")))
+}
+
+func TestIsRemoteDockerHost(t *testing.T) {
+	assert.False(t, IsRemoteDockerHost(""))
+	assert.False(t, IsRemoteDockerHost("unix:///var/run/docker.sock"))
+	assert.False(t, IsRemoteDockerHost("npipe:////./pipe/docker_engine"))
+	assert.True(t, IsRemoteDockerHost("tcp://docker:2376"))
+	assert.True(t, IsRemoteDockerHost("ssh://user@host"))
+}
+
+func TestDetectDockerSocket(t *testing.T) {
+	defer func(old func(string) bool) { socketExists = old }(socketExists)
+	defer func(old string, ok bool) {
+		if ok {
+			os.Setenv("XDG_RUNTIME_DIR", old)
+		} else {
+			os.Unsetenv("XDG_RUNTIME_DIR")
+		}
+	}(os.LookupEnv("XDG_RUNTIME_DIR"))
+	os.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+	sockets := map[string]bool{}
+	socketExists = func(path string) bool { return sockets[path] }
+
+	assert.Equal(t, DefaultDockerSocket, DetectDockerSocket(""))
+	assert.Equal(t, "/run/user/1000/docker.sock", DetectDockerSocket("unix:///run/user/1000/docker.sock"))
+
+	sockets[filepath.Join("/run/user/1000", "docker.sock")] = true
+	assert.Equal(t, filepath.Join("/run/user/1000", "docker.sock"), DetectDockerSocket(""))
+	assert.Equal(t, filepath.Join("/run/user/1000", "docker.sock"), DetectDockerSocket("tcp://docker:2376"))
+
+	sockets[DefaultDockerSocket] = true
+	assert.Equal(t, DefaultDockerSocket, DetectDockerSocket(""))
+}
+
+func TestDetectDockerSocketNamedPipe(t *testing.T) {
+	assert.Equal(t, DefaultDockerPipe, DetectDockerSocket("npipe:////./pipe/docker_engine"))
+	assert.True(t, IsNamedPipe(DefaultDockerPipe))
+	assert.True(t, IsNamedPipe(`\\.\pipe\docker_engine`))
+	assert.False(t, IsNamedPipe(DefaultDockerSocket))
+}
+
+func TestDockerSocketBind(t *testing.T) {
+	assert.Equal(t, "/var/run/docker.sock:/var/run/docker.sock", DockerSocketBind(DefaultDockerSocket, ""))
+	assert.Equal(t, "/run/user/1000/docker.sock:/var/run/docker.sock", DockerSocketBind("/run/user/1000/docker.sock", "linux/amd64"))
+	assert.Equal(t, "/var/run/docker.sock:/var/run/docker.sock", DockerSocketBind(DefaultDockerPipe, "linux/amd64"))
+	assert.Equal(t, "//./pipe/docker_engine://./pipe/docker_engine", DockerSocketBind(DefaultDockerPipe, "windows/amd64"))
+	assert.Equal(t, "//./pipe/docker_engine://./pipe/docker_engine", DockerSocketBind(DefaultDockerSocket, "windows/amd64"))
+}
+
+func TestDockerHostRootless(t *testing.T) {
+	defer func(old func(string) bool) { socketExists = old }(socketExists)
+	defer func(old string, ok bool) {
+		if ok {
+			os.Setenv("DOCKER_HOST", old)
+		} else {
+			os.Unsetenv("DOCKER_HOST")
+		}
+	}(os.LookupEnv("DOCKER_HOST"))
+	defer func(old string, ok bool) {
+		if ok {
+			os.Setenv("XDG_RUNTIME_DIR", old)
+		} else {
+			os.Unsetenv("XDG_RUNTIME_DIR")
+		}
+	}(os.LookupEnv("XDG_RUNTIME_DIR"))
+	os.Unsetenv("DOCKER_HOST")
+	os.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+	rootless := filepath.Join("/run/user/1000", "docker.sock")
+	socketExists = func(path string) bool { return path == rootless }
+	assert.Equal(t, "unix://"+rootless, DockerHost(context.Background()))
+
+	socketExists = func(path string) bool { return path == DefaultDockerSocket }
+	assert.Equal(t, "", DockerHost(context.Background()))
+}
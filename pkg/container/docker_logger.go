@@ -18,8 +18,12 @@ type dockerMessage struct {
 	ErrorDetail struct {
 		Message string
 	}
-	Status   string `json:"status"`
-	Progress string `json:"progress"`
+	Status         string `json:"status"`
+	Progress       string `json:"progress"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
 }
 
 const logPrefix = "  \U0001F433  "
@@ -77,7 +81,9 @@ func streamDockerOutput(ctx context.Context, dockerResponse io.Reader) {
 }
 */
 
-func logDockerResponse(logger logrus.FieldLogger, dockerResponse io.ReadCloser, isError bool) error {
+// logDockerResponse logs the messages of a docker response, the download progress of the layers
+// is reported to progress if it isn't nil
+func logDockerResponse(logger logrus.FieldLogger, dockerResponse io.ReadCloser, isError bool, progress *progressReporter) error {
 	if dockerResponse == nil {
 		return nil
 	}
@@ -95,6 +101,8 @@ func logDockerResponse(logger logrus.FieldLogger, dockerResponse io.ReadCloser,
 		msg.ErrorDetail.Message = ""
 		msg.Status = ""
 		msg.Progress = ""
+		msg.ProgressDetail.Current = 0
+		msg.ProgressDetail.Total = 0
 
 		if err := json.Unmarshal(line, &msg); err != nil {
 			writeLog(logger, false, "Unable to unmarshal line [%s] ==> %v", string(line), err)
@@ -111,6 +119,17 @@ func logDockerResponse(logger logrus.FieldLogger, dockerResponse io.ReadCloser,
 			return errors.New(msg.Error)
 		}
 
+		if progress != nil && msg.ID != "" {
+			switch msg.Status {
+			case "Downloading":
+				if msg.ProgressDetail.Total > 0 {
+					progress.setLayer(msg.ID, msg.ProgressDetail.Current, msg.ProgressDetail.Total)
+				}
+			case "Download complete", "Pull complete":
+				progress.completeLayer(msg.ID)
+			}
+		}
+
 		if msg.Status != "" {
 			if msg.Progress != "" {
 				writeLog(logger, isError, "%s :: %s :: %s\n", msg.Status, msg.ID, msg.Progress)
@@ -0,0 +1,66 @@
+//go:build linux || darwin || windows || openbsd
+// +build linux darwin windows openbsd
+
+package container
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ankit-arora/act/pkg/common"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// NewDockerPruneFailedExecutor removes the job containers act kept after a failed run, with their volumes.
+// The result is read from the ResultPath of the containers, the ones without it are left alone
+func NewDockerPruneFailedExecutor() common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		cli, err := GetDockerClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		containers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+			All:     true,
+			Filters: filters.NewArgs(filters.Arg("label", ContainerLabel)),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, c := range containers {
+			if len(c.Names) == 0 {
+				continue
+			}
+			name := strings.TrimPrefix(c.Names[0], "/")
+			cr := &containerReference{cli: cli, id: c.ID, input: &NewContainerInput{Name: name}}
+			result, err := cr.ReadFile(ctx, ResultPath)
+			if IsFileNotFound(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if strings.TrimSpace(string(result)) != "failure" {
+				continue
+			}
+
+			logger.Infof("%sdocker rm %s", logPrefix, name)
+			if common.Dryrun(ctx) {
+				continue
+			}
+			if err := cli.ContainerRemove(ctx, c.ID, types.ContainerRemoveOptions{
+				RemoveVolumes: true,
+				Force:         true,
+			}); err != nil {
+				return err
+			}
+			if err := NewDockerVolumeRemoveExecutor(name, false).Finally(NewDockerVolumeRemoveExecutor(name+"-env", false))(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
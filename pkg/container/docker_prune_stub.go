@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !windows && !openbsd
+// +build !linux,!darwin,!windows,!openbsd
+
+package container
+
+import (
+	"context"
+
+	"github.com/ankit-arora/act/pkg/common"
+)
+
+func NewDockerPruneFailedExecutor() common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
@@ -52,6 +54,16 @@ func NewDockerPullExecutor(input NewDockerPullExecutorInput) common.Executor {
 			return nil
 		}
 
+		// images that exist in any architecture were there before the run and are left alone on cleanup
+		existed := true
+		if tracksPulledImages(ctx) {
+			var err error
+			existed, err = ImageExistsLocally(ctx, input.Image, "any")
+			if err != nil {
+				return errors.WithMessagef(err, "unable to determine if image already exists for image %q", input.Image)
+			}
+		}
+
 		imageRef := cleanImage(input.Image)
 		logger.Debugf("pulling image '%v' (%s)", imageRef, input.Platform)
 
@@ -68,10 +80,18 @@ func NewDockerPullExecutor(input NewDockerPullExecutorInput) common.Executor {
 
 		reader, err := cli.ImagePull(ctx, imageRef, imagePullOptions)
 
-		_ = logDockerResponse(logger, reader, err != nil)
+		progress := newProgressReporter(ctx, fmt.Sprintf("Pulling %s", imageRef))
+		respErr := logDockerResponse(logger, reader, err != nil, progress)
+		progress.stop()
+		if err == nil && isPlatformUnavailable(respErr) {
+			err = respErr
+		}
 		if err != nil {
 			return err
 		}
+		if !existed {
+			recordPulledImage(ctx, input.Image)
+		}
 		return nil
 	}
 }
@@ -116,6 +136,15 @@ func getImagePullOptions(ctx context.Context, input NewDockerPullExecutorInput)
 	return imagePullOptions, nil
 }
 
+// isPlatformUnavailable returns true if the error of a pull says the image doesn't exist for the requested platform
+func isPlatformUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "no matching manifest for") || strings.Contains(msg, "does not match the specified platform")
+}
+
 func cleanImage(image string) string {
 	ref, err := reference.ParseAnyReference(image)
 	if err != nil {
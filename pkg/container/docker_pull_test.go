@@ -4,6 +4,7 @@ package container
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/docker/cli/cli/config"
@@ -61,3 +62,10 @@ func TestGetImagePullOptions(t *testing.T) {
 	assert.Nil(t, err, "Failed to create ImagePullOptions")
 	assert.Equal(t, "eyJ1c2VybmFtZSI6InVzZXJuYW1lIiwicGFzc3dvcmQiOiJwYXNzd29yZFxuIiwic2VydmVyYWRkcmVzcyI6Imh0dHBzOi8vaW5kZXguZG9ja2VyLmlvL3YxLyJ9", options.RegistryAuth, "RegistryAuth should be taken from local docker config")
 }
+
+func TestIsPlatformUnavailable(t *testing.T) {
+	assert.False(t, isPlatformUnavailable(nil))
+	assert.False(t, isPlatformUnavailable(errors.New("pull access denied for foo, repository does not exist")))
+	assert.True(t, isPlatformUnavailable(errors.New("no matching manifest for linux/arm64/v8 in the manifest list entries")))
+	assert.True(t, isPlatformUnavailable(errors.New("image with reference foo was found but does not match the specified platform: wanted linux/arm64, actual: linux/amd64")))
+}
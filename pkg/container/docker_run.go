@@ -12,15 +12,13 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
 	"time"
 
-	"github.com/go-git/go-billy/v5/helper/polyfill"
-	"github.com/go-git/go-billy/v5/osfs"
-	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
 	"github.com/joho/godotenv"
 
 	"github.com/docker/cli/cli/connhelper"
@@ -28,6 +26,7 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/stdcopy"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 
@@ -48,7 +47,7 @@ func NewContainer(input *NewContainerInput) Container {
 
 // supportsContainerImagePlatform returns true if the underlying Docker server
 // API version is 1.41 and beyond
-func supportsContainerImagePlatform(ctx context.Context, cli *client.Client) bool {
+func supportsContainerImagePlatform(ctx context.Context, cli client.APIClient) bool {
 	logger := common.Logger(ctx)
 	ver, err := cli.ServerVersion(ctx)
 	if err != nil {
@@ -93,15 +92,25 @@ func (cr *containerReference) Start(attach bool) common.Executor {
 func (cr *containerReference) Pull(forcePull bool) common.Executor {
 	return common.
 		NewInfoExecutor("%sdocker pull image=%s platform=%s username=%s forcePull=%t", logPrefix, cr.input.Image, cr.input.Platform, cr.input.Username, forcePull).
-		Then(
-			NewDockerPullExecutor(NewDockerPullExecutorInput{
+		Then(func(ctx context.Context) error {
+			pullInput := NewDockerPullExecutorInput{
 				Image:     cr.input.Image,
 				ForcePull: forcePull,
 				Platform:  cr.input.Platform,
 				Username:  cr.input.Username,
 				Password:  cr.input.Password,
-			}),
-		)
+			}
+			err := NewDockerPullExecutor(pullInput)(ctx)
+			if cr.input.Platform == "" || cr.input.StrictPlatform || !isPlatformUnavailable(err) {
+				return err
+			}
+
+			// the container is created without a platform as well, else docker refuses the image
+			common.Logger(ctx).Warnf("%simage %s isn't available for %s, falling back to its native platform: %v", logPrefix, cr.input.Image, cr.input.Platform, err)
+			cr.input.Platform = ""
+			pullInput.Platform = ""
+			return NewDockerPullExecutor(pullInput)(ctx)
+		})
 }
 
 func (cr *containerReference) Copy(destPath string, files ...*FileEntry) common.Executor {
@@ -112,19 +121,56 @@ func (cr *containerReference) Copy(destPath string, files ...*FileEntry) common.
 	).IfNot(common.Dryrun)
 }
 
-func (cr *containerReference) CopyDir(destPath string, srcPath string, useGitIgnore bool) common.Executor {
+func (cr *containerReference) CopyDir(destPath string, srcPath string, useGitIgnore bool, excludes ...string) common.Executor {
 	return common.NewPipelineExecutor(
 		common.NewInfoExecutor("%sdocker cp src=%s dst=%s", logPrefix, srcPath, destPath),
 		cr.Exec([]string{"mkdir", "-p", destPath}, "", nil, "", ""),
-		cr.copyDir(destPath, srcPath, useGitIgnore),
+		cr.copyDir(destPath, srcPath, useGitIgnore, excludes),
+	).IfNot(common.Dryrun)
+}
+
+func (cr *containerReference) CopyGlob(destPath string, srcPath string, patterns ...string) common.Executor {
+	return common.NewPipelineExecutor(
+		common.NewInfoExecutor("%sdocker cp src=%s patterns=%v dst=%s", logPrefix, srcPath, patterns, destPath),
+		cr.Exec([]string{"mkdir", "-p", destPath}, "", nil, "", ""),
+		cr.copyGlob(destPath, srcPath, patterns),
 	).IfNot(common.Dryrun)
 }
 
+func (cr *containerReference) MkdirAll(dirPath string, mode os.FileMode) common.Executor {
+	return common.NewPipelineExecutor(
+		cr.connect(),
+		cr.find(),
+		cr.mkdirAll(cr.getWorkdir(dirPath), mode),
+	).IfNot(common.Dryrun)
+}
+
+func (cr *containerReference) WriteFile(filePath string, content []byte, mode os.FileMode) common.Executor {
+	// the archive is extracted at the root, docker creates any missing parent directories
+	return cr.Copy("/", &FileEntry{
+		Name: strings.TrimPrefix(cr.getWorkdir(filePath), "/"),
+		Mode: int64(mode.Perm()),
+		Body: string(content),
+	})
+}
+
 func (cr *containerReference) GetContainerArchive(ctx context.Context, srcPath string) (io.ReadCloser, error) {
 	a, _, err := cr.cli.CopyFromContainer(ctx, cr.id, srcPath)
 	return a, err
 }
 
+func (cr *containerReference) ReadFile(ctx context.Context, srcPath string) ([]byte, error) {
+	archive, err := cr.GetContainerArchive(ctx, srcPath)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, &FileNotFoundError{Path: srcPath}
+		}
+		return nil, errors.WithStack(err)
+	}
+	defer archive.Close()
+	return readFileFromArchive(archive, srcPath)
+}
+
 func (cr *containerReference) UpdateFromEnv(srcPath string, env *map[string]string) common.Executor {
 	return parseEnvFile(cr, srcPath, env).IfNot(common.Dryrun)
 }
@@ -155,8 +201,46 @@ func (cr *containerReference) Remove() common.Executor {
 	).IfNot(common.Dryrun)
 }
 
+func (cr *containerReference) Commit(ctx context.Context, ref string) error {
+	if common.Dryrun(ctx) {
+		return nil
+	}
+	if err := common.NewPipelineExecutor(cr.connect(), cr.find())(ctx); err != nil {
+		return err
+	}
+	if cr.id == "" {
+		return fmt.Errorf("container '%s' not found", cr.input.Name)
+	}
+	common.Logger(ctx).Debugf("Committing container %s to %s", cr.id, ref)
+	_, err := cr.cli.ContainerCommit(ctx, cr.id, types.ContainerCommitOptions{
+		Reference: ref,
+		Comment:   "snapshot created by act",
+	})
+	return errors.WithStack(err)
+}
+
+func (cr *containerReference) Export(ctx context.Context, w io.Writer) error {
+	if common.Dryrun(ctx) {
+		return nil
+	}
+	if err := common.NewPipelineExecutor(cr.connect(), cr.find())(ctx); err != nil {
+		return err
+	}
+	if cr.id == "" {
+		return fmt.Errorf("container '%s' not found", cr.input.Name)
+	}
+	common.Logger(ctx).Debugf("Exporting container %s", cr.id)
+	rc, err := cr.cli.ContainerExport(ctx, cr.id)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer rc.Close()
+	_, err = io.Copy(w, rc)
+	return errors.WithStack(err)
+}
+
 type containerReference struct {
-	cli   *client.Client
+	cli   client.APIClient
 	id    string
 	input *NewContainerInput
 }
@@ -168,7 +252,7 @@ func GetDockerClient(ctx context.Context) (*client.Client, error) {
 	// TODO: this should maybe need to be a global option, not hidden in here?
 	//       though i'm not sure how that works out when there's another Executor :D
 	//		 I really would like something that works on OSX native for eg
-	dockerHost := os.Getenv("DOCKER_HOST")
+	dockerHost := DockerHost(ctx)
 
 	if strings.HasPrefix(dockerHost, "ssh://") {
 		var helper *connhelper.ConnectionHelper
@@ -181,6 +265,9 @@ func GetDockerClient(ctx context.Context) (*client.Client, error) {
 			client.WithHost(helper.Host),
 			client.WithDialContext(helper.Dialer),
 		)
+	} else if dockerHost != "" {
+		// FromEnv still applies DOCKER_TLS_VERIFY and DOCKER_CERT_PATH for remote daemons
+		cli, err = client.NewClientWithOpts(client.FromEnv, client.WithHost(dockerHost))
 	} else {
 		cli, err = client.NewClientWithOpts(client.FromEnv)
 	}
@@ -192,6 +279,21 @@ func GetDockerClient(ctx context.Context) (*client.Client, error) {
 	return cli, err
 }
 
+// PingDockerDaemon verifies the docker daemon is reachable, so a stopped daemon is reported
+// before the job starts instead of failing somewhere in the middle of it
+func PingDockerDaemon(ctx context.Context) error {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to create a docker client, check DOCKER_HOST: %w", err)
+	}
+	defer cli.Close()
+
+	if _, err := cli.Ping(ctx); err != nil {
+		return fmt.Errorf("cannot connect to the docker daemon at %s, start docker or point DOCKER_HOST to a running daemon: %w", cli.DaemonHost(), err)
+	}
+	return nil
+}
+
 func (cr *containerReference) connect() common.Executor {
 	return func(ctx context.Context) error {
 		if cr.cli != nil {
@@ -242,6 +344,9 @@ func (cr *containerReference) find() common.Executor {
 	}
 }
 
+// defaultStopTimeout is the time docker gives a container to exit after SIGTERM by default
+const defaultStopTimeout = 10 * time.Second
+
 func (cr *containerReference) remove() common.Executor {
 	return func(ctx context.Context) error {
 		if cr.id == "" {
@@ -249,6 +354,18 @@ func (cr *containerReference) remove() common.Executor {
 		}
 
 		logger := common.Logger(ctx)
+		// give the processes a chance to exit gracefully before the removal kills them, an idle entrypoint
+		// running as PID 1 ignores SIGTERM, stopping it would only wait for the timeout
+		if !cr.input.Idle || cr.input.Init {
+			timeout := cr.input.StopTimeout
+			if timeout <= 0 {
+				timeout = defaultStopTimeout
+			}
+			if err := cr.cli.ContainerStop(ctx, cr.id, &timeout); err != nil {
+				logger.Debugf("Unable to stop container %v: %v", cr.id, err)
+			}
+		}
+
 		err := cr.cli.ContainerRemove(ctx, cr.id, types.ContainerRemoveOptions{
 			RemoveVolumes: true,
 			Force:         true,
@@ -279,6 +396,8 @@ func (cr *containerReference) create(capAdd []string, capDrop []string) common.E
 			Env:        input.Env,
 			Tty:        containerAllocateTerminal,
 			Hostname:   input.Hostname,
+			User:       input.User,
+			Labels:     input.Labels,
 		}
 
 		mounts := make([]mount.Mount, 0)
@@ -303,7 +422,7 @@ func (cr *containerReference) create(capAdd []string, capDrop []string) common.E
 				OS:           desiredPlatform[0],
 			}
 		}
-		resp, err := cr.cli.ContainerCreate(ctx, config, &container.HostConfig{
+		hostConfig := &container.HostConfig{
 			CapAdd:      capAdd,
 			CapDrop:     capDrop,
 			Binds:       input.Binds,
@@ -311,7 +430,12 @@ func (cr *containerReference) create(capAdd []string, capDrop []string) common.E
 			NetworkMode: container.NetworkMode(input.NetworkMode),
 			Privileged:  input.Privileged,
 			UsernsMode:  container.UsernsMode(input.UsernsMode),
-		}, nil, platSpecs, input.Name)
+		}
+		if input.Init {
+			// an init process reaps the zombies left by background processes of the steps
+			hostConfig.Init = &input.Init
+		}
+		resp, err := cr.cli.ContainerCreate(ctx, config, hostConfig, nil, platSpecs, input.Name)
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -457,7 +581,17 @@ func (cr *containerReference) exec2(ctx context.Context, cmd []string, env map[s
 	envList := getEnvListFromMap(env)
 	wd := cr.getWorkdir(workdir)
 	logger.Debugf("Working directory '%s'", wd)
-	idResp, err := cr.cli.ContainerExecCreate(ctx, cr.id, types.ExecConfig{
+	// docker only fails to start the command in a missing directory with a runtime error, so check it first
+	if workdir != "" {
+		stat, err := cr.cli.ContainerStatPath(ctx, cr.id, wd)
+		if client.IsErrNotFound(err) || (err == nil && !stat.Mode.IsDir()) {
+			return &WorkdirNotFoundError{Path: wd}
+		} else if err != nil {
+			logger.Debugf("Unable to check the working directory '%s': %v", wd, err)
+		}
+	}
+	stdin := ExecStdin(ctx)
+	execID, resp, err := cr.startExec(ctx, types.ExecConfig{
 		User:         user,
 		Cmd:          cmd,
 		WorkingDir:   wd,
@@ -465,14 +599,7 @@ func (cr *containerReference) exec2(ctx context.Context, cmd []string, env map[s
 		Tty:          containerAllocateTerminal,
 		AttachStderr: true,
 		AttachStdout: true,
-		AttachStdin:  containerAllocateTerminal,
-	})
-	if err != nil {
-		return errors.WithStack(err)
-	}
-
-	resp, err := cr.cli.ContainerExecAttach(ctx, idResp.ID, types.ExecStartCheck{
-		Tty: containerAllocateTerminal,
+		AttachStdin:  containerAllocateTerminal || stdin != nil,
 	})
 	if err != nil {
 		return errors.WithStack(err)
@@ -489,8 +616,20 @@ func (cr *containerReference) exec2(ctx context.Context, cmd []string, env map[s
 		errWriter = os.Stderr
 	}
 
-	if containerAllocateTerminal {
+	if containerAllocateTerminal || stdin != nil {
 		go func() {
+			if stdin != nil {
+				if _, err := io.Copy(resp.Conn, stdin); err != nil {
+					logger.Debugf("Failed to write the stdin of the exec: %v", err)
+				}
+				if !containerAllocateTerminal {
+					if err := resp.CloseWrite(); err != nil {
+						logger.Debugf("Failed to close the stdin of the exec: %v", err)
+					}
+					return
+				}
+			}
+			// the input of a terminal is ended with EOT
 			c := 1
 			var err error
 			for c == 1 && err == nil {
@@ -509,7 +648,7 @@ func (cr *containerReference) exec2(ctx context.Context, cmd []string, env map[s
 		logger.Error(err)
 	}
 
-	inspectResp, err := cr.cli.ContainerExecInspect(ctx, idResp.ID)
+	inspectResp, err := cr.cli.ContainerExecInspect(ctx, execID)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -521,6 +660,52 @@ func (cr *containerReference) exec2(ctx context.Context, cmd []string, env map[s
 	return fmt.Errorf("exit with `FAILURE`: %v", inspectResp.ExitCode)
 }
 
+// execAttempts is how often creating an exec is tried if the daemon fails transiently
+const execAttempts = 3
+
+// execRetryDelay is the delay before the first retry of an exec, it doubles with every further retry
+var execRetryDelay = time.Second
+
+// isTransientExecError reports if creating an exec failed because of the daemon rather than the command: the
+// daemon couldn't be reached, was unavailable (503) or timed out. Errors of the command itself like a non-zero
+// exit code are never retried
+func isTransientExecError(err error) bool {
+	return client.IsErrConnectionFailed(err) || errdefs.IsUnavailable(err) || errdefs.IsDeadline(err)
+}
+
+// startExec creates an exec and attaches to it, which starts the command. Transient errors creating the exec are
+// retried, an exec that wasn't created hasn't run anything. The attach isn't retried, the daemon may have started
+// the command before it failed and running it twice isn't safe
+func (cr *containerReference) startExec(ctx context.Context, config types.ExecConfig) (string, types.HijackedResponse, error) {
+	delay := execRetryDelay
+	var idResp types.IDResponse
+	for attempt := 1; ; attempt++ {
+		var err error
+		idResp, err = cr.cli.ContainerExecCreate(ctx, cr.id, config)
+		if err == nil {
+			break
+		}
+		if attempt == execAttempts || !isTransientExecError(err) {
+			return "", types.HijackedResponse{}, err
+		}
+		common.Logger(ctx).Warnf("Unable to create exec, retrying in %s: %v", delay, err)
+		select {
+		case <-ctx.Done():
+			return "", types.HijackedResponse{}, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+
+	resp, err := cr.cli.ContainerExecAttach(ctx, idResp.ID, types.ExecStartCheck{
+		Tty: config.Tty,
+	})
+	if err != nil {
+		return "", types.HijackedResponse{}, err
+	}
+	return idResp.ID, resp, nil
+}
+
 func (cr *containerReference) exec(cmd []string, env map[string]string, user, workdir string) common.Executor {
 	return func(ctx context.Context) error {
 		logger := common.Logger(ctx)
@@ -546,115 +731,159 @@ func (cr *containerReference) exec(cmd []string, env map[string]string, user, wo
 }
 
 // nolint: gocyclo
-func (cr *containerReference) copyDir(dstPath string, srcPath string, useGitIgnore bool) common.Executor {
+func (cr *containerReference) copyDir(dstPath string, srcPath string, useGitIgnore bool, excludes []string) common.Executor {
 	return func(ctx context.Context) error {
-		logger := common.Logger(ctx)
-		tarFile, err := ioutil.TempFile("", "act")
-		if err != nil {
-			return err
-		}
-		log.Debugf("Writing tarball %s from %s", tarFile.Name(), srcPath)
-		defer tarFile.Close()
-		defer os.Remove(tarFile.Name())
-		tw := tar.NewWriter(tarFile)
-
 		srcPrefix := filepath.Dir(srcPath)
 		if !strings.HasSuffix(srcPrefix, string(filepath.Separator)) {
 			srcPrefix += string(filepath.Separator)
 		}
 		log.Debugf("Stripping prefix:%s src:%s", srcPrefix, srcPath)
 
-		var ignorer gitignore.Matcher
-		if useGitIgnore {
-			ps, err := gitignore.ReadPatterns(polyfill.New(osfs.New(srcPath)), nil)
-			if err != nil {
-				log.Debugf("Error loading .gitignore: %v", err)
-			}
+		ignorer := newCopyMatcher(srcPath, useGitIgnore, excludes)
 
-			ignorer = gitignore.NewMatcher(ps)
-		}
-
-		err = filepath.Walk(srcPath, func(file string, fi os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-
-			sansPrefix := strings.TrimPrefix(file, srcPrefix)
-			split := strings.Split(sansPrefix, string(filepath.Separator))
-			if ignorer != nil && ignorer.Match(split, fi.IsDir()) {
-				if fi.IsDir() {
-					return filepath.SkipDir
+		return cr.copyTarball(ctx, dstPath, srcPath, func(add tarFileAdder) error {
+			return filepath.Walk(srcPath, func(file string, fi os.FileInfo, err error) error {
+				if err != nil {
+					return err
 				}
-				return nil
-			}
 
-			// return on non-regular files (thanks to [kumo](https://medium.com/@komuw/just-like-you-did-fbdd7df829d3) for this suggested update)
-			linkName := fi.Name()
-			if fi.Mode()&os.ModeSymlink == os.ModeSymlink {
-				linkName, err = os.Readlink(file)
-				if err != nil {
-					return errors.WithMessagef(err, "unable to readlink %s", file)
+				sansPrefix := strings.TrimPrefix(file, srcPrefix)
+				split := strings.Split(sansPrefix, string(filepath.Separator))
+				if ignorer != nil && ignorer.Match(split, fi.IsDir()) {
+					if fi.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
 				}
-			} else if !fi.Mode().IsRegular() {
-				return nil
-			}
 
-			// create a new dir/file header
-			header, err := tar.FileInfoHeader(fi, linkName)
-			if err != nil {
-				return err
-			}
+				// update the name to correctly reflect the desired destination when untaring
+				return add(file, filepath.ToSlash(sansPrefix), fi)
+			})
+		})
+	}
+}
 
-			// update the name to correctly reflect the desired destination when untaring
-			header.Name = filepath.ToSlash(sansPrefix)
-			header.Mode = int64(fi.Mode())
-			header.ModTime = fi.ModTime()
+func (cr *containerReference) copyGlob(dstPath string, srcPath string, patterns []string) common.Executor {
+	return func(ctx context.Context) error {
+		files, err := globFiles(srcPath, patterns)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Copying %d files of %s matching %v", len(files), srcPath, patterns)
 
-			// write the header
-			if err := tw.WriteHeader(header); err != nil {
-				return err
+		return cr.copyTarball(ctx, dstPath, srcPath, func(add tarFileAdder) error {
+			for _, name := range files {
+				file := filepath.Join(srcPath, filepath.FromSlash(name))
+				fi, err := os.Lstat(file)
+				if err != nil {
+					return err
+				}
+				if err := add(file, name, fi); err != nil {
+					return err
+				}
 			}
+			return nil
+		})
+	}
+}
 
-			// symlinks don't need to be copied
-			if fi.Mode()&os.ModeSymlink == os.ModeSymlink {
-				return nil
-			}
+// tarFileAdder adds the file to the tarball under name
+type tarFileAdder func(file string, name string, fi os.FileInfo) error
 
-			// open files for taring
-			f, err := os.Open(file)
+// copyTarball writes the files passed to add by collect to a tarball and extracts it at dstPath in the container
+func (cr *containerReference) copyTarball(ctx context.Context, dstPath string, srcPath string, collect func(add tarFileAdder) error) error {
+	logger := common.Logger(ctx)
+	tarFile, err := ioutil.TempFile("", "act")
+	if err != nil {
+		return err
+	}
+	log.Debugf("Writing tarball %s from %s", tarFile.Name(), srcPath)
+	defer tarFile.Close()
+	defer os.Remove(tarFile.Name())
+	archiveProgress := newProgressReporter(ctx, fmt.Sprintf("Archiving %s", srcPath))
+	defer archiveProgress.stop()
+	tw := tar.NewWriter(&progressWriter{Writer: tarFile, progress: archiveProgress})
+
+	var copiedFiles, copiedBytes int64
+	err = collect(func(file string, name string, fi os.FileInfo) error {
+		// return on non-regular files (thanks to [kumo](https://medium.com/@komuw/just-like-you-did-fbdd7df829d3) for this suggested update)
+		linkName := fi.Name()
+		if fi.Mode()&os.ModeSymlink == os.ModeSymlink {
+			linkName, err = os.Readlink(file)
 			if err != nil {
-				return err
-			}
-
-			// copy file data into tar writer
-			if _, err := io.Copy(tw, f); err != nil {
-				return err
+				return errors.WithMessagef(err, "unable to readlink %s", file)
 			}
-
-			// manually close here after each file operation; deferring would cause each file close
-			// to wait until all operations have completed.
-			f.Close()
-
+		} else if !fi.Mode().IsRegular() {
 			return nil
-		})
+		}
+
+		// create a new dir/file header
+		header, err := tar.FileInfoHeader(fi, linkName)
 		if err != nil {
 			return err
 		}
-		if err := tw.Close(); err != nil {
+
+		header.Name = name
+		header.Mode = int64(fi.Mode())
+		header.ModTime = fi.ModTime()
+
+		// write the header
+		if err := tw.WriteHeader(header); err != nil {
 			return err
 		}
 
-		logger.Debugf("Extracting content from '%s' to '%s'", tarFile.Name(), dstPath)
-		_, err = tarFile.Seek(0, 0)
+		// symlinks don't need to be copied
+		if fi.Mode()&os.ModeSymlink == os.ModeSymlink {
+			copiedFiles++
+			return nil
+		}
+
+		// open files for taring
+		f, err := os.Open(file)
 		if err != nil {
-			return errors.WithStack(err)
+			return err
 		}
-		err = cr.cli.CopyToContainer(ctx, cr.id, dstPath, tarFile, types.CopyToContainerOptions{})
+
+		// copy file data into tar writer
+		n, err := io.Copy(tw, f)
 		if err != nil {
-			return errors.WithStack(err)
+			return err
 		}
+		copiedFiles++
+		copiedBytes += n
+
+		// manually close here after each file operation; deferring would cause each file close
+		// to wait until all operations have completed.
+		f.Close()
+
 		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	archiveProgress.stop()
+
+	logger.Debugf("Extracting content from '%s' to '%s'", tarFile.Name(), dstPath)
+	size, err := tarFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = tarFile.Seek(0, 0)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	uploadProgress := newProgressReporter(ctx, fmt.Sprintf("Copying %s to %s", srcPath, dstPath))
+	uploadProgress.setTotal(size)
+	defer uploadProgress.stop()
+	err = cr.cli.CopyToContainer(ctx, cr.id, dstPath, &progressReader{Reader: tarFile, progress: uploadProgress}, types.CopyToContainerOptions{})
+	if err != nil {
+		return errors.WithStack(err)
 	}
+	RecordCopy(ctx, copiedFiles, copiedBytes)
+	return nil
 }
 
 func (cr *containerReference) copyContent(dstPath string, files ...*FileEntry) common.Executor {
@@ -685,6 +914,35 @@ func (cr *containerReference) copyContent(dstPath string, files ...*FileEntry) c
 		if err != nil {
 			return errors.WithStack(err)
 		}
+		for _, file := range files {
+			RecordCopy(ctx, 1, int64(len(file.Body)))
+		}
+		return nil
+	}
+}
+
+func (cr *containerReference) mkdirAll(dirPath string, mode os.FileMode) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		hdr := &tar.Header{
+			Name:     strings.TrimPrefix(path.Clean(dirPath), "/") + "/",
+			Mode:     int64(mode.Perm()),
+			Typeflag: tar.TypeDir,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+
+		logger.Debugf("Creating directory '%s'", dirPath)
+		err := cr.cli.CopyToContainer(ctx, cr.id, "/", &buf, types.CopyToContainerOptions{})
+		if err != nil {
+			return errors.WithStack(err)
+		}
 		return nil
 	}
 }
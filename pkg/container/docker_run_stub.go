@@ -2,7 +2,17 @@
 
 package container
 
+import (
+	"context"
+	"errors"
+)
+
 // NewContainer creates a reference to a container
 func NewContainer(input *NewContainerInput) Container {
 	return nil
 }
+
+// PingDockerDaemon verifies the docker daemon is reachable
+func PingDockerDaemon(ctx context.Context) error {
+	return errors.New("Unsupported Operation")
+}
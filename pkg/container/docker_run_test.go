@@ -1,9 +1,20 @@
 package container
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"io"
+	"net"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,3 +56,181 @@ func TestDocker(t *testing.T) {
 		"CONFLICT_VAR":    "I_EXIST_IN_MULTIPLE_PLACES",
 	}, env)
 }
+
+// flakyExecClient fails to create the first execs with createErr and every attach with attachErr, the
+// commands exit with exitCode
+type flakyExecClient struct {
+	client.APIClient
+	createErr error
+	failures  int
+	attachErr error
+	exitCode  int
+	creates   int
+	attaches  int
+	inspected int
+}
+
+func (c *flakyExecClient) ContainerExecCreate(ctx context.Context, container string, config types.ExecConfig) (types.IDResponse, error) {
+	c.creates++
+	if c.creates <= c.failures {
+		return types.IDResponse{}, c.createErr
+	}
+	return types.IDResponse{ID: "exec"}, nil
+}
+
+func (c *flakyExecClient) ContainerExecAttach(ctx context.Context, execID string, config types.ExecStartCheck) (types.HijackedResponse, error) {
+	c.attaches++
+	if c.attachErr != nil {
+		return types.HijackedResponse{}, c.attachErr
+	}
+	conn, other := net.Pipe()
+	other.Close()
+	return types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(&bytes.Buffer{})}, nil
+}
+
+func (c *flakyExecClient) ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error) {
+	c.inspected++
+	return types.ContainerExecInspect{ExitCode: c.exitCode}, nil
+}
+
+func TestDockerExecRetry(t *testing.T) {
+	defer func(delay time.Duration) { execRetryDelay = delay }(execRetryDelay)
+	execRetryDelay = time.Millisecond
+
+	for _, tt := range []struct {
+		name    string
+		cli     *flakyExecClient
+		creates int
+		started bool
+		err     bool
+	}{
+		{"transient unavailable", &flakyExecClient{createErr: errdefs.Unavailable(errors.New("daemon busy")), failures: 2}, 3, true, false},
+		{"transient connection", &flakyExecClient{createErr: client.ErrorConnectionFailed("unix:///var/run/docker.sock"), failures: 1}, 2, true, false},
+		{"attempts exhausted", &flakyExecClient{createErr: errdefs.Unavailable(errors.New("daemon busy")), failures: 5}, 3, false, true},
+		{"not transient", &flakyExecClient{createErr: errdefs.Conflict(errors.New("container is not running")), failures: 1}, 1, false, true},
+		{"non-zero exit", &flakyExecClient{exitCode: 1}, 1, true, true},
+		{"attach not retried", &flakyExecClient{attachErr: errdefs.Unavailable(errors.New("daemon busy"))}, 1, false, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := &containerReference{
+				cli:   tt.cli,
+				id:    "container",
+				input: &NewContainerInput{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}},
+			}
+			err := cr.exec([]string{"true"}, map[string]string{}, "", "")(context.Background())
+			assert.Equal(t, tt.err, err != nil, "%v", err)
+			assert.Equal(t, tt.creates, tt.cli.creates)
+			if tt.cli.createErr == nil {
+				assert.Equal(t, 1, tt.cli.attaches)
+			}
+			if tt.started {
+				assert.Equal(t, 1, tt.cli.inspected)
+			} else {
+				assert.Equal(t, 0, tt.cli.inspected)
+			}
+		})
+	}
+}
+
+// echoExecClient runs the execs like cat, it echoes the first line of their stdin to their stdout
+type echoExecClient struct {
+	client.APIClient
+	config types.ExecConfig
+}
+
+func (c *echoExecClient) ContainerExecCreate(ctx context.Context, container string, config types.ExecConfig) (types.IDResponse, error) {
+	c.config = config
+	return types.IDResponse{ID: "exec"}, nil
+}
+
+func (c *echoExecClient) ContainerExecAttach(ctx context.Context, execID string, config types.ExecStartCheck) (types.HijackedResponse, error) {
+	conn, other := net.Pipe()
+	stdout, stdoutWriter := io.Pipe()
+	go func() {
+		line, _ := bufio.NewReader(other).ReadString('\n')
+		_, _ = stdcopy.NewStdWriter(stdoutWriter, stdcopy.Stdout).Write([]byte(line))
+		stdoutWriter.Close()
+	}()
+	return types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(stdout)}, nil
+}
+
+func (c *echoExecClient) ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error) {
+	return types.ContainerExecInspect{}, nil
+}
+
+func TestDockerExecStdin(t *testing.T) {
+	defer SetContainerAllocateTerminal(containerAllocateTerminal)
+	SetContainerAllocateTerminal(false)
+
+	cli := &echoExecClient{}
+	stdout := &bytes.Buffer{}
+	cr := &containerReference{
+		cli:   cli,
+		id:    "container",
+		input: &NewContainerInput{Stdout: stdout, Stderr: &bytes.Buffer{}},
+	}
+	ctx := WithExecStdin(context.Background(), strings.NewReader("piped input\n"))
+	err := cr.exec([]string{"cat"}, map[string]string{}, "", "")(ctx)
+	assert.NoError(t, err)
+	assert.True(t, cli.config.AttachStdin)
+	assert.Equal(t, "piped input\n", stdout.String())
+}
+
+// stopRecordingClient records the timeout containers are stopped with before they are removed
+type stopRecordingClient struct {
+	client.APIClient
+	calls   []string
+	timeout time.Duration
+}
+
+func (c *stopRecordingClient) ContainerStop(ctx context.Context, container string, timeout *time.Duration) error {
+	c.calls = append(c.calls, "stop")
+	c.timeout = *timeout
+	return nil
+}
+
+func (c *stopRecordingClient) ContainerRemove(ctx context.Context, container string, options types.ContainerRemoveOptions) error {
+	c.calls = append(c.calls, "remove")
+	return nil
+}
+
+func TestDockerRemoveStopTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		stopTimeout time.Duration
+		timeout     time.Duration
+	}{
+		{0, 10 * time.Second},
+		{30 * time.Second, 30 * time.Second},
+	} {
+		cli := &stopRecordingClient{}
+		cr := &containerReference{
+			cli:   cli,
+			id:    "container",
+			input: &NewContainerInput{StopTimeout: tt.stopTimeout},
+		}
+		assert.NoError(t, cr.remove()(context.Background()))
+		assert.Equal(t, []string{"stop", "remove"}, cli.calls)
+		assert.Equal(t, tt.timeout, cli.timeout)
+		assert.Empty(t, cr.id)
+	}
+}
+
+func TestDockerRemoveIdle(t *testing.T) {
+	for _, tt := range []struct {
+		init  bool
+		calls []string
+	}{
+		{false, []string{"remove"}},
+		{true, []string{"stop", "remove"}},
+	} {
+		cli := &stopRecordingClient{}
+		cr := &containerReference{
+			cli:   cli,
+			id:    "container",
+			input: &NewContainerInput{Idle: true, Init: tt.init},
+		}
+		assert.NoError(t, cr.remove()(context.Background()))
+		assert.Equal(t, tt.calls, cli.calls)
+		assert.Empty(t, cr.id)
+	}
+}
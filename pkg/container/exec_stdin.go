@@ -0,0 +1,25 @@
+package container
+
+import (
+	"context"
+	"io"
+)
+
+type execStdinContextKey string
+
+const execStdinContextKeyVal = execStdinContextKey("container.execStdin")
+
+// WithExecStdin adds a reader to the context that the commands run with Exec read their stdin from,
+// without one their stdin is empty
+func WithExecStdin(ctx context.Context, stdin io.Reader) context.Context {
+	return context.WithValue(ctx, execStdinContextKeyVal, stdin)
+}
+
+// ExecStdin returns the stdin of the commands run with Exec, it is nil if the context has none. It is meant for
+// the implementations of Container
+func ExecStdin(ctx context.Context) io.Reader {
+	if stdin, ok := ctx.Value(execStdinContextKeyVal).(io.Reader); ok {
+		return stdin
+	}
+	return nil
+}
@@ -46,58 +46,123 @@ func (e *HostExecutor) Copy(destPath string, files ...*FileEntry) common.Executo
 			if err := os.WriteFile(filepath.Join(destPath, f.Name), []byte(f.Body), fs.FileMode(f.Mode)); err != nil {
 				return err
 			}
+			RecordCopy(ctx, 1, int64(len(f.Body)))
 		}
 		return nil
 	}
 }
 
-func (e *HostExecutor) CopyDir(destPath string, srcPath string, useGitIgnore bool) common.Executor {
+// CopyDir copies srcPath to destPath without the files matching excludes, unlike the docker container it
+// copies gitignored files as well
+func (e *HostExecutor) CopyDir(destPath string, srcPath string, useGitIgnore bool, excludes ...string) common.Executor {
 	return func(ctx context.Context) error {
+		ignorer := newCopyMatcher(srcPath, false, excludes)
 		return filepath.Walk(srcPath, func(file string, fi os.FileInfo, err error) error {
-			if fi.Mode()&os.ModeSymlink != 0 {
-				lnk, err := os.Readlink(file)
-				if err != nil {
-					return err
-				}
-				relpath, err := filepath.Rel(srcPath, file)
-				if err != nil {
-					return err
-				}
-				fdestpath := filepath.Join(destPath, relpath)
-				if err := os.MkdirAll(filepath.Dir(fdestpath), 0777); err != nil {
-					return err
-				}
-				if err := os.Symlink(lnk, fdestpath); err != nil {
-					return err
-				}
-			} else if fi.Mode().IsRegular() {
+			if err != nil {
+				return err
+			}
+			if ignorer != nil {
 				relpath, err := filepath.Rel(srcPath, file)
 				if err != nil {
 					return err
 				}
-				f, err := os.Open(file)
-				if err != nil {
-					return err
-				}
-				defer f.Close()
-				fdestpath := filepath.Join(destPath, relpath)
-				if err := os.MkdirAll(filepath.Dir(fdestpath), 0777); err != nil {
-					return err
-				}
-				df, err := os.OpenFile(fdestpath, os.O_CREATE|os.O_WRONLY, fi.Mode())
-				if err != nil {
-					return err
-				}
-				defer df.Close()
-				if _, err := io.Copy(df, f); err != nil {
-					return err
+				if relpath != "." && ignorer.Match(strings.Split(relpath, string(filepath.Separator)), fi.IsDir()) {
+					if fi.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
 				}
 			}
-			return nil
+			relpath, err := filepath.Rel(srcPath, file)
+			if err != nil {
+				return err
+			}
+			return copyHostFile(ctx, file, filepath.Join(destPath, relpath), fi)
 		})
 	}
 }
 
+// CopyGlob copies the files below srcPath matching any of the patterns to destPath
+func (e *HostExecutor) CopyGlob(destPath string, srcPath string, patterns ...string) common.Executor {
+	return func(ctx context.Context) error {
+		files, err := globFiles(srcPath, patterns)
+		if err != nil {
+			return err
+		}
+		for _, name := range files {
+			file := filepath.Join(srcPath, filepath.FromSlash(name))
+			fi, err := os.Lstat(file)
+			if err != nil {
+				return err
+			}
+			if err := copyHostFile(ctx, file, filepath.Join(destPath, filepath.FromSlash(name)), fi); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// copyHostFile copies a regular file or a symlink to destFile, other files are skipped
+func copyHostFile(ctx context.Context, file string, destFile string, fi os.FileInfo) error {
+	if fi.Mode()&os.ModeSymlink != 0 {
+		lnk, err := os.Readlink(file)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(destFile), 0777); err != nil {
+			return err
+		}
+		if err := os.Symlink(lnk, destFile); err != nil {
+			return err
+		}
+		RecordCopy(ctx, 1, 0)
+	} else if fi.Mode().IsRegular() {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := os.MkdirAll(filepath.Dir(destFile), 0777); err != nil {
+			return err
+		}
+		df, err := os.OpenFile(destFile, os.O_CREATE|os.O_WRONLY, fi.Mode())
+		if err != nil {
+			return err
+		}
+		defer df.Close()
+		n, err := io.Copy(df, f)
+		if err != nil {
+			return err
+		}
+		RecordCopy(ctx, 1, n)
+	}
+	return nil
+}
+
+func (e *HostExecutor) resolvePath(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(e.Path, p)
+}
+
+func (e *HostExecutor) MkdirAll(path string, mode os.FileMode) common.Executor {
+	return func(ctx context.Context) error {
+		return os.MkdirAll(e.resolvePath(path), mode)
+	}
+}
+
+func (e *HostExecutor) WriteFile(path string, content []byte, mode os.FileMode) common.Executor {
+	return func(ctx context.Context) error {
+		fpath := e.resolvePath(path)
+		if err := os.MkdirAll(filepath.Dir(fpath), 0777); err != nil {
+			return err
+		}
+		return os.WriteFile(fpath, content, mode)
+	}
+}
+
 func fileCallbackfilecbk(srcPath string, tw *tar.Writer, file string, fi os.FileInfo, err error) error {
 	if fi.Mode()&os.ModeSymlink != 0 {
 		lnk, err := os.Readlink(file)
@@ -175,6 +240,14 @@ func (e *HostExecutor) GetContainerArchive(ctx context.Context, srcPath string)
 	return io.NopCloser(buf), nil
 }
 
+func (e *HostExecutor) ReadFile(ctx context.Context, srcPath string) ([]byte, error) {
+	content, err := os.ReadFile(e.resolvePath(srcPath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &FileNotFoundError{Path: srcPath}
+	}
+	return content, err
+}
+
 func (e *HostExecutor) Pull(forcePull bool) common.Executor {
 	return func(ctx context.Context) error {
 		return nil
@@ -280,6 +353,11 @@ func (e *HostExecutor) exec2(ctx context.Context, command []string, cmdline stri
 	} else {
 		wd = e.Path
 	}
+	if workdir != "" {
+		if info, err := os.Stat(wd); os.IsNotExist(err) || (err == nil && !info.IsDir()) {
+			return &WorkdirNotFoundError{Path: wd}
+		}
+	}
 	f, err := lookupPathHost(command[0], env, e.StdOut)
 	if err != nil {
 		return err
@@ -320,6 +398,9 @@ func (e *HostExecutor) exec2(ctx context.Context, command []string, cmdline stri
 	if ppty != nil {
 		go writeKeepAlive(ppty)
 	}
+	if stdin := ExecStdin(ctx); stdin != nil {
+		cmd.Stdin = stdin
+	}
 	err = cmd.Run()
 	if err != nil {
 		return err
@@ -383,6 +464,18 @@ func (e *HostExecutor) UpdateFromPath(env *map[string]string) common.Executor {
 	}
 }
 
+func (e *HostExecutor) Commit(ctx context.Context, ref string) error {
+	return fmt.Errorf("commit is not supported for self-hosted jobs")
+}
+
+func (e *HostExecutor) Export(ctx context.Context, w io.Writer) error {
+	return fmt.Errorf("export is not supported for self-hosted jobs")
+}
+
+func (e *HostExecutor) BuildImage(ctx context.Context, input BuildInput) error {
+	return fmt.Errorf("building images is not supported for self-hosted jobs")
+}
+
 func (e *HostExecutor) Remove() common.Executor {
 	return func(ctx context.Context) error {
 		if e.CleanUp != nil {
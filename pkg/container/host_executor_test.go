@@ -0,0 +1,111 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostExecutorMkdirAllWriteFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	e := &HostExecutor{Path: dir}
+
+	err := e.MkdirAll("a/b", 0755)(ctx)
+	assert.NoError(t, err)
+	info, err := os.Stat(filepath.Join(dir, "a", "b"))
+	assert.NoError(t, err)
+	assert.True(t, info.IsDir())
+
+	err = e.WriteFile(filepath.Join(dir, "c", "file.txt"), []byte("content"), 0644)(ctx)
+	assert.NoError(t, err)
+	b, err := os.ReadFile(filepath.Join(dir, "c", "file.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(b))
+}
+
+func TestHostExecutorExecStdin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("cat isn't available")
+	}
+	stdout := &bytes.Buffer{}
+	e := &HostExecutor{Path: t.TempDir(), StdOut: stdout}
+
+	ctx := WithExecStdin(context.Background(), strings.NewReader("piped input\n"))
+	err := e.Exec([]string{"cat"}, "", map[string]string{"PATH": os.Getenv("PATH")}, "", "")(ctx)
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "piped input")
+}
+
+func TestHostExecutorReadFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	e := &HostExecutor{Path: dir}
+
+	err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("content"), 0644)
+	assert.NoError(t, err)
+
+	content, err := e.ReadFile(ctx, "file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+
+	_, err = e.ReadFile(ctx, filepath.Join(dir, "missing.txt"))
+	assert.True(t, IsFileNotFound(err))
+}
+
+func TestHostExecutorCopyDirExcludes(t *testing.T) {
+	ctx := context.Background()
+	src := t.TempDir()
+	dest := t.TempDir()
+	e := &HostExecutor{Path: dest}
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, ".git"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, ".git", "HEAD"), []byte("ref: refs/heads/main"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "main.go"), []byte("package main"), 0644))
+
+	err := e.CopyDir(dest, src, true, ".git")(ctx)
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dest, "main.go"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dest, ".git"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestHostExecutorExecMissingWorkdir(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	e := &HostExecutor{Path: dir}
+
+	err := e.Exec([]string{"echo", "hello"}, "", map[string]string{}, "", "missing/sub")(ctx)
+	var notFound *WorkdirNotFoundError
+	if assert.True(t, errors.As(err, &notFound), "%v", err) {
+		assert.Equal(t, dir+"/missing/sub", notFound.Path)
+	}
+	assert.Contains(t, err.Error(), "working directory '"+dir+"/missing/sub' does not exist")
+}
+
+func TestHostExecutorCopyGlob(t *testing.T) {
+	ctx := context.Background()
+	src := t.TempDir()
+	dest := t.TempDir()
+	e := &HostExecutor{Path: dest}
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, "src", "lib"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "src", "lib", "util.go"), []byte("package lib"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "README.md"), []byte("readme"), 0644))
+
+	err := e.CopyGlob(dest, src, "**/*.go")(ctx)
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(dest, "src", "lib", "util.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package lib", string(content))
+	_, err = os.Stat(filepath.Join(dest, "README.md"))
+	assert.True(t, os.IsNotExist(err))
+}
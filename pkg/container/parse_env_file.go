@@ -1,8 +1,8 @@
 package container
 
 import (
-	"archive/tar"
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -14,47 +14,82 @@ import (
 func parseEnvFile(e Container, srcPath string, env *map[string]string) common.Executor {
 	localEnv := *env
 	return func(ctx context.Context) error {
-		envTar, err := e.GetContainerArchive(ctx, srcPath)
-		if err != nil {
+		content, err := e.ReadFile(ctx, srcPath)
+		if IsFileNotFound(err) {
 			return nil
-		}
-		defer envTar.Close()
-		reader := tar.NewReader(envTar)
-		_, err = reader.Next()
-		if err != nil && err != io.EOF {
+		} else if err != nil {
 			return err
 		}
-		s := bufio.NewScanner(reader)
-		for s.Scan() {
-			line := s.Text()
-			singleLineEnv := strings.Index(line, "=")
-			multiLineEnv := strings.Index(line, "<<")
-			if singleLineEnv != -1 && (multiLineEnv == -1 || singleLineEnv < multiLineEnv) {
-				localEnv[line[:singleLineEnv]] = line[singleLineEnv+1:]
-			} else if multiLineEnv != -1 {
-				multiLineEnvContent := ""
-				multiLineEnvDelimiter := line[multiLineEnv+2:]
-				delimiterFound := false
-				for s.Scan() {
-					content := s.Text()
-					if content == multiLineEnvDelimiter {
-						delimiterFound = true
-						break
-					}
-					if multiLineEnvContent != "" {
-						multiLineEnvContent += "\n"
-					}
-					multiLineEnvContent += content
+		if err := parseKeyValuePairs(bytes.NewReader(content), localEnv); err != nil {
+			return fmt.Errorf("failed to parse '%s': %w", srcPath, err)
+		}
+		env = &localEnv
+		return nil
+	}
+}
+
+// parseKeyValuePairs reads the file command format used by GITHUB_ENV and GITHUB_OUTPUT,
+// which is either `name=value` or a heredoc `name<<DELIMITER` followed by the value lines
+// and the delimiter on a line of its own. Values are taken literally, quotes are not removed.
+func parseKeyValuePairs(r io.Reader, env map[string]string) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1024*1024)
+	scan := func() (string, bool) {
+		if !s.Scan() {
+			return "", false
+		}
+		// files written on windows runners use CRLF line endings
+		return strings.TrimSuffix(s.Text(), "\r"), true
+	}
+	for {
+		line, ok := scan()
+		if !ok {
+			break
+		}
+		if line == "" {
+			continue
+		}
+		singleLineEnv := strings.Index(line, "=")
+		multiLineEnv := strings.Index(line, "<<")
+		if singleLineEnv != -1 && (multiLineEnv == -1 || singleLineEnv < multiLineEnv) {
+			name := line[:singleLineEnv]
+			if name == "" {
+				return fmt.Errorf("invalid format '%v', name must not be empty", line)
+			}
+			env[name] = line[singleLineEnv+1:]
+		} else if multiLineEnv != -1 {
+			name := line[:multiLineEnv]
+			multiLineEnvDelimiter := line[multiLineEnv+2:]
+			if name == "" {
+				return fmt.Errorf("invalid format '%v', name must not be empty", line)
+			}
+			if strings.TrimSpace(multiLineEnvDelimiter) == "" {
+				return fmt.Errorf("invalid format '%v', delimiter must not be empty", line)
+			}
+			var multiLineEnvContent []string
+			delimiterFound := false
+			for {
+				content, ok := scan()
+				if !ok {
+					break
 				}
-				if !delimiterFound {
-					return fmt.Errorf("invalid format delimiter '%v' not found before end of file", multiLineEnvDelimiter)
+				if content == multiLineEnvDelimiter {
+					delimiterFound = true
+					break
 				}
-				localEnv[line[:multiLineEnv]] = multiLineEnvContent
-			} else {
-				return fmt.Errorf("invalid format '%v', expected a line with '=' or '<<'", line)
+				// same validation as the actions toolkit, a value must not contain its delimiter
+				if strings.Contains(content, multiLineEnvDelimiter) {
+					return fmt.Errorf("invalid value for '%v', it must not contain the delimiter '%v'", name, multiLineEnvDelimiter)
+				}
+				multiLineEnvContent = append(multiLineEnvContent, content)
+			}
+			if !delimiterFound {
+				return fmt.Errorf("invalid format delimiter '%v' not found before end of file", multiLineEnvDelimiter)
 			}
+			env[name] = strings.Join(multiLineEnvContent, "\n")
+		} else {
+			return fmt.Errorf("invalid format '%v', expected a line with '=' or '<<'", line)
 		}
-		env = &localEnv
-		return nil
 	}
+	return s.Err()
 }
@@ -0,0 +1,93 @@
+package container
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeyValuePairs(t *testing.T) {
+	table := []struct {
+		name    string
+		content string
+		env     map[string]string
+		err     string
+	}{
+		{
+			name:    "single line",
+			content: "FOO=bar\nBAZ=a=b\n",
+			env:     map[string]string{"FOO": "bar", "BAZ": "a=b"},
+		},
+		{
+			name:    "quoted values are kept as is",
+			content: "FOO=\"bar\"\nBAZ='qux'\n",
+			env:     map[string]string{"FOO": "\"bar\"", "BAZ": "'qux'"},
+		},
+		{
+			name:    "crlf line endings",
+			content: "FOO=bar\r\nMULTI<<EOF\r\nline1\r\nline2\r\nEOF\r\n",
+			env:     map[string]string{"FOO": "bar", "MULTI": "line1\nline2"},
+		},
+		{
+			name:    "heredoc",
+			content: "MULTI<<EOF\nline1\nline2\nEOF\nFOO=bar\n",
+			env:     map[string]string{"MULTI": "line1\nline2", "FOO": "bar"},
+		},
+		{
+			name:    "empty lines are ignored",
+			content: "\nFOO=bar\n\n",
+			env:     map[string]string{"FOO": "bar"},
+		},
+		{
+			name:    "heredoc with empty value",
+			content: "EMPTY<<EOF\nEOF\n",
+			env:     map[string]string{"EMPTY": ""},
+		},
+		{
+			name:    "heredoc keeps blank lines and equal signs",
+			content: "MULTI<<ghadelimiter_1\na=b\n\nc<<d\nghadelimiter_1\n",
+			env:     map[string]string{"MULTI": "a=b\n\nc<<d"},
+		},
+		{
+			name:    "value containing the delimiter",
+			content: "MULTI<<EOF\nline1 EOF\nEOF\n",
+			err:     "must not contain the delimiter 'EOF'",
+		},
+		{
+			name:    "missing delimiter",
+			content: "MULTI<<EOF\nline1\n",
+			err:     "delimiter 'EOF' not found",
+		},
+		{
+			name:    "empty delimiter",
+			content: "MULTI<<\nline1\n",
+			err:     "delimiter must not be empty",
+		},
+		{
+			name:    "empty name",
+			content: "=bar\n",
+			err:     "name must not be empty",
+		},
+		{
+			name:    "invalid line",
+			content: "FOO\n",
+			err:     "expected a line with '=' or '<<'",
+		},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{}
+			err := parseKeyValuePairs(strings.NewReader(tt.content), env)
+			if tt.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.env, env)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package container
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SupportedPlatforms are the platforms docker runs images for, in the os/architecture[/variant] form
+var SupportedPlatforms = []string{
+	"linux/386",
+	"linux/amd64",
+	"linux/arm",
+	"linux/arm/v5",
+	"linux/arm/v6",
+	"linux/arm/v7",
+	"linux/arm64",
+	"linux/arm64/v8",
+	"linux/mips64le",
+	"linux/ppc64le",
+	"linux/riscv64",
+	"linux/s390x",
+	"windows/amd64",
+	"windows/arm64",
+}
+
+// ValidatePlatform returns an error listing the SupportedPlatforms if platform isn't one of them,
+// an empty platform is valid since it selects the native platform of the daemon
+func ValidatePlatform(platform string) error {
+	if platform == "" {
+		return nil
+	}
+	for _, supported := range SupportedPlatforms {
+		if strings.EqualFold(platform, supported) {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid container architecture '%s', valid values are: %s", platform, strings.Join(SupportedPlatforms, ", "))
+}
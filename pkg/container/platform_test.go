@@ -0,0 +1,19 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePlatform(t *testing.T) {
+	assert.NoError(t, ValidatePlatform(""))
+	assert.NoError(t, ValidatePlatform("linux/amd64"))
+	assert.NoError(t, ValidatePlatform("linux/arm/v7"))
+
+	err := ValidatePlatform("linux/x86")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid container architecture 'linux/x86'")
+		assert.Contains(t, err.Error(), "linux/amd64, linux/arm")
+	}
+}
@@ -0,0 +1,173 @@
+//go:build linux || darwin || windows || openbsd
+// +build linux darwin windows openbsd
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/ankit-arora/act/pkg/common"
+)
+
+var (
+	// progressOutput is where the spinner is drawn if it is a terminal, the job loggers write to it as well
+	// and its lock keeps the spinner from being drawn into a log line
+	progressOutput io.Writer = common.Stdout
+	// progressSpinnerInterval is the interval the spinner is redrawn at on terminals
+	progressSpinnerInterval = 100 * time.Millisecond
+	// progressLogInterval is the interval the progress is logged at if the output isn't a terminal
+	progressLogInterval = 10 * time.Second
+)
+
+var progressSpinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// progressReporter shows the progress of a pull or a copy, as a spinner on terminals and
+// as periodic log lines otherwise, so slow transfers don't look like act is hung
+type progressReporter struct {
+	mu       sync.Mutex
+	label    string
+	current  int64
+	total    int64
+	layers   map[string][2]int64
+	done     chan struct{}
+	stopped  sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// newProgressReporter starts reporting the progress of label until stop is called
+func newProgressReporter(ctx context.Context, label string) *progressReporter {
+	p := &progressReporter{
+		label:  label,
+		layers: map[string][2]int64{},
+		done:   make(chan struct{}),
+	}
+	logger := common.Logger(ctx)
+	f, ok := progressOutput.(interface{ Fd() uintptr })
+	tty := ok && term.IsTerminal(int(f.Fd()))
+
+	interval := progressLogInterval
+	if tty {
+		interval = progressSpinnerInterval
+	}
+	p.stopped.Add(1)
+	go func() {
+		defer p.stopped.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			select {
+			case <-p.done:
+				if tty && frame > 0 {
+					fmt.Fprint(progressOutput, "\r\x1b[K")
+				}
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if tty {
+					fmt.Fprintf(progressOutput, "\r\x1b[K%s %s", progressSpinnerFrames[frame%len(progressSpinnerFrames)], p.String())
+				} else {
+					logger.Infof("%s%s", logPrefix, p.String())
+				}
+			}
+		}
+	}()
+	return p
+}
+
+// add counts n more bytes transferred
+func (p *progressReporter) add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current += n
+}
+
+// setTotal sets the number of bytes to transfer, if it isn't known only the transferred bytes are shown
+func (p *progressReporter) setTotal(total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = total
+}
+
+// setLayer updates the progress of a layer of a pull, the progress is the sum of all layers
+func (p *progressReporter) setLayer(id string, current int64, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.layers[id] = [2]int64{current, total}
+	p.current = 0
+	p.total = 0
+	for _, layer := range p.layers {
+		p.current += layer[0]
+		p.total += layer[1]
+	}
+}
+
+// completeLayer marks a layer as completely transferred
+func (p *progressReporter) completeLayer(id string) {
+	p.mu.Lock()
+	layer, ok := p.layers[id]
+	p.mu.Unlock()
+	if ok {
+		p.setLayer(id, layer[1], layer[1])
+	}
+}
+
+// stop stops reporting and clears the spinner, it can be called more than once
+func (p *progressReporter) stop() {
+	p.stopOnce.Do(func() {
+		close(p.done)
+		p.stopped.Wait()
+	})
+}
+
+func (p *progressReporter) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.total <= 0 {
+		return fmt.Sprintf("%s (%s)", p.label, formatBytes(p.current))
+	}
+	return fmt.Sprintf("%s %d%% (%s/%s)", p.label, p.current*100/p.total, formatBytes(p.current), formatBytes(p.total))
+}
+
+// progressReader counts the bytes read through it
+type progressReader struct {
+	io.Reader
+	progress *progressReporter
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	r.progress.add(int64(n))
+	return n, err
+}
+
+// progressWriter counts the bytes written through it
+type progressWriter struct {
+	io.Writer
+	progress *progressReporter
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	n, err := w.Writer.Write(b)
+	w.progress.add(int64(n))
+	return n, err
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
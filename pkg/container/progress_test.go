@@ -0,0 +1,51 @@
+//go:build linux || darwin || windows || openbsd
+// +build linux darwin windows openbsd
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankit-arora/act/pkg/common"
+)
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2.0 GiB", formatBytes(2<<30))
+}
+
+func TestProgressReporter(t *testing.T) {
+	defer func(interval time.Duration) { progressLogInterval = interval }(progressLogInterval)
+	progressLogInterval = time.Millisecond
+	defer func(output io.Writer) { progressOutput = output }(progressOutput)
+	progressOutput = &bytes.Buffer{}
+
+	logger, hook := test.NewNullLogger()
+	p := newProgressReporter(common.WithLogger(context.Background(), logger), "Pulling node:16")
+	p.setLayer("a", 10, 100)
+	p.setLayer("b", 40, 100)
+	assert.Equal(t, "Pulling node:16 25% (50 B/200 B)", p.String())
+	p.completeLayer("a")
+	assert.Equal(t, "Pulling node:16 70% (140 B/200 B)", p.String())
+
+	assert.Eventually(t, func() bool { return len(hook.AllEntries()) > 0 }, time.Second, time.Millisecond)
+	p.stop()
+	p.stop()
+	assert.Contains(t, hook.LastEntry().Message, "Pulling node:16")
+
+	p = newProgressReporter(common.WithLogger(context.Background(), logger), "Copying")
+	_, err := ioutil.ReadAll(&progressReader{Reader: strings.NewReader("hello"), progress: p})
+	assert.NoError(t, err)
+	p.stop()
+	assert.Equal(t, "Copying (5 B)", p.String())
+}
@@ -0,0 +1,53 @@
+package container
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+type pulledImagesContextKey string
+
+const pulledImagesContextKeyVal = pulledImagesContextKey("container.pulledImages")
+
+type pulledImages struct {
+	mu     sync.Mutex
+	images map[string]bool
+}
+
+// WithPulledImages adds a value to the context that records the images pulled by act,
+// images that already existed locally are never recorded
+func WithPulledImages(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pulledImagesContextKeyVal, &pulledImages{images: map[string]bool{}})
+}
+
+// PulledImages returns the images pulled since WithPulledImages was called
+func PulledImages(ctx context.Context) []string {
+	val, ok := ctx.Value(pulledImagesContextKeyVal).(*pulledImages)
+	if !ok {
+		return nil
+	}
+	val.mu.Lock()
+	defer val.mu.Unlock()
+	images := make([]string, 0, len(val.images))
+	for image := range val.images {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images
+}
+
+func tracksPulledImages(ctx context.Context) bool {
+	_, ok := ctx.Value(pulledImagesContextKeyVal).(*pulledImages)
+	return ok
+}
+
+func recordPulledImage(ctx context.Context, image string) {
+	val, ok := ctx.Value(pulledImagesContextKeyVal).(*pulledImages)
+	if !ok {
+		return
+	}
+	val.mu.Lock()
+	defer val.mu.Unlock()
+	val.images[image] = true
+}
@@ -0,0 +1,20 @@
+package container
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPulledImages(t *testing.T) {
+	ctx := context.Background()
+	recordPulledImage(ctx, "node:16")
+	assert.Nil(t, PulledImages(ctx))
+
+	ctx = WithPulledImages(ctx)
+	recordPulledImage(ctx, "node:16")
+	recordPulledImage(ctx, "alpine:3")
+	recordPulledImage(ctx, "node:16")
+	assert.Equal(t, []string{"alpine:3", "node:16"}, PulledImages(ctx))
+}
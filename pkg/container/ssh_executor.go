@@ -0,0 +1,411 @@
+package container
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ankit-arora/act/pkg/common"
+	"github.com/kballard/go-shellquote"
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSHConfig describes how to connect to the host of an SSHExecutor
+type SSHConfig struct {
+	Host         string // user@host[:port], the port defaults to 22 and the user to the local user
+	IdentityFile string // private key to authenticate with, the keys of the ssh-agent at SSH_AUTH_SOCK are tried as well
+	KnownHosts   string // known_hosts file to verify the key of the host with, defaults to ~/.ssh/known_hosts
+}
+
+// DialSSH connects to the host of cfg, the key of the host must be in the known_hosts file
+func DialSSH(cfg SSHConfig) (*ssh.Client, error) {
+	user, addr := splitSSHHost(cfg.Host)
+
+	var auth []ssh.AuthMethod
+	if cfg.IdentityFile != "" {
+		key, err := os.ReadFile(cfg.IdentityFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse identity file '%s': %w", cfg.IdentityFile, err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	knownHostsFile := cfg.KnownHosts
+	if knownHostsFile == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return nil, err
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known hosts '%s': %w", knownHostsFile, err)
+	}
+
+	return ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	})
+}
+
+// splitSSHHost splits user@host[:port] into the user and the address to dial
+func splitSSHHost(host string) (string, string) {
+	user := os.Getenv("USER")
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		user, host = host[:i], host[i+1:]
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), "22")
+	}
+	return user, host
+}
+
+// sshFileNotFoundStatus is the exit status of the remote commands reading a file that doesn't exist
+const sshFileNotFoundStatus = 44
+
+// SSHExecutor runs a self-hosted job on another machine over SSH, the commands are run
+// by a shell of the remote user and files are copied as tar streams through the session
+type SSHExecutor struct {
+	Client *ssh.Client
+	Path   string // working directory of the job on the remote host
+	Root   string // directory on the remote host removed with Remove, it defaults to Path
+	StdOut io.Writer
+}
+
+// run runs cmdline in a new session, the session is killed if ctx is done. Without stderr the
+// error output of the command is added to the returned error
+func (e *SSHExecutor) run(ctx context.Context, cmdline string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (err error) {
+	session, err := e.Client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = stderr
+	if stderr == nil {
+		errOutput := &bytes.Buffer{}
+		session.Stderr = errOutput
+		defer func() {
+			if err != nil && errOutput.Len() > 0 {
+				err = fmt.Errorf("%w: %s", err, strings.TrimSpace(errOutput.String()))
+			}
+		}()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(cmdline)
+	}()
+	select {
+	case err = <-done:
+		return err
+	case <-ctx.Done():
+		_ = session.Signal(ssh.SIGKILL)
+		return ctx.Err()
+	}
+}
+
+func isSSHExitStatus(err error, status int) bool {
+	var exitErr *ssh.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitStatus() == status
+}
+
+func (e *SSHExecutor) resolvePath(p string) string {
+	if path.IsAbs(p) {
+		return p
+	}
+	return path.Join(e.Path, p)
+}
+
+// Env returns the env of a login shell of the remote user
+func (e *SSHExecutor) Env(ctx context.Context) (map[string]string, error) {
+	out := &bytes.Buffer{}
+	if err := e.run(ctx, "env", nil, out, nil); err != nil {
+		return nil, err
+	}
+	env := map[string]string{}
+	s := bufio.NewScanner(out)
+	for s.Scan() {
+		if i := strings.Index(s.Text(), "="); i > 0 {
+			env[s.Text()[:i]] = s.Text()[i+1:]
+		}
+	}
+	return env, nil
+}
+
+func (e *SSHExecutor) Create(capAdd []string, capDrop []string) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+// Close closes the connection to the remote host
+func (e *SSHExecutor) Close() common.Executor {
+	return func(ctx context.Context) error {
+		return e.Client.Close()
+	}
+}
+
+// extractTar extracts the tar written by write into destPath on the remote host
+func (e *SSHExecutor) extractTar(ctx context.Context, destPath string, write func(tw *tar.Writer) error) error {
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := write(tw)
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	dest := shellquote.Join(e.resolvePath(destPath))
+	err := e.run(ctx, fmt.Sprintf("mkdir -p %s && tar -x -C %s", dest, dest), pr, nil, nil)
+	pr.Close()
+	return err
+}
+
+func (e *SSHExecutor) Copy(destPath string, files ...*FileEntry) common.Executor {
+	return func(ctx context.Context) error {
+		return e.extractTar(ctx, destPath, func(tw *tar.Writer) error {
+			for _, f := range files {
+				if err := tw.WriteHeader(&tar.Header{
+					Name: f.Name,
+					Mode: f.Mode,
+					Size: int64(len(f.Body)),
+				}); err != nil {
+					return err
+				}
+				if _, err := tw.Write([]byte(f.Body)); err != nil {
+					return err
+				}
+				RecordCopy(ctx, 1, int64(len(f.Body)))
+			}
+			return nil
+		})
+	}
+}
+
+// CopyGlob copies the files below srcPath on the local machine matching any of the patterns to destPath
+// on the remote host
+func (e *SSHExecutor) CopyGlob(destPath string, srcPath string, patterns ...string) common.Executor {
+	return func(ctx context.Context) error {
+		srcPath = filepath.Clean(srcPath)
+		files, err := globFiles(srcPath, patterns)
+		if err != nil {
+			return err
+		}
+		return e.extractTar(ctx, destPath, func(tw *tar.Writer) error {
+			for _, name := range files {
+				file := filepath.Join(srcPath, filepath.FromSlash(name))
+				fi, err := os.Lstat(file)
+				if err != nil {
+					return err
+				}
+				if fi.Mode().IsRegular() {
+					RecordCopy(ctx, 1, fi.Size())
+				} else {
+					RecordCopy(ctx, 1, 0)
+				}
+				if err := fileCallbackfilecbk(srcPath, tw, file, fi, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+// CopyDir copies srcPath on the local machine to destPath on the remote host without the files
+// matching excludes and, if useGitIgnore is set, the files ignored by .gitignore
+func (e *SSHExecutor) CopyDir(destPath string, srcPath string, useGitIgnore bool, excludes ...string) common.Executor {
+	return func(ctx context.Context) error {
+		srcPath = filepath.Clean(srcPath)
+		ignorer := newCopyMatcher(srcPath, useGitIgnore, excludes)
+		return e.extractTar(ctx, destPath, func(tw *tar.Writer) error {
+			return filepath.Walk(srcPath, func(file string, fi os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				relpath, err := filepath.Rel(srcPath, file)
+				if err != nil {
+					return err
+				}
+				if ignorer != nil && relpath != "." && ignorer.Match(strings.Split(relpath, string(filepath.Separator)), fi.IsDir()) {
+					if fi.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if fi.Mode().IsRegular() {
+					RecordCopy(ctx, 1, fi.Size())
+				} else if fi.Mode()&os.ModeSymlink != 0 {
+					RecordCopy(ctx, 1, 0)
+				}
+				return fileCallbackfilecbk(srcPath, tw, file, fi, nil)
+			})
+		})
+	}
+}
+
+func (e *SSHExecutor) MkdirAll(dirPath string, mode os.FileMode) common.Executor {
+	return func(ctx context.Context) error {
+		return e.run(ctx, fmt.Sprintf("mkdir -p -m %o %s", mode.Perm(), shellquote.Join(e.resolvePath(dirPath))), nil, nil, nil)
+	}
+}
+
+func (e *SSHExecutor) WriteFile(filePath string, content []byte, mode os.FileMode) common.Executor {
+	return func(ctx context.Context) error {
+		fpath := e.resolvePath(filePath)
+		return e.run(ctx, fmt.Sprintf("mkdir -p %s && cat > %s && chmod %o %s",
+			shellquote.Join(path.Dir(fpath)), shellquote.Join(fpath), mode.Perm(), shellquote.Join(fpath)), bytes.NewReader(content), nil, nil)
+	}
+}
+
+// GetContainerArchive returns a tar of srcPath on the remote host, named relative to its parent like docker does
+func (e *SSHExecutor) GetContainerArchive(ctx context.Context, srcPath string) (io.ReadCloser, error) {
+	srcPath = e.resolvePath(srcPath)
+	out := &bytes.Buffer{}
+	err := e.run(ctx, fmt.Sprintf("if [ ! -e %s ]; then exit %d; fi; tar -c -C %s %s",
+		shellquote.Join(srcPath), sshFileNotFoundStatus, shellquote.Join(path.Dir(srcPath)), shellquote.Join(path.Base(srcPath))), nil, out, nil)
+	if isSSHExitStatus(err, sshFileNotFoundStatus) {
+		return nil, &FileNotFoundError{Path: srcPath}
+	} else if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(out), nil
+}
+
+func (e *SSHExecutor) ReadFile(ctx context.Context, srcPath string) ([]byte, error) {
+	fpath := shellquote.Join(e.resolvePath(srcPath))
+	out := &bytes.Buffer{}
+	err := e.run(ctx, fmt.Sprintf("if [ ! -f %s ]; then exit %d; fi; cat %s", fpath, sshFileNotFoundStatus, fpath), nil, out, nil)
+	if isSSHExitStatus(err, sshFileNotFoundStatus) {
+		return nil, &FileNotFoundError{Path: srcPath}
+	} else if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (e *SSHExecutor) Commit(ctx context.Context, ref string) error {
+	return fmt.Errorf("commit is not supported for jobs run over ssh")
+}
+
+func (e *SSHExecutor) Export(ctx context.Context, w io.Writer) error {
+	return fmt.Errorf("export is not supported for jobs run over ssh")
+}
+
+func (e *SSHExecutor) BuildImage(ctx context.Context, input BuildInput) error {
+	return fmt.Errorf("building images is not supported for jobs run over ssh")
+}
+
+func (e *SSHExecutor) Pull(forcePull bool) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func (e *SSHExecutor) Start(attach bool) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+// sshCommandLine is the shell command running command in workdir with env, the env of the
+// remote login shell is kept for the variables env doesn't set
+func sshCommandLine(command []string, env map[string]string, workdir string) string {
+	args := append([]string{"env"}, getEnvListFromMap(env)...)
+	args = append(append(args, "--"), command...)
+	return fmt.Sprintf("cd %s && exec %s", shellquote.Join(workdir), shellquote.Join(args...))
+}
+
+// Exec runs the command on the remote host, the user is ignored since the session runs as the user it logged in with
+func (e *SSHExecutor) Exec(command []string, cmdline string, env map[string]string, user, workdir string) common.Executor {
+	return func(ctx context.Context) error {
+		wd := e.Path
+		if workdir != "" {
+			wd = e.resolvePath(workdir)
+			if err := e.run(ctx, fmt.Sprintf("test -d %s", shellquote.Join(wd)), nil, nil, nil); err != nil {
+				if isSSHExitStatus(err, 1) {
+					return &WorkdirNotFoundError{Path: wd}
+				}
+				return err
+			}
+		}
+		if err := e.run(ctx, sshCommandLine(command, env, wd), ExecStdin(ctx), e.StdOut, e.StdOut); err != nil {
+			select {
+			case <-ctx.Done():
+				return errors.Wrapf(err, "This step was cancelled\n")
+			default:
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (e *SSHExecutor) UpdateFromEnv(srcPath string, env *map[string]string) common.Executor {
+	return parseEnvFile(e, srcPath, env)
+}
+
+func (e *SSHExecutor) UpdateFromImageEnv(env *map[string]string) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+// UpdateFromPath prepends the directories added to GITHUB_PATH to the PATH in env
+func (e *SSHExecutor) UpdateFromPath(env *map[string]string) common.Executor {
+	localEnv := *env
+	return func(ctx context.Context) error {
+		content, err := e.ReadFile(ctx, localEnv["GITHUB_PATH"])
+		if IsFileNotFound(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		s := bufio.NewScanner(bytes.NewReader(content))
+		for s.Scan() {
+			if line := s.Text(); line != "" {
+				localEnv["PATH"] = fmt.Sprintf("%s:%s", line, localEnv["PATH"])
+			}
+		}
+		return nil
+	}
+}
+
+// Remove removes the directory of the job from the remote host
+func (e *SSHExecutor) Remove() common.Executor {
+	return func(ctx context.Context) error {
+		root := e.Root
+		if root == "" {
+			root = e.Path
+		}
+		return e.run(ctx, fmt.Sprintf("rm -rf %s", shellquote.Join(root)), nil, nil, nil)
+	}
+}
+
+var _ Container = &SSHExecutor{}
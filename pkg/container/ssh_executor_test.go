@@ -0,0 +1,34 @@
+package container
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSSHHost(t *testing.T) {
+	os.Setenv("USER", "local")
+	defer os.Unsetenv("USER")
+
+	for host, expected := range map[string][2]string{
+		"build-box":              {"local", "build-box:22"},
+		"runner@build-box":       {"runner", "build-box:22"},
+		"runner@build-box:2222":  {"runner", "build-box:2222"},
+		"runner@[2001:db8::1]":   {"runner", "[2001:db8::1]:22"},
+		"runner@[2001:db8::1]:7": {"runner", "[2001:db8::1]:7"},
+	} {
+		user, addr := splitSSHHost(host)
+		assert.Equal(t, expected[0], user, host)
+		assert.Equal(t, expected[1], addr, host)
+	}
+}
+
+func TestSSHCommandLine(t *testing.T) {
+	line := sshCommandLine([]string{"sh", "-c", "echo $GREETING"}, map[string]string{"GREETING": "hello world"}, "/tmp/act/work dir")
+
+	words, err := shellquote.Split(line)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cd", "/tmp/act/work dir", "&&", "exec", "env", "GREETING=hello world", "--", "sh", "-c", "echo $GREETING"}, words)
+}
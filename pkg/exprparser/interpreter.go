@@ -19,13 +19,19 @@ type EvaluationEnvironment struct {
 	Steps       map[string]*model.StepResult
 	Runner      map[string]interface{}
 	Secrets     map[string]string
+	Vars        map[string]string
 	Strategy    map[string]interface{}
 	Matrix      map[string]interface{}
-	Needs       map[string]map[string]map[string]string
+	Needs       map[string]Needs
 	Inputs      map[string]interface{}
 	ContextData map[string]interface{}
 }
 
+type Needs struct {
+	Outputs map[string]string `json:"outputs"`
+	Result  string            `json:"result"`
+}
+
 type Config struct {
 	Run        *model.Run
 	WorkingDir string
@@ -150,6 +156,8 @@ func (impl *interperterImpl) evaluateVariable(variableNode *actionlint.VariableN
 		return impl.env.Runner, nil
 	case "secrets":
 		return impl.env.Secrets, nil
+	case "vars":
+		return impl.env.Vars, nil
 	case "strategy":
 		return impl.env.Strategy, nil
 	case "matrix":
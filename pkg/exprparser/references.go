@@ -0,0 +1,52 @@
+package exprparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rhysd/actionlint"
+)
+
+// ContextProperties returns the properties of the named context the expression references, e.g. TOKEN for
+// secrets.TOKEN or secrets['TOKEN']. String literals and properties of other contexts, like github.env, are
+// not references of the context
+func ContextProperties(input string, context string) ([]string, error) {
+	input = strings.TrimPrefix(strings.TrimSpace(input), "${{")
+	exprNode, err := actionlint.NewExprParser().Parse(actionlint.NewExprLexer(input + "}}"))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse: %s", err.Message)
+	}
+
+	properties := make([]string, 0)
+	actionlint.VisitExprNode(exprNode, func(node, _ actionlint.ExprNode, entering bool) {
+		if !entering {
+			return
+		}
+		switch node := node.(type) {
+		case *actionlint.ObjectDerefNode:
+			if isVariable(node.Receiver, context) {
+				properties = append(properties, propertySource(input, node))
+			}
+		case *actionlint.IndexAccessNode:
+			if index, ok := node.Index.(*actionlint.StringNode); ok && isVariable(node.Operand, context) {
+				properties = append(properties, index.Value)
+			}
+		}
+	})
+	return properties, nil
+}
+
+// propertySource returns the property of the dereference as written in the input, the parser lower cases it
+func propertySource(input string, node *actionlint.ObjectDerefNode) string {
+	receiver := node.Receiver.Token()
+	rest := input[receiver.Offset+len(receiver.Value):]
+	if i := strings.Index(strings.ToLower(rest), node.Property); i >= 0 {
+		return rest[i : i+len(node.Property)]
+	}
+	return node.Property
+}
+
+func isVariable(node actionlint.ExprNode, name string) bool {
+	variable, ok := node.(*actionlint.VariableNode)
+	return ok && strings.EqualFold(variable.Name, name)
+}
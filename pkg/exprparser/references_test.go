@@ -0,0 +1,31 @@
+package exprparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextProperties(t *testing.T) {
+	table := []struct {
+		input    string
+		expected []string
+	}{
+		{"secrets.TOKEN", []string{"TOKEN"}},
+		{"${{ secrets.token != '' && secrets['npm-token'] }}", []string{"token", "npm-token"}},
+		{"format('secrets.{0}', env.NAME)", []string{}},
+		{"github.secrets.TOKEN || matrix.secrets", []string{}},
+		{"contains(Secrets.LIST, 'x')", []string{"LIST"}},
+	}
+
+	for _, tt := range table {
+		t.Run(tt.input, func(t *testing.T) {
+			properties, err := ContextProperties(tt.input, "secrets")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, properties)
+		})
+	}
+
+	_, err := ContextProperties("secrets.", "secrets")
+	assert.Error(t, err)
+}
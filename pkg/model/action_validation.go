@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// brandingColors are the colors GitHub Marketplace accepts for the branding of an action
+var brandingColors = []string{"white", "yellow", "blue", "green", "orange", "red", "purple", "gray-dark"}
+
+var stepsReferencePattern = regexp.MustCompile(`\bsteps\.([A-Za-z_][A-Za-z0-9_-]*)`)
+
+// Validate checks the metadata of the action for mistakes that would otherwise only show once it runs:
+// missing required keys, a runs block that doesn't match runs.using, outputs of composite actions without
+// a value or referencing steps that don't exist and an unknown branding color. It returns a description of each problem
+func (a *Action) Validate() []string {
+	problems := make([]string, 0)
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if a.Name == "" {
+		add("missing 'name'")
+	}
+
+	switch a.Runs.Using {
+	case "":
+		add("missing 'runs.using'")
+	case ActionRunsUsingNode12, ActionRunsUsingNode16:
+		if a.Runs.Main == "" {
+			add("'runs.main' is required for %s actions", a.Runs.Using)
+		}
+	case ActionRunsUsingDocker:
+		if a.Runs.Image == "" {
+			add("'runs.image' is required for docker actions")
+		}
+	case ActionRunsUsingComposite:
+		if len(a.Runs.Steps) == 0 {
+			add("'runs.steps' is required for composite actions")
+		}
+	}
+
+	stepIDs := make(map[string]bool)
+	steps := make([]*Step, 0, len(a.Runs.Steps))
+	for i := range a.Runs.Steps {
+		stepIDs[a.Runs.Steps[i].ID] = true
+		steps = append(steps, &a.Runs.Steps[i])
+	}
+	for _, id := range DuplicateStepIDs(steps) {
+		add("more than one step with id '%s'", id)
+	}
+
+	outputs := make([]string, 0, len(a.Outputs))
+	for name := range a.Outputs {
+		outputs = append(outputs, name)
+	}
+	sort.Strings(outputs)
+	for _, name := range outputs {
+		output := a.Outputs[name]
+		if a.Runs.Using != ActionRunsUsingComposite {
+			if output.Value != "" {
+				add("output '%s' has a value, which is only used by composite actions", name)
+			}
+			continue
+		}
+		if output.Value == "" {
+			add("output '%s' of a composite action is missing 'value'", name)
+		}
+		for _, match := range stepsReferencePattern.FindAllStringSubmatch(output.Value, -1) {
+			if !stepIDs[match[1]] {
+				add("output '%s' references step '%s', which doesn't exist", name, match[1])
+			}
+		}
+	}
+
+	if color := a.Branding.Color; color != "" && !containsString(brandingColors, color) {
+		add("branding color '%s' is not one of %v", color, brandingColors)
+	}
+
+	return problems
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
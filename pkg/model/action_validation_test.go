@@ -0,0 +1,86 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		action   string
+		problems []string
+	}{
+		{"valid node", `
+name: hello
+runs:
+  using: node16
+  main: index.js
+branding:
+  color: purple
+  icon: box
+`, []string{}},
+		{"valid composite", `
+name: hello
+outputs:
+  greeting:
+    value: ${{ steps.greet.outputs.greeting }}
+runs:
+  using: composite
+  steps:
+    - id: greet
+      run: echo "::set-output name=greeting::hi"
+      shell: bash
+`, []string{}},
+		{"missing keys", `
+runs:
+  using: docker
+`, []string{"missing 'name'", "'runs.image' is required for docker actions"}},
+		{"missing using", `
+name: hello
+`, []string{"missing 'runs.using'"}},
+		{"composite outputs", `
+name: hello
+outputs:
+  empty:
+    description: nothing
+  greeting:
+    value: ${{ steps.greet.outputs.greeting }} ${{ steps.missing.outputs.x }}
+runs:
+  using: composite
+  steps:
+    - id: greet
+      run: echo hi
+      shell: bash
+    - id: greet
+      run: echo hi
+      shell: bash
+branding:
+  color: pink
+`, []string{
+			"more than one step with id 'greet'",
+			"output 'empty' of a composite action is missing 'value'",
+			"output 'greeting' references step 'missing', which doesn't exist",
+			"branding color 'pink' is not one of [white yellow blue green orange red purple gray-dark]",
+		}},
+		{"node output value", `
+name: hello
+outputs:
+  greeting:
+    value: hi
+runs:
+  using: node12
+`, []string{
+			"'runs.main' is required for node12 actions",
+			"output 'greeting' has a value, which is only used by composite actions",
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			action, err := ReadAction(strings.NewReader(tt.action))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.problems, action.Validate())
+		})
+	}
+}
@@ -11,6 +11,7 @@ type GithubContext struct {
 	Event            map[string]interface{} `json:"event"`
 	EventPath        string                 `json:"event_path"`
 	Workflow         string                 `json:"workflow"`
+	WorkflowRef      string                 `json:"workflow_ref"`
 	RunID            string                 `json:"run_id"`
 	RunNumber        string                 `json:"run_number"`
 	RunAttempt       string                 `json:"run_attempt"`
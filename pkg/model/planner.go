@@ -9,9 +9,11 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
 // WorkflowPlanner contains methods for creating plans
@@ -19,6 +21,7 @@ type WorkflowPlanner interface {
 	PlanEvent(eventName string) *Plan
 	PlanJob(jobName string) *Plan
 	GetEvents() []string
+	Validate() error
 }
 
 // Plan contains a list of stages to run in series
@@ -143,6 +146,7 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 			}
 
 			workflow.File = wf.workflowFileInfo.Name()
+			workflow.Path = filepath.Join(wf.dirPath, wf.workflowFileInfo.Name())
 			if workflow.Name == "" {
 				workflow.Name = wf.workflowFileInfo.Name()
 			}
@@ -154,6 +158,20 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 				}
 			}
 
+			// a cycle in the needs can't be planned, report it before anything runs
+			jobIDs := workflow.GetJobIDs()
+			sort.Strings(jobIDs)
+			if cycle := workflow.needsCycle(jobIDs); cycle != nil {
+				f.Close()
+				return nil, fmt.Errorf("workflow is not valid. '%s': jobs depend on each other in a cycle: %s", workflow.Name, strings.Join(cycle, " -> "))
+			}
+			for _, jobID := range jobIDs {
+				if ids := DuplicateStepIDs(workflow.Jobs[jobID].Steps); len(ids) > 0 {
+					f.Close()
+					return nil, fmt.Errorf("workflow is not valid. '%s': job '%s' has more than one step with id '%s'", workflow.Name, jobID, ids[0])
+				}
+			}
+
 			wp.workflows = append(wp.workflows, workflow)
 			f.Close()
 		}
@@ -162,6 +180,48 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 	return wp, nil
 }
 
+// SingleActionJobID is the id of the job and the action step created by NewSingleActionPlanner
+const SingleActionJobID = "action"
+
+// NewSingleActionPlanner creates a planner for a synthetic workflow triggered by eventName with a single job,
+// which runs the action uses with the inputs with against the workspace on the runner runsOn. The outputs of
+// the action are available as JSON in the `outputs` output of the job.
+func NewSingleActionPlanner(uses string, with map[string]string, runsOn string, eventName string) WorkflowPlanner {
+	job := &Job{
+		Name: SingleActionJobID,
+		RawRunsOn: yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   "!!str",
+			Value: runsOn,
+		},
+		Steps: []*Step{
+			{
+				ID:   SingleActionJobID,
+				Uses: uses,
+				With: with,
+			},
+		},
+		Outputs: map[string]string{
+			"outputs": fmt.Sprintf("${{ toJSON(steps.%s.outputs) }}", SingleActionJobID),
+		},
+	}
+	w := &Workflow{
+		File: uses,
+		Name: uses,
+		RawOn: yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   "!!str",
+			Value: eventName,
+		},
+		Jobs: map[string]*Job{
+			SingleActionJobID: job,
+		},
+	}
+	return &workflowPlanner{
+		workflows: []*Workflow{w},
+	}
+}
+
 type workflowPlanner struct {
 	workflows []*Workflow
 }
@@ -196,6 +256,20 @@ func (wp *workflowPlanner) PlanJob(jobName string) *Plan {
 	return plan
 }
 
+// Validate checks all the workflows for mistakes, see Workflow.Validate
+func (wp *workflowPlanner) Validate() error {
+	errs := ValidationErrors{}
+	for _, w := range wp.workflows {
+		if err := w.Validate(); err != nil {
+			errs = append(errs, err.(ValidationErrors)...)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
 // GetEvents gets all the events in the workflows file
 func (wp *workflowPlanner) GetEvents() []string {
 	events := make([]string, 0)
@@ -298,7 +372,12 @@ func createStages(w *Workflow, jobIDs ...string) []*Stage {
 			}
 		}
 		if len(stage.Runs) == 0 {
-			log.Fatalf("Unable to build dependency graph!")
+			unplanned := make([]string, 0, len(jobDependencies))
+			for jID, jDeps := range jobDependencies {
+				unplanned = append(unplanned, fmt.Sprintf("%s (needs %s)", jID, strings.Join(jDeps, ", ")))
+			}
+			sort.Strings(unplanned)
+			log.Fatalf("Unable to build dependency graph, the needs of these jobs can't be satisfied: %s", strings.Join(unplanned, "; "))
 		}
 		stages = append(stages, stage)
 	}
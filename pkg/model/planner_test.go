@@ -22,6 +22,8 @@ func TestPlanner(t *testing.T) {
 		{"invalid-job-name/invalid-2.yml", "workflow is not valid. 'invalid-job-name-2': Job name '1234invalid-JOB-Name-v123-docker_hub' is invalid. Names must start with a letter or '_' and contain only alphanumeric characters, '-', or '_'", false},
 		{"invalid-job-name/valid-1.yml", "", false},
 		{"invalid-job-name/valid-2.yml", "", false},
+		{"cyclic-needs", "workflow is not valid. 'cyclic-needs': jobs depend on each other in a cycle: a -> c -> b -> a", false},
+		{"duplicate-step-ids", "workflow is not valid. 'duplicate-step-ids': job 'build' has more than one step with id 'version'", false},
 		{"empty-workflow", "unable to read workflow, push.yml file is empty: EOF", false},
 		{"nested", "unable to read workflow, fail.yml file is empty: EOF", false},
 		{"nested", "", true},
@@ -39,3 +41,19 @@ func TestPlanner(t *testing.T) {
 		}
 	}
 }
+
+func TestSingleActionPlanner(t *testing.T) {
+	planner := NewSingleActionPlanner("actions/setup-node@v2", map[string]string{"node-version": "16"}, "self-hosted", "workflow_dispatch")
+
+	plan := planner.PlanJob(SingleActionJobID)
+	assert.Len(t, plan.Stages, 1)
+	assert.Len(t, plan.Stages[0].Runs, 1)
+
+	job := plan.Stages[0].Runs[0].Job()
+	assert.Equal(t, []string{"self-hosted"}, job.RunsOn())
+	assert.Len(t, job.Steps, 1, "only the action runs, the workspace isn't checked out")
+	assert.Equal(t, SingleActionJobID, job.Steps[0].ID)
+	assert.Equal(t, "actions/setup-node@v2", job.Steps[0].Uses)
+	assert.Equal(t, "16", job.Steps[0].With["node-version"])
+	assert.Equal(t, []string{"workflow_dispatch"}, planner.GetEvents())
+}
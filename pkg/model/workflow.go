@@ -16,12 +16,20 @@ import (
 
 // Workflow is the structure of the files in .github/workflows
 type Workflow struct {
-	File     string
-	Name     string            `yaml:"name"`
-	RawOn    yaml.Node         `yaml:"on"`
-	Env      map[string]string `yaml:"env"`
-	Jobs     map[string]*Job   `yaml:"jobs"`
-	Defaults Defaults          `yaml:"defaults"`
+	File           string
+	Path           string            `yaml:"-"` // absolute path of the workflow file, empty for workflows that aren't read from a file
+	Name           string            `yaml:"name"`
+	RawOn          yaml.Node         `yaml:"on"`
+	Env            map[string]string `yaml:"env"`
+	Jobs           map[string]*Job   `yaml:"jobs"`
+	Defaults       Defaults          `yaml:"defaults"`
+	RawConcurrency yaml.Node         `yaml:"concurrency"`
+}
+
+// Concurrency of a workflow, runs sharing the Group don't run at the same time
+type Concurrency struct {
+	Group            string `yaml:"group"`
+	CancelInProgress bool   `yaml:"cancel-in-progress"`
 }
 
 // CompositeRestrictions is the structure to control what is allowed in composite actions
@@ -71,6 +79,65 @@ func (w *Workflow) On() []string {
 	return nil
 }
 
+// Concurrency returns the concurrency of the workflow, it is nil if the workflow doesn't set one
+func (w *Workflow) Concurrency() *Concurrency {
+	switch w.RawConcurrency.Kind {
+	case yaml.ScalarNode:
+		return &Concurrency{Group: w.RawConcurrency.Value}
+	case yaml.MappingNode:
+		val := new(Concurrency)
+		if err := w.RawConcurrency.Decode(val); err != nil {
+			log.Errorf("Failed to parse 'concurrency' of the workflow: %v", err)
+			return nil
+		}
+		return val
+	}
+	return nil
+}
+
+// WorkflowDispatchInput is an input declared by the workflow_dispatch event of a workflow
+type WorkflowDispatchInput struct {
+	Description string   `yaml:"description"`
+	Required    bool     `yaml:"required"`
+	Default     string   `yaml:"default"`
+	Type        string   `yaml:"type"`
+	Options     []string `yaml:"options"`
+}
+
+// WorkflowDispatch is the configuration of the workflow_dispatch event
+type WorkflowDispatch struct {
+	Inputs map[string]WorkflowDispatchInput `yaml:"inputs"`
+}
+
+// WorkflowDispatchConfig returns the configuration of the workflow_dispatch event, it is nil if the workflow isn't triggered by it
+func (w *Workflow) WorkflowDispatchConfig() *WorkflowDispatch {
+	switch w.RawOn.Kind {
+	case yaml.ScalarNode, yaml.SequenceNode:
+		for _, event := range w.On() {
+			if event == "workflow_dispatch" {
+				return &WorkflowDispatch{}
+			}
+		}
+	case yaml.MappingNode:
+		var val map[string]yaml.Node
+		if err := w.RawOn.Decode(&val); err != nil {
+			log.Errorf("Failed to parse 'on' of the workflow: %v", err)
+			return nil
+		}
+		node, ok := val["workflow_dispatch"]
+		if !ok {
+			return nil
+		}
+		config := &WorkflowDispatch{}
+		if err := node.Decode(config); err != nil {
+			log.Errorf("Failed to parse 'on.workflow_dispatch' of the workflow: %v", err)
+			return nil
+		}
+		return config
+	}
+	return nil
+}
+
 // Job is the structure of one job in a workflow
 type Job struct {
 	Name           string                    `yaml:"name"`
@@ -85,6 +152,8 @@ type Job struct {
 	RawContainer   yaml.Node                 `yaml:"container"`
 	Defaults       Defaults                  `yaml:"defaults"`
 	Outputs        map[string]string         `yaml:"outputs"`
+	Uses           string                    `yaml:"uses"`
+	RawSecrets     yaml.Node                 `yaml:"secrets"`
 	Result         string
 }
 
@@ -180,7 +249,7 @@ func (j *Job) Needs() []string {
 	return nil
 }
 
-// RunsOn list for Job
+// RunsOn list for Job, the object form with group and labels returns the labels
 func (j *Job) RunsOn() []string {
 	switch j.RawRunsOn.Kind {
 	case yaml.ScalarNode:
@@ -197,6 +266,22 @@ func (j *Job) RunsOn() []string {
 			log.Fatal(err)
 		}
 		return val
+	case yaml.MappingNode:
+		var val struct {
+			Group  string
+			Labels yaml.Node
+		}
+		err := j.RawRunsOn.Decode(&val)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// runners of a group aren't known locally, the job runs on the platform of its labels
+		// or else on the platform named like the group
+		labels := (&Job{RawRunsOn: val.Labels}).RunsOn()
+		if len(labels) == 0 && val.Group != "" {
+			return []string{val.Group}
+		}
+		return labels
 	}
 	return nil
 }
@@ -216,6 +301,25 @@ func (j *Job) Environment() map[string]string {
 	return environment(j.Env)
 }
 
+// InheritSecrets returns true if the job passes all secrets of the caller to the reusable workflow it uses
+func (j *Job) InheritSecrets() bool {
+	return j.RawSecrets.Kind == yaml.ScalarNode && j.RawSecrets.Value == "inherit"
+}
+
+// Secrets returns the secrets the job passes by name to the reusable workflow it uses, the values are
+// expressions. It is nil with `secrets: inherit`
+func (j *Job) Secrets() map[string]string {
+	if j.RawSecrets.Kind != yaml.MappingNode {
+		return nil
+	}
+	var val map[string]string
+	if err := j.RawSecrets.Decode(&val); err != nil {
+		log.Errorf("Failed to parse 'secrets' of the job: %v", err)
+		return nil
+	}
+	return val
+}
+
 // Matrix decodes RawMatrix YAML node
 func (j *Job) Matrix() map[string][]interface{} {
 	if j.Strategy.RawMatrix.Kind == yaml.MappingNode {
@@ -335,7 +439,7 @@ type Step struct {
 	Shell            string            `yaml:"shell"`
 	Env              yaml.Node         `yaml:"env"`
 	With             map[string]string `yaml:"with"`
-	ContinueOnError  bool              `yaml:"continue-on-error"`
+	ContinueOnError  string            `yaml:"continue-on-error"`
 	TimeoutMinutes   int64             `yaml:"timeout-minutes"`
 }
 
@@ -437,7 +541,7 @@ func (s *Step) Validate(config *CompositeRestrictions) error {
 		return fmt.Errorf("(StepID: %s): Required property is missing: 'shell'", s.String())
 	} else if !s.If.IsZero() && !config.AllowCompositeIf {
 		return fmt.Errorf("(StepID: %s): Property is not available: 'if'", s.String())
-	} else if s.ContinueOnError && !config.AllowCompositeContinueOnError {
+	} else if s.ContinueOnError != "" && !config.AllowCompositeContinueOnError {
 		return fmt.Errorf("(StepID: %s): Property is not available: 'continue-on-error'", s.String())
 	}
 	return nil
@@ -129,6 +129,76 @@ jobs:
 	assert.Contains(t, container.Credentials["password"], "registry-password")
 }
 
+func TestJob_Container(t *testing.T) {
+	tables := []struct {
+		container string
+		expected  *ContainerSpec
+	}{
+		{"", nil},
+		{"container: node:18", &ContainerSpec{Image: "node:18"}},
+		{"container:\n  image: node:18\n  options: --cpus 1", &ContainerSpec{Image: "node:18", Options: "--cpus 1"}},
+	}
+
+	for _, table := range tables {
+		workflow, err := ReadWorkflow(strings.NewReader("jobs:\n  test:\n    runs-on: ubuntu-latest\n" + indent(table.container, "    ")))
+		assert.NoError(t, err, table.container)
+		assert.Equal(t, table.expected, workflow.GetJob("test").Container(), table.container)
+	}
+}
+
+func TestJob_RunsOn(t *testing.T) {
+	tables := []struct {
+		runsOn   string
+		expected []string
+	}{
+		{"runs-on: ubuntu-latest", []string{"ubuntu-latest"}},
+		{"runs-on: [self-hosted, linux]", []string{"self-hosted", "linux"}},
+		{"runs-on:\n  group: ubuntu-runners\n  labels: ubuntu-20.04", []string{"ubuntu-20.04"}},
+		{"runs-on:\n  group: ubuntu-runners\n  labels: [self-hosted, ubuntu-20.04]", []string{"self-hosted", "ubuntu-20.04"}},
+		{"runs-on:\n  group: ubuntu-runners", []string{"ubuntu-runners"}},
+	}
+
+	for _, table := range tables {
+		workflow, err := ReadWorkflow(strings.NewReader("jobs:\n  test:\n" + indent(table.runsOn, "    ")))
+		assert.NoError(t, err, table.runsOn)
+		assert.Equal(t, table.expected, workflow.GetJob("test").RunsOn(), table.runsOn)
+	}
+}
+
+func indent(s string, prefix string) string {
+	if s == "" {
+		return s
+	}
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+func TestReadWorkflow_JobSecrets(t *testing.T) {
+	yaml := `
+name: reusable
+
+jobs:
+  inherit:
+    uses: ./.github/workflows/deploy.yml
+    secrets: inherit
+  named:
+    uses: ./.github/workflows/deploy.yml
+    secrets:
+      token: ${{ secrets.DEPLOY_TOKEN }}
+`
+
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	inherit := workflow.GetJob("inherit")
+	assert.Equal(t, "./.github/workflows/deploy.yml", inherit.Uses)
+	assert.True(t, inherit.InheritSecrets())
+	assert.Nil(t, inherit.Secrets())
+
+	named := workflow.GetJob("named")
+	assert.False(t, named.InheritSecrets())
+	assert.Equal(t, map[string]string{"token": "${{ secrets.DEPLOY_TOKEN }}"}, named.Secrets())
+}
+
 func TestReadWorkflow_StepsTypes(t *testing.T) {
 	yaml := `
 name: invalid step definition
@@ -203,6 +273,87 @@ jobs:
 	assert.Equal(t, "${{ steps.test1_1.outputs.b-key }}", workflow.Jobs["test1"].Outputs["some-b-key"])
 }
 
+func TestReadWorkflow_WorkflowDispatchConfig(t *testing.T) {
+	yaml := `
+name: workflow_dispatch inputs
+on:
+  push:
+  workflow_dispatch:
+    inputs:
+      debug:
+        type: boolean
+        default: "false"
+      environment:
+        type: choice
+        required: true
+        options:
+          - staging
+          - production
+
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ inputs.environment }}
+`
+
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	config := workflow.WorkflowDispatchConfig()
+	assert.NotNil(t, config)
+	assert.Len(t, config.Inputs, 2)
+	assert.Equal(t, WorkflowDispatchInput{Type: "boolean", Default: "false"}, config.Inputs["debug"])
+	assert.Equal(t, WorkflowDispatchInput{Type: "choice", Required: true, Options: []string{"staging", "production"}}, config.Inputs["environment"])
+
+	workflow, err = ReadWorkflow(strings.NewReader("on: [push, workflow_dispatch]\njobs: {}\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, &WorkflowDispatch{}, workflow.WorkflowDispatchConfig())
+
+	workflow, err = ReadWorkflow(strings.NewReader("on: push\njobs: {}\n"))
+	assert.NoError(t, err)
+	assert.Nil(t, workflow.WorkflowDispatchConfig())
+}
+
+func TestJob_GetMatrixesOrder(t *testing.T) {
+	yaml := `
+name: matrix order
+on: push
+
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        shard: [1, 2, 3]
+        os: [ubuntu-latest, windows-latest]
+        node: [14, 16]
+        exclude:
+          - os: windows-latest
+            node: 14
+        include:
+          - os: macos-latest
+            node: 16
+            shard: 1
+    steps:
+      - run: echo ${{ strategy.job-index }}
+`
+	var expected []map[string]interface{}
+	for i := 0; i < 10; i++ {
+		workflow, err := ReadWorkflow(strings.NewReader(yaml))
+		assert.NoError(t, err, "read workflow should succeed")
+		matrixes := workflow.GetJob("test").GetMatrixes()
+		if expected == nil {
+			expected = matrixes
+		}
+		assert.Equal(t, expected, matrixes)
+	}
+	assert.Len(t, expected, 10)
+	assert.Equal(t, map[string]interface{}{"node": 14, "os": "ubuntu-latest", "shard": 1}, expected[0])
+	assert.Equal(t, map[string]interface{}{"node": 16, "os": "ubuntu-latest", "shard": 1}, expected[3])
+	assert.Equal(t, map[string]interface{}{"node": 16, "os": "macos-latest", "shard": 1}, expected[9])
+}
+
 func TestReadWorkflow_Strategy(t *testing.T) {
 	w, err := NewWorkflowPlanner("testdata/strategy/push.yml", true)
 	assert.NoError(t, err)
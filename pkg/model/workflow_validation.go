@@ -0,0 +1,186 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rhysd/actionlint"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError is a mistake found in a workflow, Line is 0 if the position isn't known
+type ValidationError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Message)
+}
+
+// ValidationErrors are all the mistakes found in the workflows
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("the workflow is not valid:\n%s", strings.Join(messages, "\n"))
+}
+
+// Validate checks the workflow for mistakes that would otherwise only fail once it runs: undefined or
+// cyclic needs, missing runs-on, duplicate step ids and if expressions that can't be parsed. It returns all of them at once
+// as ValidationErrors. Duplicate job ids are already reported by the yaml decoder when reading the workflow
+func (w *Workflow) Validate() error {
+	errs := ValidationErrors{}
+	add := func(node *yaml.Node, format string, args ...interface{}) {
+		line := 0
+		if node != nil {
+			line = node.Line
+		}
+		errs = append(errs, ValidationError{File: w.File, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	jobIDs := w.GetJobIDs()
+	sort.Strings(jobIDs)
+	for _, jobID := range jobIDs {
+		job := w.Jobs[jobID]
+		if job == nil {
+			add(nil, "job '%s' is empty", jobID)
+			continue
+		}
+		// a job calling a reusable workflow runs on the runners of the jobs of that workflow
+		if job.RawRunsOn.IsZero() && job.Uses == "" {
+			add(nil, "job '%s' is missing 'runs-on'", jobID)
+		}
+		for _, need := range neededJobs(job) {
+			if _, ok := w.Jobs[need]; !ok {
+				add(&job.RawNeeds, "job '%s' needs job '%s', which doesn't exist", jobID, need)
+			}
+		}
+		if err := validateIf(job.If); err != nil {
+			add(&job.If, "job '%s' has an invalid 'if': %v", jobID, err)
+		}
+		for _, id := range DuplicateStepIDs(job.Steps) {
+			add(nil, "job '%s' has more than one step with id '%s'", jobID, id)
+		}
+		for i, step := range job.Steps {
+			if step == nil {
+				continue
+			}
+			if err := validateIf(step.If); err != nil {
+				name := step.String()
+				if name == "" {
+					name = fmt.Sprintf("#%d", i+1)
+				}
+				add(&step.If, "step '%s' of job '%s' has an invalid 'if': %v", name, jobID, err)
+			}
+		}
+	}
+
+	if cycle := w.needsCycle(jobIDs); cycle != nil {
+		add(&w.Jobs[cycle[0]].RawNeeds, "jobs depend on each other in a cycle: %s", strings.Join(cycle, " -> "))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// DuplicateStepIDs returns the ids used by more than one of the steps, in the order of the steps. The results
+// of the steps are stored by id, so a duplicate would overwrite the outputs and outcome of the earlier step
+func DuplicateStepIDs(steps []*Step) []string {
+	seen := map[string]bool{}
+	duplicates := []string{}
+	for _, step := range steps {
+		if step == nil || step.ID == "" {
+			continue
+		}
+		if reported, ok := seen[step.ID]; ok {
+			if !reported {
+				duplicates = append(duplicates, step.ID)
+				seen[step.ID] = true
+			}
+			continue
+		}
+		seen[step.ID] = false
+	}
+	return duplicates
+}
+
+// neededJobs returns the needs of the job without failing on a malformed value, the yaml decoder reports those
+func neededJobs(job *Job) []string {
+	switch job.RawNeeds.Kind {
+	case yaml.ScalarNode:
+		return []string{job.RawNeeds.Value}
+	case yaml.SequenceNode:
+		var needs []string
+		if err := job.RawNeeds.Decode(&needs); err == nil {
+			return needs
+		}
+	}
+	return nil
+}
+
+// needsCycle returns the jobs of a cycle in the needs, starting and ending with the same job, or nil without one
+func (w *Workflow) needsCycle(jobIDs []string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	path := []string{}
+	var visit func(jobID string) []string
+	visit = func(jobID string) []string {
+		switch state[jobID] {
+		case visiting:
+			for i, id := range path {
+				if id == jobID {
+					return append(append([]string{}, path[i:]...), jobID)
+				}
+			}
+		case visited:
+			return nil
+		}
+		job, ok := w.Jobs[jobID]
+		if !ok || job == nil {
+			return nil
+		}
+		state[jobID] = visiting
+		path = append(path, jobID)
+		for _, need := range neededJobs(job) {
+			if cycle := visit(need); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[jobID] = visited
+		return nil
+	}
+	for _, jobID := range jobIDs {
+		if cycle := visit(jobID); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// validateIf parses an if expression the way the expression interpreter does
+func validateIf(node yaml.Node) error {
+	if node.Value == "" {
+		return nil
+	}
+	input := strings.TrimPrefix(strings.TrimSpace(node.Value), "${{")
+	if _, err := actionlint.NewExprParser().Parse(actionlint.NewExprLexer(input + "}}")); err != nil {
+		return fmt.Errorf("%s", err.Message)
+	}
+	return nil
+}
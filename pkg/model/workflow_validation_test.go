@@ -0,0 +1,45 @@
+package model
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowValidate(t *testing.T) {
+	f, err := os.Open("testdata/invalid-workflow/push.yml")
+	assert.NoError(t, err)
+	defer f.Close()
+	workflow, err := ReadWorkflow(f)
+	assert.NoError(t, err)
+	workflow.File = "push.yml"
+
+	err = workflow.Validate()
+	assert.Error(t, err)
+	errs, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+	if assert.Len(t, errs, 5) {
+		assert.Equal(t, ValidationError{File: "push.yml", Line: 7, Message: "job 'build' needs job 'missing', which doesn't exist"}, errs[0])
+		assert.Equal(t, 10, errs[1].Line)
+		assert.Contains(t, errs[1].Message, "step 'echo build' of job 'build' has an invalid 'if'")
+		assert.Equal(t, ValidationError{File: "push.yml", Message: "job 'lint' is missing 'runs-on'"}, errs[2])
+		assert.Equal(t, ValidationError{File: "push.yml", Message: "job 'test' has more than one step with id 'test'"}, errs[3])
+		assert.Equal(t, ValidationError{File: "push.yml", Line: 12, Message: "jobs depend on each other in a cycle: lint -> test -> lint"}, errs[4])
+	}
+	assert.Contains(t, err.Error(), "push.yml:7: job 'build' needs job 'missing'")
+	assert.Contains(t, err.Error(), "push.yml: job 'lint' is missing 'runs-on'")
+	assert.NotContains(t, err.Error(), "job 'reuse'")
+}
+
+func TestDuplicateStepIDs(t *testing.T) {
+	steps := []*Step{{ID: "a"}, {}, {ID: "b"}, {ID: "a"}, {}, {ID: "b"}, {ID: "a"}}
+	assert.Equal(t, []string{"a", "b"}, DuplicateStepIDs(steps))
+	assert.Empty(t, DuplicateStepIDs([]*Step{{ID: "a"}, {}, {}}))
+}
+
+func TestWorkflowValidateValid(t *testing.T) {
+	planner, err := NewWorkflowPlanner("testdata/strategy/push.yml", true)
+	assert.NoError(t, err)
+	assert.NoError(t, planner.Validate())
+}
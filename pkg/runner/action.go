@@ -80,5 +80,10 @@ func (sc *StepContext) readAction(step *model.Step, actionDir string, actionPath
 
 	action, err := model.ReadAction(reader)
 	log.Debugf("Read action %v from '%s'", action, "Unknown")
+	if err == nil && sc.RunContext != nil && sc.RunContext.Config.ValidateActions {
+		for _, problem := range action.Validate() {
+			log.Warnf("\U000026A0  action '%s': %s", step.Uses, problem)
+		}
+	}
 	return action, err
 }
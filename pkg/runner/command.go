@@ -16,6 +16,11 @@ func init() {
 	commandPatternADO = regexp.MustCompile("^##\\[([^ ]+)( (.+))?]([^\r\n]*)[\r\n]+$")
 }
 
+// isWorkflowCommand returns whether the line is handled by the commandHandler
+func isWorkflowCommand(line string) bool {
+	return commandPatternGA.MatchString(line) || commandPatternADO.MatchString(line)
+}
+
 func (rc *RunContext) commandHandler(ctx context.Context) common.LineHandler {
 	logger := common.Logger(ctx)
 	resumeCommand := ""
@@ -72,12 +77,19 @@ func (rc *RunContext) commandHandler(ctx context.Context) common.LineHandler {
 
 func (rc *RunContext) setEnv(ctx context.Context, kvPairs map[string]string, arg string) {
 	common.Logger(ctx).Infof("  \U00002699  ::set-env:: %s=%s", kvPairs["name"], arg)
+	mu := rc.stateLock()
+	mu.Lock()
+	defer mu.Unlock()
 	if rc.Env == nil {
 		rc.Env = make(map[string]string)
 	}
 	rc.Env[kvPairs["name"]] = arg
 }
 func (rc *RunContext) setOutput(ctx context.Context, kvPairs map[string]string, arg string) {
+	mu := rc.stateLock()
+	mu.Lock()
+	defer mu.Unlock()
+
 	stepID := rc.CurrentStep
 	outputName := kvPairs["name"]
 	if outputMapping, ok := rc.OutputMappings[MappableOutput{StepID: stepID, OutputName: outputName}]; ok {
@@ -96,6 +108,9 @@ func (rc *RunContext) setOutput(ctx context.Context, kvPairs map[string]string,
 }
 func (rc *RunContext) addPath(ctx context.Context, arg string) {
 	common.Logger(ctx).Infof("  \U00002699  ::add-path:: %s", arg)
+	mu := rc.stateLock()
+	mu.Lock()
+	defer mu.Unlock()
 	rc.ExtraPath = append(rc.ExtraPath, arg)
 }
 
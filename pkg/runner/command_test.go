@@ -2,6 +2,8 @@ package runner
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/sirupsen/logrus/hooks/test"
@@ -51,6 +53,32 @@ func TestSetOutput(t *testing.T) {
 	a.Equal("percent2%\ntest", rc.StepResults["my-step"].Outputs["x:,\n%\r:"])
 }
 
+func TestSetOutputConcurrent(t *testing.T) {
+	ctx := context.Background()
+	rc := new(RunContext)
+	rc.CurrentStep = "my-step"
+	rc.StepResults = map[string]*model.StepResult{
+		"my-step": {Outputs: make(map[string]string)},
+	}
+	handler := rc.commandHandler(ctx)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			handler(fmt.Sprintf("::set-output name=x%d::val%d\n", i, i))
+			handler(fmt.Sprintf("::set-env name=x%d::val%d\n", i, i))
+			_ = rc.getStepsContext()
+		}(i)
+	}
+	wg.Wait()
+
+	steps := rc.getStepsContext()
+	assert.Len(t, steps["my-step"].Outputs, 10)
+	assert.Len(t, rc.Env, 10)
+}
+
 func TestAddpath(t *testing.T) {
 	a := assert.New(t)
 	ctx := context.Background()
@@ -0,0 +1,194 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/ankit-arora/act/pkg/common"
+	"github.com/ankit-arora/act/pkg/model"
+)
+
+// ConcurrencyLock acquires a concurrency group across act processes, it blocks until the group is held
+// or ctx is done and returns the function releasing the group. Jobs of workflows sharing a group already
+// wait for each other within a run, the lock is the hook to serialize runs of several processes, e.g. of a CI
+// harness. It is taken once per group and process, while any job of the process holds the group.
+// cancelInProgress is the cancel-in-progress of the workflow, a lock may ignore it and wait instead
+type ConcurrencyLock func(ctx context.Context, group string, cancelInProgress bool) (release func(), err error)
+
+// concurrencyGroup is a concurrency group in use within this process, it is held by the jobs of one workflow
+type concurrencyGroup struct {
+	owner    *model.Workflow
+	cancels  map[*RunContext]context.CancelFunc
+	released chan struct{}
+}
+
+// concurrencyLock is the ConcurrencyLock of a group held by this process, it is acquired by the first
+// job of the group and released by the last one, the jobs in between only count the references
+type concurrencyLock struct {
+	mu      sync.Mutex
+	refs    int
+	release func()
+}
+
+var (
+	concurrencyGroupsMu sync.Mutex
+	concurrencyGroups   = map[string]*concurrencyGroup{}
+	concurrencyLocks    = map[string]*concurrencyLock{}
+)
+
+// withConcurrency runs the job once the concurrency group of its workflow is free, a job of another
+// workflow holding the group is cancelled if the workflow sets cancel-in-progress, else it is waited for
+func (rc *RunContext) withConcurrency(executor common.Executor) common.Executor {
+	return func(ctx context.Context) error {
+		concurrency := rc.Run.Workflow.Concurrency()
+		if concurrency == nil {
+			return executor(ctx)
+		}
+		group := rc.NewExpressionEvaluator().Interpolate(concurrency.Group)
+		if group == "" {
+			return executor(ctx)
+		}
+
+		jobCtx, release, err := rc.acquireConcurrencyGroup(ctx, group, concurrency.CancelInProgress)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		if rc.Config.ConcurrencyLock != nil {
+			releaseLock, err := rc.holdConcurrencyLock(ctx, group, concurrency.CancelInProgress)
+			if err != nil {
+				return fmt.Errorf("failed to acquire concurrency group '%s': %w", group, err)
+			}
+			defer releaseLock()
+		}
+		return executor(jobCtx)
+	}
+}
+
+// holdConcurrencyLock takes the ConcurrencyLock of the group once per process, the jobs of the process
+// holding the group already are ordered by acquireConcurrencyGroup and only add a reference to the lock
+func (rc *RunContext) holdConcurrencyLock(ctx context.Context, group string, cancelInProgress bool) (func(), error) {
+	concurrencyGroupsMu.Lock()
+	l, ok := concurrencyLocks[group]
+	if !ok {
+		l = &concurrencyLock{}
+		concurrencyLocks[group] = l
+	}
+	concurrencyGroupsMu.Unlock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.refs == 0 {
+		release, err := rc.Config.ConcurrencyLock(ctx, group, cancelInProgress)
+		if err != nil {
+			return nil, err
+		}
+		l.release = release
+	}
+	l.refs++
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			l.release()
+			l.release = nil
+		}
+	}, nil
+}
+
+// acquireConcurrencyGroup waits until the group is free or held by the workflow of the job, the returned
+// context is cancelled if a later workflow with cancel-in-progress takes over the group
+func (rc *RunContext) acquireConcurrencyGroup(ctx context.Context, group string, cancelInProgress bool) (context.Context, func(), error) {
+	logger := common.Logger(ctx)
+	for {
+		concurrencyGroupsMu.Lock()
+		g, ok := concurrencyGroups[group]
+		if !ok {
+			g = &concurrencyGroup{
+				cancels:  map[*RunContext]context.CancelFunc{},
+				released: make(chan struct{}),
+			}
+			concurrencyGroups[group] = g
+		}
+		if len(g.cancels) == 0 || g.owner == rc.Run.Workflow {
+			g.owner = rc.Run.Workflow
+			jobCtx, cancel := context.WithCancel(ctx)
+			g.cancels[rc] = cancel
+			concurrencyGroupsMu.Unlock()
+			return jobCtx, func() {
+				concurrencyGroupsMu.Lock()
+				defer concurrencyGroupsMu.Unlock()
+				cancel()
+				delete(g.cancels, rc)
+				if len(g.cancels) == 0 {
+					g.owner = nil
+					close(g.released)
+					g.released = make(chan struct{})
+				}
+			}, nil
+		}
+		if cancelInProgress {
+			logger.Infof("Cancelling the jobs of workflow '%s' in concurrency group '%s'", g.owner.Name, group)
+			for _, cancel := range g.cancels {
+				cancel()
+			}
+		} else {
+			logger.Infof("Waiting for the jobs of workflow '%s' in concurrency group '%s'", g.owner.Name, group)
+		}
+		released := g.released
+		concurrencyGroupsMu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+// concurrencyLockPollInterval is the interval NewFileConcurrencyLock checks if a lock was released at
+var concurrencyLockPollInterval = 500 * time.Millisecond
+
+var concurrencyLockNameRegex = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// NewFileConcurrencyLock returns a ConcurrencyLock holding a group through a lock file in dir, which
+// must be shared by the processes. It always waits for the holder, a process can't cancel another one.
+// The lock file of a process that was killed has to be removed by hand
+func NewFileConcurrencyLock(dir string) ConcurrencyLock {
+	return func(ctx context.Context, group string, cancelInProgress bool) (func(), error) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		lockFile := filepath.Join(dir, concurrencyLockNameRegex.ReplaceAllString(group, "_")+".lock")
+		logged := false
+		for {
+			f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+			if err == nil {
+				_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+				f.Close()
+				return func() {
+					_ = os.Remove(lockFile)
+				}, nil
+			}
+			if !os.IsExist(err) {
+				return nil, err
+			}
+			if !logged {
+				common.Logger(ctx).Infof("Waiting for concurrency group '%s' held by another process (%s)", group, lockFile)
+				logged = true
+			}
+			select {
+			case <-time.After(concurrencyLockPollInterval):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+}
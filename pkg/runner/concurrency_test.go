@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankit-arora/act/pkg/model"
+)
+
+func newConcurrencyRunContext(workflow *model.Workflow) *RunContext {
+	return &RunContext{
+		Config: &Config{},
+		Run:    &model.Run{Workflow: workflow, JobID: "job"},
+	}
+}
+
+func TestRunContextAcquireConcurrencyGroup(t *testing.T) {
+	first := &model.Workflow{Name: "first", Jobs: map[string]*model.Job{"job": {}}}
+	second := &model.Workflow{Name: "second", Jobs: map[string]*model.Job{"job": {}}}
+	ctx := context.Background()
+
+	firstCtx, releaseFirst, err := newConcurrencyRunContext(first).acquireConcurrencyGroup(ctx, "test-wait", false)
+	assert.NoError(t, err)
+	// jobs of the same workflow share the group
+	_, releaseSibling, err := newConcurrencyRunContext(first).acquireConcurrencyGroup(ctx, "test-wait", false)
+	assert.NoError(t, err)
+
+	acquired := make(chan struct{})
+	go func() {
+		_, release, err := newConcurrencyRunContext(second).acquireConcurrencyGroup(ctx, "test-wait", false)
+		assert.NoError(t, err)
+		close(acquired)
+		release()
+	}()
+
+	releaseFirst()
+	select {
+	case <-acquired:
+		t.Fatal("the group was acquired while a job of the first workflow still holds it")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Error(t, firstCtx.Err())
+	releaseSibling()
+	<-acquired
+
+	firstCtx, releaseFirst, err = newConcurrencyRunContext(first).acquireConcurrencyGroup(ctx, "test-cancel", false)
+	assert.NoError(t, err)
+	go func() {
+		<-firstCtx.Done()
+		releaseFirst()
+	}()
+	_, release, err := newConcurrencyRunContext(second).acquireConcurrencyGroup(ctx, "test-cancel", true)
+	assert.NoError(t, err)
+	assert.Equal(t, context.Canceled, firstCtx.Err())
+	release()
+}
+
+func TestFileConcurrencyLock(t *testing.T) {
+	defer func(interval time.Duration) { concurrencyLockPollInterval = interval }(concurrencyLockPollInterval)
+	concurrencyLockPollInterval = time.Millisecond
+
+	dir := t.TempDir()
+	lock := NewFileConcurrencyLock(dir)
+	release, err := lock(context.Background(), "ci/main", false)
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "ci_main.lock"))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = lock(ctx, "ci/main", false)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	release()
+	release, err = lock(context.Background(), "ci/main", false)
+	assert.NoError(t, err)
+	release()
+}
+
+func TestRunContextHoldConcurrencyLock(t *testing.T) {
+	var mu sync.Mutex
+	acquired, released := 0, 0
+	config := &Config{
+		ConcurrencyLock: func(ctx context.Context, group string, cancelInProgress bool) (func(), error) {
+			mu.Lock()
+			defer mu.Unlock()
+			acquired++
+			return func() {
+				mu.Lock()
+				defer mu.Unlock()
+				released++
+			}, nil
+		},
+	}
+	workflow := &model.Workflow{Name: "workflow", Jobs: map[string]*model.Job{"job": {}}}
+	ctx := context.Background()
+
+	// the jobs of a workflow take the lock of the process once
+	releases := make([]func(), 0)
+	for i := 0; i < 3; i++ {
+		rc := newConcurrencyRunContext(workflow)
+		rc.Config = config
+		release, err := rc.holdConcurrencyLock(ctx, "test-lock", false)
+		assert.NoError(t, err)
+		releases = append(releases, release)
+	}
+	assert.Equal(t, 1, acquired)
+	for _, release := range releases {
+		assert.Equal(t, 0, released)
+		release()
+	}
+	assert.Equal(t, 1, released)
+
+	rc := newConcurrencyRunContext(workflow)
+	rc.Config = config
+	release, err := rc.holdConcurrencyLock(ctx, "test-lock", false)
+	assert.NoError(t, err)
+	release()
+	assert.Equal(t, 2, acquired)
+	assert.Equal(t, 2, released)
+}
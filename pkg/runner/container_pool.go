@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// containerPool hands out the names of warm job containers, which are kept alive
+// between runs and are reset instead of being recreated
+type containerPool struct {
+	mu    sync.Mutex
+	inUse map[string][]bool
+}
+
+var jobContainerPool = &containerPool{
+	inUse: map[string][]bool{},
+}
+
+// poolKey identifies containers which are interchangeable, a container is only reused for the same image,
+// platform and workdir since the binds depend on it, and the same settings it was created with
+func poolKey(image string, platform string, workdir string, settings ...string) string {
+	parts := append([]string{image, platform, workdir}, settings...)
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return createContainerName("act-pool", image) + "-" + hex.EncodeToString(hash[:])[:8]
+}
+
+// acquire returns the name of a free container of the pool, or false if all of them are in use
+func (p *containerPool) acquire(key string, size int) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	slots := p.inUse[key]
+	if len(slots) < size {
+		slots = append(slots, make([]bool, size-len(slots))...)
+		p.inUse[key] = slots
+	}
+	for i := 0; i < size; i++ {
+		if !slots[i] {
+			slots[i] = true
+			return fmt.Sprintf("%s-%d", key, i), true
+		}
+	}
+	return "", false
+}
+
+// release marks the container name returned by acquire as free
+func (p *containerPool) release(key string, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := range p.inUse[key] {
+		if fmt.Sprintf("%s-%d", key, i) == name {
+			p.inUse[key][i] = false
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankit-arora/act/pkg/model"
+)
+
+func TestContainerPool(t *testing.T) {
+	pool := &containerPool{inUse: map[string][]bool{}}
+	key := poolKey("node:16-buster-slim", "", "/workdir")
+
+	first, ok := pool.acquire(key, 2)
+	assert.True(t, ok)
+	second, ok := pool.acquire(key, 2)
+	assert.True(t, ok)
+	assert.NotEqual(t, first, second)
+
+	_, ok = pool.acquire(key, 2)
+	assert.False(t, ok, "pool should be exhausted")
+
+	pool.release(key, first)
+	name, ok := pool.acquire(key, 2)
+	assert.True(t, ok)
+	assert.Equal(t, first, name)
+
+	assert.NotEqual(t, key, poolKey("node:16-buster-slim", "", "/other"))
+}
+
+func TestRunContextContainerPoolSettings(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest
+container:
+  image: node:16-buster-slim
+  options: --memory 1g`, ""),
+		"large": createJob(t, `runs-on: ubuntu-latest
+container:
+  image: node:16-buster-slim
+  options: --memory 8g`, ""),
+		"plain": createJob(t, `runs-on: ubuntu-latest
+container:
+  image: node:16-buster-slim`, ""),
+	})
+	rc.Config.ContainerPoolSize = 2
+	ctx := context.Background()
+
+	keys := map[string]string{}
+	for _, jobID := range []string{"job1", "large", "plain"} {
+		rc.Run.JobID = jobID
+		rc.acquirePooledContainer(ctx, "node:16-buster-slim", "", "")
+		keys[jobID] = rc.containerPoolKey
+		rc.releasePooledContainer()
+	}
+	// the jobs share the image, but a container created with other options can't be reused
+	assert.NotEqual(t, keys["job1"], keys["large"])
+	assert.NotEqual(t, keys["job1"], keys["plain"])
+
+	rc.Run.JobID = "job1"
+	rc.acquirePooledContainer(ctx, "node:16-buster-slim", "", "")
+	assert.Equal(t, keys["job1"], rc.containerPoolKey)
+	rc.releasePooledContainer()
+	rc.acquirePooledContainer(ctx, "node:16-buster-slim", "user", "password")
+	assert.NotEqual(t, keys["job1"], rc.containerPoolKey, "the credentials are part of the key")
+	rc.releasePooledContainer()
+}
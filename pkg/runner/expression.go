@@ -21,26 +21,11 @@ type ExpressionEvaluator interface {
 // NewExpressionEvaluator creates a new evaluator
 func (rc *RunContext) NewExpressionEvaluator() ExpressionEvaluator {
 	// todo: cleanup EvaluationEnvironment creation
-	job := rc.Run.Job()
-	strategy := make(map[string]interface{})
-	if job.Strategy != nil {
-		strategy["fail-fast"] = job.Strategy.FailFast
-		strategy["max-parallel"] = job.Strategy.MaxParallel
-	}
-
-	jobs := rc.Run.Workflow.Jobs
-	jobNeeds := rc.Run.Job().Needs()
-
-	using := make(map[string]map[string]map[string]string)
-	for _, needs := range jobNeeds {
-		using[needs] = map[string]map[string]string{
-			"outputs": jobs[needs].Outputs,
-		}
-	}
-
 	secrets := rc.Config.Secrets
+	vars := rc.Config.Vars
 	if rc.Composite != nil {
 		secrets = nil
+		vars = nil
 	}
 
 	ee := &exprparser.EvaluationEnvironment{
@@ -57,23 +42,24 @@ func (rc *RunContext) NewExpressionEvaluator() ExpressionEvaluator {
 			"tool_cache": rc.Env["RUNNER_TOOL_CACHE"],
 		},
 		Secrets:     secrets,
-		Strategy:    strategy,
+		Vars:        vars,
+		Strategy:    rc.getStrategyContext(),
 		Matrix:      rc.Matrix,
-		Needs:       using,
+		Needs:       rc.getNeedsContext(),
 		Inputs:      rc.Inputs,
 		ContextData: rc.ContextData,
 	}
 	if ee.Runner["os"] == "" {
 		ee.Runner = map[string]interface{}{
 			"os":         "Linux",
-			"temp":       "/tmp",
-			"tool_cache": "/opt/hostedtoolcache",
+			"temp":       rc.runnerTemp(),
+			"tool_cache": rc.runnerToolCache(),
 		}
 	}
 	return expressionEvaluator{
 		interpreter: exprparser.NewInterpeter(ee, exprparser.Config{
 			Run:        rc.Run,
-			WorkingDir: rc.Config.Workdir,
+			WorkingDir: rc.workspaceDir(),
 			Context:    "job",
 		}),
 	}
@@ -83,26 +69,11 @@ func (rc *RunContext) NewExpressionEvaluator() ExpressionEvaluator {
 func (sc *StepContext) NewExpressionEvaluator() ExpressionEvaluator {
 	rc := sc.RunContext
 	// todo: cleanup EvaluationEnvironment creation
-	job := rc.Run.Job()
-	strategy := make(map[string]interface{})
-	if job.Strategy != nil {
-		strategy["fail-fast"] = job.Strategy.FailFast
-		strategy["max-parallel"] = job.Strategy.MaxParallel
-	}
-
-	jobs := rc.Run.Workflow.Jobs
-	jobNeeds := rc.Run.Job().Needs()
-
-	using := make(map[string]map[string]map[string]string)
-	for _, needs := range jobNeeds {
-		using[needs] = map[string]map[string]string{
-			"outputs": jobs[needs].Outputs,
-		}
-	}
-
 	secrets := rc.Config.Secrets
+	vars := rc.Config.Vars
 	if rc.Composite != nil {
 		secrets = nil
+		vars = nil
 	}
 
 	ee := &exprparser.EvaluationEnvironment{
@@ -117,9 +88,10 @@ func (sc *StepContext) NewExpressionEvaluator() ExpressionEvaluator {
 			"tool_cache": rc.Env["RUNNER_TOOL_CACHE"],
 		},
 		Secrets:  secrets,
-		Strategy: strategy,
+		Vars:     vars,
+		Strategy: rc.getStrategyContext(),
 		Matrix:   rc.Matrix,
-		Needs:    using,
+		Needs:    rc.getNeedsContext(),
 		// todo: should be unavailable
 		// but required to interpolate/evaluate the inputs in actions/composite
 		Inputs:      rc.Inputs,
@@ -128,19 +100,53 @@ func (sc *StepContext) NewExpressionEvaluator() ExpressionEvaluator {
 	if ee.Runner["os"] == "" {
 		ee.Runner = map[string]interface{}{
 			"os":         "Linux",
-			"temp":       "/tmp",
-			"tool_cache": "/opt/hostedtoolcache",
+			"temp":       rc.runnerTemp(),
+			"tool_cache": rc.runnerToolCache(),
 		}
 	}
 	return expressionEvaluator{
 		interpreter: exprparser.NewInterpeter(ee, exprparser.Config{
 			Run:        rc.Run,
-			WorkingDir: rc.Config.Workdir,
+			WorkingDir: rc.workspaceDir(),
 			Context:    "step",
 		}),
 	}
 }
 
+// getStrategyContext returns the strategy of the job, job-index is the position of the matrix combination
+// of the job in the order of the expansion of the matrix
+func (rc *RunContext) getStrategyContext() map[string]interface{} {
+	strategy := map[string]interface{}{
+		"job-index": rc.jobIndex,
+		"job-total": rc.jobTotal,
+	}
+	if rc.jobTotal == 0 {
+		strategy["job-total"] = 1
+	}
+	if job := rc.Run.Job(); job.Strategy != nil {
+		strategy["fail-fast"] = job.Strategy.FailFast
+		strategy["max-parallel"] = job.Strategy.MaxParallel
+	}
+	return strategy
+}
+
+// getNeedsContext returns the outputs and result of every job the current job needs
+func (rc *RunContext) getNeedsContext() map[string]exprparser.Needs {
+	jobs := rc.Run.Workflow.Jobs
+	using := make(map[string]exprparser.Needs)
+	for _, needs := range rc.Run.Job().Needs() {
+		job, ok := jobs[needs]
+		if !ok {
+			continue
+		}
+		using[needs] = exprparser.Needs{
+			Outputs: job.Outputs,
+			Result:  job.Result,
+		}
+	}
+	return using
+}
+
 type expressionEvaluator struct {
 	interpreter exprparser.Interpreter
 }
@@ -3,6 +3,7 @@ package runner
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"sort"
 	"testing"
 
@@ -0,0 +1,139 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/ankit-arora/act/pkg/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// ReadInputsFile reads the inputs from a JSON object, the values that aren't strings are converted
+// to the strings they would be given as on the command line, e.g. true or 16
+func ReadInputsFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse the inputs file %s: %w", path, err)
+	}
+
+	inputs := make(map[string]string, len(values))
+	for name, value := range values {
+		switch v := value.(type) {
+		case nil:
+			inputs[name] = ""
+		case string:
+			inputs[name] = v
+		case bool:
+			inputs[name] = strconv.FormatBool(v)
+		case float64:
+			inputs[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			inputs[name] = string(encoded)
+		}
+	}
+	return inputs, nil
+}
+
+// MergeInputs returns the inputs of the inputs file overridden by the ones given on the command line
+func MergeInputs(path string, inputs map[string]string) (map[string]string, error) {
+	merged := make(map[string]string)
+	if path != "" {
+		log.Debugf("Loading inputs from %s", path)
+		fileInputs, err := ReadInputsFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range fileInputs {
+			merged[name] = value
+		}
+	}
+	for name, value := range inputs {
+		merged[name] = value
+	}
+	return merged, nil
+}
+
+// workflowDispatchInputs returns the inputs context of the workflow_dispatch event, the declared inputs
+// default to their default value and are converted to the declared type
+func workflowDispatchInputs(workflow *model.Workflow, inputs map[string]string) (map[string]interface{}, error) {
+	config := workflow.WorkflowDispatchConfig()
+	if config == nil {
+		return nil, nil
+	}
+
+	values := make(map[string]interface{})
+	names := make([]string, 0, len(config.Inputs))
+	for name := range config.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		input := config.Inputs[name]
+		value, ok := inputs[name]
+		if !ok {
+			if input.Required && input.Default == "" {
+				return nil, fmt.Errorf("input '%s' is required", name)
+			}
+			value = input.Default
+		}
+		coerced, err := coerceInput(name, value, input)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = coerced
+	}
+
+	for name, value := range inputs {
+		if _, ok := config.Inputs[name]; !ok {
+			log.Warnf("Input '%s' isn't declared by the workflow_dispatch event of %s", name, workflow.File)
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+// coerceInput converts the value of an input to its declared type
+func coerceInput(name string, value string, input model.WorkflowDispatchInput) (interface{}, error) {
+	switch input.Type {
+	case "boolean":
+		if value == "" {
+			return false, nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("input '%s' is a boolean, got '%s'", name, value)
+		}
+		return b, nil
+	case "number":
+		if value == "" {
+			return 0.0, nil
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("input '%s' is a number, got '%s'", name, value)
+		}
+		return f, nil
+	case "choice":
+		if value == "" {
+			return value, nil
+		}
+		for _, option := range input.Options {
+			if option == value {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("input '%s' must be one of %v, got '%s'", name, input.Options, value)
+	}
+	return value, nil
+}
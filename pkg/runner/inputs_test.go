@@ -0,0 +1,82 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ankit-arora/act/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeInputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inputs.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"debug": true, "retries": 3, "ratio": 0.5, "name": "file", "tags": ["a", "b"], "empty": null}`), 0600))
+
+	inputs, err := MergeInputs(path, map[string]string{"name": "cli"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"debug":   "true",
+		"retries": "3",
+		"ratio":   "0.5",
+		"name":    "cli",
+		"tags":    `["a","b"]`,
+		"empty":   "",
+	}, inputs)
+
+	inputs, err = MergeInputs("", map[string]string{"name": "cli"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "cli"}, inputs)
+
+	require.NoError(t, os.WriteFile(path, []byte(`["not", "an", "object"]`), 0600))
+	_, err = MergeInputs(path, nil)
+	assert.Error(t, err)
+}
+
+func TestWorkflowDispatchInputs(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on:
+  workflow_dispatch:
+    inputs:
+      debug:
+        type: boolean
+      retries:
+        type: number
+        default: "1"
+      environment:
+        type: choice
+        options: [staging, production]
+        default: staging
+      name:
+        required: true
+jobs: {}
+`))
+	require.NoError(t, err)
+
+	inputs, err := workflowDispatchInputs(workflow, map[string]string{"debug": "true", "name": "act", "extra": "value"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"debug":       true,
+		"retries":     1.0,
+		"environment": "staging",
+		"name":        "act",
+		"extra":       "value",
+	}, inputs)
+
+	_, err = workflowDispatchInputs(workflow, map[string]string{})
+	assert.EqualError(t, err, "input 'name' is required")
+
+	_, err = workflowDispatchInputs(workflow, map[string]string{"name": "act", "retries": "many"})
+	assert.EqualError(t, err, "input 'retries' is a number, got 'many'")
+
+	_, err = workflowDispatchInputs(workflow, map[string]string{"name": "act", "environment": "qa"})
+	assert.EqualError(t, err, "input 'environment' must be one of [staging production], got 'qa'")
+
+	workflow, err = model.ReadWorkflow(strings.NewReader("on: push\njobs: {}\n"))
+	require.NoError(t, err)
+	inputs, err = workflowDispatchInputs(workflow, map[string]string{"name": "act"})
+	assert.NoError(t, err)
+	assert.Nil(t, inputs)
+}
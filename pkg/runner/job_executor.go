@@ -3,6 +3,7 @@ package runner
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/ankit-arora/act/pkg/common"
 	"github.com/ankit-arora/act/pkg/model"
@@ -17,6 +18,10 @@ type jobInfo interface {
 	newStepExecutor(step *model.Step) common.Executor
 	interpolateOutputs() common.Executor
 	result(result string)
+	timeout() time.Duration
+	onTimeout() common.Executor
+	preJobHook() common.Executor
+	postJobHook() common.Executor
 }
 
 func newJobExecutor(info jobInfo) common.Executor {
@@ -31,12 +36,14 @@ func newJobExecutor(info jobInfo) common.Executor {
 
 	steps = append(steps, info.startContainer())
 
+	stepExecutors := make([]common.Executor, 0)
+	explicitIDs := stepIDs(info.steps())
 	for i, step := range info.steps() {
 		if step.ID == "" {
-			step.ID = fmt.Sprintf("%d", i)
+			step.ID = defaultStepID(i, explicitIDs)
 		}
 		stepExec := info.newStepExecutor(step)
-		steps = append(steps, func(ctx context.Context) error {
+		stepExecutors = append(stepExecutors, func(ctx context.Context) error {
 			err := stepExec(ctx)
 			if err != nil {
 				common.Logger(ctx).Errorf("%v", err)
@@ -48,6 +55,7 @@ func newJobExecutor(info jobInfo) common.Executor {
 			return nil
 		})
 	}
+	steps = append(steps, withJobHooks(info, withJobTimeout(info, common.NewPipelineExecutor(stepExecutors...))))
 
 	steps = append(steps, func(ctx context.Context) error {
 		err := info.stopContainer()(ctx)
@@ -67,3 +75,43 @@ func newJobExecutor(info jobInfo) common.Executor {
 
 	return common.NewPipelineExecutor(steps...).Finally(info.interpolateOutputs()).Finally(info.closeContainer())
 }
+
+// withJobHooks runs the steps between the pre and post job hooks, the steps are skipped if the pre job hook
+// fails and the post job hook runs in any case. A failing hook fails the job
+func withJobHooks(info jobInfo, steps common.Executor) common.Executor {
+	return func(ctx context.Context) error {
+		var err error
+		if hookErr := info.preJobHook()(ctx); hookErr != nil {
+			common.Logger(ctx).Errorf("%v", hookErr)
+			common.SetJobError(ctx, hookErr)
+		} else {
+			err = steps(ctx)
+		}
+		if hookErr := info.postJobHook()(ctx); hookErr != nil {
+			common.Logger(ctx).Errorf("%v", hookErr)
+			common.SetJobError(ctx, hookErr)
+		}
+		return err
+	}
+}
+
+// withJobTimeout runs the steps with the timeout of the job, once it is exceeded the remaining steps are
+// skipped, the job fails and its timeout action runs before the container is stopped
+func withJobTimeout(info jobInfo, steps common.Executor) common.Executor {
+	return func(ctx context.Context) error {
+		timeout := info.timeout()
+		if timeout <= 0 {
+			return steps(ctx)
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		err := steps(timeoutCtx)
+		if timeoutCtx.Err() != context.DeadlineExceeded || ctx.Err() != nil {
+			return err
+		}
+		err = fmt.Errorf("the job has exceeded the maximum execution time of %v", timeout)
+		common.Logger(ctx).Errorf("%v", err)
+		common.SetJobError(ctx, err)
+		return info.onTimeout()(ctx)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/ankit-arora/act/pkg/common"
 	"github.com/ankit-arora/act/pkg/model"
@@ -60,6 +61,30 @@ func (jpm *jobInfoMock) result(result string) {
 	jpm.Called(result)
 }
 
+func (jpm *jobInfoMock) timeout() time.Duration {
+	args := jpm.Called()
+
+	return args.Get(0).(time.Duration)
+}
+
+func (jpm *jobInfoMock) onTimeout() common.Executor {
+	args := jpm.Called()
+
+	return args.Get(0).(func(context.Context) error)
+}
+
+func (jpm *jobInfoMock) preJobHook() common.Executor {
+	args := jpm.Called()
+
+	return args.Get(0).(func(context.Context) error)
+}
+
+func (jpm *jobInfoMock) postJobHook() common.Executor {
+	args := jpm.Called()
+
+	return args.Get(0).(func(context.Context) error)
+}
+
 func TestNewJobExecutor(t *testing.T) {
 	table := []struct {
 		name          string
@@ -67,12 +92,16 @@ func TestNewJobExecutor(t *testing.T) {
 		executedSteps []string
 		result        string
 		hasError      bool
+		timeout       time.Duration
+		preHookError  bool
 	}{
 		{
 			name:  "zeroSteps",
 			steps: []*model.Step{},
 			executedSteps: []string{
 				"startContainer",
+				"preJobHook",
+				"postJobHook",
 				"stopContainer",
 				"interpolateOutputs",
 				"closeContainer",
@@ -87,7 +116,9 @@ func TestNewJobExecutor(t *testing.T) {
 			}},
 			executedSteps: []string{
 				"startContainer",
+				"preJobHook",
 				"step1",
+				"postJobHook",
 				"stopContainer",
 				"interpolateOutputs",
 				"closeContainer",
@@ -102,7 +133,9 @@ func TestNewJobExecutor(t *testing.T) {
 			}},
 			executedSteps: []string{
 				"startContainer",
+				"preJobHook",
 				"step1",
+				"postJobHook",
 				"stopContainer",
 				"interpolateOutputs",
 				"closeContainer",
@@ -119,8 +152,10 @@ func TestNewJobExecutor(t *testing.T) {
 			}},
 			executedSteps: []string{
 				"startContainer",
+				"preJobHook",
 				"step1",
 				"step2",
+				"postJobHook",
 				"stopContainer",
 				"interpolateOutputs",
 				"closeContainer",
@@ -128,6 +163,42 @@ func TestNewJobExecutor(t *testing.T) {
 			result:   "success",
 			hasError: false,
 		},
+		{
+			name: "timeout",
+			steps: []*model.Step{{
+				ID: "1",
+			}, {
+				ID: "2",
+			}},
+			executedSteps: []string{
+				"startContainer",
+				"preJobHook",
+				"step1",
+				"onTimeout",
+				"postJobHook",
+				"stopContainer",
+				"interpolateOutputs",
+				"closeContainer",
+			},
+			result:  "failure",
+			timeout: 10 * time.Millisecond,
+		},
+		{
+			name: "preJobHookFailure",
+			steps: []*model.Step{{
+				ID: "1",
+			}},
+			executedSteps: []string{
+				"startContainer",
+				"preJobHook",
+				"postJobHook",
+				"stopContainer",
+				"interpolateOutputs",
+				"closeContainer",
+			},
+			result:       "failure",
+			preHookError: true,
+		},
 	}
 
 	for _, tt := range table {
@@ -147,6 +218,10 @@ func TestNewJobExecutor(t *testing.T) {
 				func(stepMock *model.Step) {
 					jpm.On("newStepExecutor", stepMock).Return(func(ctx context.Context) error {
 						executorOrder = append(executorOrder, "step"+stepMock.ID)
+						if tt.timeout > 0 {
+							<-ctx.Done()
+							return nil
+						}
 						if tt.hasError {
 							return fmt.Errorf("error")
 						}
@@ -162,6 +237,26 @@ func TestNewJobExecutor(t *testing.T) {
 
 			jpm.On("matrix").Return(map[string]interface{}{})
 
+			jpm.On("timeout").Return(tt.timeout)
+
+			jpm.On("onTimeout").Return(func(ctx context.Context) error {
+				executorOrder = append(executorOrder, "onTimeout")
+				return nil
+			})
+
+			jpm.On("preJobHook").Return(func(ctx context.Context) error {
+				executorOrder = append(executorOrder, "preJobHook")
+				if tt.preHookError {
+					return fmt.Errorf("pre-job hook failed")
+				}
+				return nil
+			})
+
+			jpm.On("postJobHook").Return(func(ctx context.Context) error {
+				executorOrder = append(executorOrder, "postJobHook")
+				return nil
+			})
+
 			jpm.On("stopContainer").Return(func(ctx context.Context) error {
 				executorOrder = append(executorOrder, "stopContainer")
 				return nil
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
 
 	"github.com/ankit-arora/act/pkg/common"
 
@@ -38,6 +41,8 @@ func init() {
 }
 
 // WithJobLogger attaches a new logger to context that is aware of steps
+// The output of the steps is only prefixed with the job name if the terminal isn't colored,
+// see WithJobNamePrefix
 func WithJobLogger(ctx context.Context, jobName string, secrets map[string]string, insecureSecrets bool) context.Context {
 	mux.Lock()
 	defer mux.Unlock()
@@ -55,13 +60,19 @@ func WithJobLogger(ctx context.Context, jobName string, secrets map[string]strin
 		}
 	}
 	logger.SetFormatter(formatter)
-	logger.SetOutput(os.Stdout)
+	logger.SetOutput(common.Stdout)
 	logger.SetLevel(logrus.GetLevel())
 	rtn := logger.WithFields(logrus.Fields{"job": jobName, "dryrun": common.Dryrun(ctx)})
 
 	return common.WithLogger(ctx, rtn)
 }
 
+// WithJobNamePrefix prefixes the output of the steps with the job name in colored terminals too,
+// so the output of jobs running in parallel can be told apart
+func WithJobNamePrefix(ctx context.Context) context.Context {
+	return common.WithLogger(ctx, common.Logger(ctx).WithField("prefix_job_name", true))
+}
+
 type stepLogFormatter struct {
 	color           int
 	secrets         map[string]string
@@ -76,6 +87,8 @@ func (f *stepLogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 		for _, v := range f.secrets {
 			if v != "" {
 				entry.Message = strings.ReplaceAll(entry.Message, v, "***")
+				entry.Message = maskTruncatedSecret(entry.Message, v)
+				entry.Message = maskSecretLines(entry.Message, v)
 			}
 		}
 	}
@@ -95,7 +108,17 @@ func (f *stepLogFormatter) printColored(b *bytes.Buffer, entry *logrus.Entry) {
 	jobName := entry.Data["job"]
 
 	if entry.Data["raw_output"] == true {
-		fmt.Fprintf(b, "\x1b[%dm|\x1b[0m %s", f.color, entry.Message)
+		// a batch of output lines is logged as one entry
+		for i, line := range strings.Split(entry.Message, "\n") {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			if entry.Data["prefix_job_name"] == true {
+				fmt.Fprintf(b, "\x1b[%dm[%s]   |\x1b[0m %s", f.color, jobName, line)
+			} else {
+				fmt.Fprintf(b, "\x1b[%dm|\x1b[0m %s", f.color, line)
+			}
+		}
 	} else if entry.Data["dryrun"] == true {
 		fmt.Fprintf(b, "\x1b[1m\x1b[%dm\x1b[7m*DRYRUN*\x1b[0m \x1b[%dm[%s] \x1b[0m%s", gray, f.color, jobName, entry.Message)
 	} else {
@@ -108,7 +131,12 @@ func (f *stepLogFormatter) print(b *bytes.Buffer, entry *logrus.Entry) {
 	jobName := entry.Data["job"]
 
 	if entry.Data["raw_output"] == true {
-		fmt.Fprintf(b, "[%s]   | %s", jobName, entry.Message)
+		for i, line := range strings.Split(entry.Message, "\n") {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			fmt.Fprintf(b, "[%s]   | %s", jobName, line)
+		}
 	} else if entry.Data["dryrun"] == true {
 		fmt.Fprintf(b, "*DRYRUN* [%s] %s", jobName, entry.Message)
 	} else {
@@ -132,9 +160,107 @@ func (f *stepLogFormatter) isColored(entry *logrus.Entry) bool {
 
 func checkIfTerminal(w io.Writer) bool {
 	switch v := w.(type) {
-	case *os.File:
+	case interface{ Fd() uintptr }:
 		return term.IsTerminal(int(v.Fd()))
 	default:
 		return false
 	}
 }
+
+var truncatedLinePattern = regexp.MustCompile(`\.\.\.\[truncated \d+ bytes\]$`)
+
+// minSecretLineLength is the length a line of a multi-line secret needs to be masked, shorter lines like
+// the braces of a JSON secret appear everywhere and reveal nothing
+const minSecretLineLength = 4
+
+// maskSecretLines masks every line of a multi-line secret like a certificate, the output is logged line by line
+// so the whole secret never appears in one message. Short lines and lines without any letter or digit aren't masked
+func maskSecretLines(message string, secret string) string {
+	if !strings.Contains(secret, "\n") {
+		return message
+	}
+	for _, line := range strings.Split(secret, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < minSecretLineLength || strings.IndexFunc(line, isAlphanumeric) < 0 {
+			continue
+		}
+		message = strings.ReplaceAll(message, line, "***")
+	}
+	return message
+}
+
+func isAlphanumeric(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// maskTruncatedSecret masks the start of a secret that was cut off by the truncation of a long line
+func maskTruncatedSecret(message string, secret string) string {
+	lines := strings.Split(message, "\n")
+	for l, line := range lines {
+		loc := truncatedLinePattern.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		content := line[:loc[0]]
+		for i := len(secret) - 1; i > 0; i-- {
+			if strings.HasSuffix(content, secret[:i]) {
+				lines[l] = content[:len(content)-i] + "***" + line[loc[0]:]
+				break
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// outputBatch coalesces the lines of output written within an interval into a single log entry,
+// so a step producing output quickly isn't slowed down by the logging of every line
+type outputBatch struct {
+	mu       sync.Mutex
+	interval time.Duration
+	logger   logrus.FieldLogger
+	info     bool
+	lines    []string
+	timer    *time.Timer
+}
+
+// add queues a line, it is logged with logger at the info level if info is set, else at the debug level
+func (b *outputBatch) add(logger logrus.FieldLogger, info bool, line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.lines) > 0 && (b.logger != logger || b.info != info) {
+		b.flushLocked()
+	}
+	b.logger = logger
+	b.info = info
+	b.lines = append(b.lines, strings.TrimSuffix(line, "\n"))
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, b.flush)
+	}
+}
+
+// flush logs the queued lines, a nil batch has nothing to flush
+func (b *outputBatch) flush() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.flushLocked()
+}
+
+func (b *outputBatch) flushLocked() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.lines) == 0 {
+		return
+	}
+	message := strings.Join(b.lines, "\n")
+	b.lines = nil
+	if b.info {
+		b.logger.Infof("%s", message)
+	} else {
+		b.logger.Debugf("%s", message)
+	}
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskTruncatedSecret(t *testing.T) {
+	assert.Equal(t, "token ***...[truncated 3 bytes]\n", maskTruncatedSecret("token abc...[truncated 3 bytes]\n", "abcdef"))
+	assert.Equal(t, "token xyz...[truncated 3 bytes]\n", maskTruncatedSecret("token xyz...[truncated 3 bytes]\n", "abcdef"))
+	assert.Equal(t, "token abc", maskTruncatedSecret("token abc", "abcdef"))
+	assert.Equal(t, "a***...[truncated 3 bytes]\nb", maskTruncatedSecret("aab...[truncated 3 bytes]\nb", "abcdef"))
+}
+
+func TestStepLogFormatterMasksMultiLineSecrets(t *testing.T) {
+	formatter := &stepLogFormatter{secrets: map[string]string{
+		"GITHUB_TOKEN": "file-token",
+		"CERT":         "-----BEGIN-----\nabc123\n-----END-----",
+		"JSON":         "{\n  \"key\": \"value\",\n  ----\n}",
+	}}
+	entry := logrus.NewEntry(logrus.New())
+	for message, masked := range map[string]string{
+		"token file-token":       "token ***",
+		"line abc123":            "line ***",
+		"-----END-----":          "] ***",
+		`config "key": "value",`: "config ***",
+		// lines too short or made of punctuation only aren't masked
+		"if [ -z $x ]; then {": "then {",
+		"---- }":               "---- }",
+	} {
+		entry.Message = message
+		out, err := formatter.Format(entry)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasSuffix(string(out), masked+"\n"), string(out))
+	}
+}
+
+func TestOutputBatch(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	entry := logger.WithField("raw_output", true)
+	batch := &outputBatch{interval: time.Hour}
+
+	batch.add(entry, true, "first\n")
+	batch.add(entry, true, "second\n")
+	assert.Empty(t, hook.AllEntries())
+
+	batch.add(entry, false, "debug\n")
+	assert.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, "first\nsecond", hook.LastEntry().Message)
+	assert.Equal(t, logrus.InfoLevel, hook.LastEntry().Level)
+
+	batch.flush()
+	assert.Len(t, hook.AllEntries(), 2)
+	assert.Equal(t, "debug", hook.LastEntry().Message)
+	assert.Equal(t, logrus.DebugLevel, hook.LastEntry().Level)
+
+	batch = &outputBatch{interval: time.Millisecond}
+	batch.add(entry, true, "timed\n")
+	assert.Eventually(t, func() bool { return len(hook.AllEntries()) == 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, "timed", hook.LastEntry().Message)
+
+	var nilBatch *outputBatch
+	nilBatch.flush()
+}
+
+func TestStepLogFormatterPrefixJobName(t *testing.T) {
+	t.Setenv("CLICOLOR_FORCE", "1")
+	formatter := &stepLogFormatter{color: blue}
+	logger := logrus.New()
+
+	entry := logrus.NewEntry(logger).WithFields(logrus.Fields{"job": "ci/build-1", "raw_output": true})
+	entry.Message = "first\nsecond"
+	out, err := formatter.Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "\x1b[34m|\x1b[0m first\n\x1b[34m|\x1b[0m second\n", string(out))
+
+	entry = entry.WithField("prefix_job_name", true)
+	entry.Message = "first\nsecond"
+	out, err = formatter.Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "\x1b[34m[ci/build-1]   |\x1b[0m first\n\x1b[34m[ci/build-1]   |\x1b[0m second\n", string(out))
+}
@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/ankit-arora/act/pkg/model"
+)
+
+// PlannedJob is a job of a plan with the steps it would run, for listing it without running anything
+type PlannedJob struct {
+	Stage    int
+	JobID    string
+	Name     string
+	Workflow string
+	Steps    []PlannedStep
+}
+
+// PlannedStep is a step of a PlannedJob, Run is the first line of the script
+type PlannedStep struct {
+	ID      string
+	Name    string
+	Uses    string
+	Run     string
+	If      string
+	Enabled string
+}
+
+// Values of PlannedStep.Enabled
+const (
+	StepEnabled        = "yes"
+	StepDisabled       = "no"
+	StepEnabledRuntime = "at runtime"
+)
+
+// runtimeExpressionRegex matches the expressions that depend on the outcome of the steps or jobs run before
+var runtimeExpressionRegex = regexp.MustCompile(`(?i)\b(steps\.|needs\.|job\.status|success\(|failure\(|always\(|cancelled\(|hashfiles\()`)
+
+// PlanSteps returns the jobs of the plan with their steps in order, the if of a step is evaluated with the
+// contexts known before the job runs, an if depending on the outcome of the run is only evaluated at runtime
+func (runner *runnerImpl) PlanSteps(plan *model.Plan) []PlannedJob {
+	jobs := make([]PlannedJob, 0)
+	for s, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			job := run.Job()
+			if job.Strategy != nil {
+				strategyRc := runner.newRunContext(run, nil)
+				if err := strategyRc.NewExpressionEvaluator().EvaluateYamlNode(&job.Strategy.RawMatrix); err != nil {
+					log.Errorf("Error while evaluating matrix: %v", err)
+				}
+			}
+			matrixes := job.GetMatrixes()
+			for i, matrix := range matrixes {
+				rc := runner.newRunContext(run, matrix)
+				rc.jobIndex = i
+				rc.jobTotal = len(matrixes)
+				if len(matrixes) > 1 {
+					rc.Name = fmt.Sprintf("%s-%d", rc.Name, i+1)
+				}
+				jobs = append(jobs, PlannedJob{
+					Stage:    s,
+					JobID:    run.JobID,
+					Name:     rc.Name,
+					Workflow: run.Workflow.File,
+					Steps:    rc.plannedSteps(),
+				})
+			}
+		}
+	}
+	return jobs
+}
+
+func (rc *RunContext) plannedSteps() []PlannedStep {
+	steps := make([]PlannedStep, 0)
+	for i, step := range rc.steps() {
+		id := step.ID
+		if id == "" {
+			id = fmt.Sprintf("%d", i)
+		}
+		run := strings.TrimSpace(step.Run)
+		if n := strings.Index(run, "\n"); n >= 0 {
+			run = run[:n] + " ..."
+		}
+		steps = append(steps, PlannedStep{
+			ID:      id,
+			Name:    step.Name,
+			Uses:    step.Uses,
+			Run:     run,
+			If:      step.If.Value,
+			Enabled: rc.staticStepEnabled(step),
+		})
+	}
+	return steps
+}
+
+// staticStepEnabled evaluates the if of the step before the job runs
+func (rc *RunContext) staticStepEnabled(step *model.Step) string {
+	if step.If.Value == "" {
+		return StepEnabled
+	}
+	if runtimeExpressionRegex.MatchString(step.If.Value) {
+		return StepEnabledRuntime
+	}
+	sc := &StepContext{RunContext: rc, Step: step}
+	enabled, err := EvalBool(sc.NewExpressionEvaluator(), step.If.Value)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err)
+	}
+	if enabled {
+		return StepEnabled
+	}
+	return StepDisabled
+}
@@ -1,20 +1,30 @@
 package runner
 
 import (
+	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/shlex"
 	"github.com/google/uuid"
 	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
 
 	"github.com/mitchellh/go-homedir"
 	log "github.com/sirupsen/logrus"
@@ -23,6 +33,7 @@ import (
 
 	"github.com/ankit-arora/act/pkg/common"
 	"github.com/ankit-arora/act/pkg/container"
+	"github.com/ankit-arora/act/pkg/exprparser"
 	"github.com/ankit-arora/act/pkg/model"
 )
 
@@ -51,6 +62,14 @@ type RunContext struct {
 	Inputs            map[string]interface{}
 	Parent            *RunContext
 	ContextData       map[string]interface{}
+	containerPoolKey  string
+	containerPoolName string
+	stateMu           *sync.Mutex
+	outputTail        *outputTail
+	outputBatch       *outputBatch
+	runNumber         string
+	jobIndex          int
+	jobTotal          int
 }
 
 func (rc *RunContext) Clone() *RunContext {
@@ -68,6 +87,108 @@ func (rc *RunContext) Clone() *RunContext {
 	return &clone
 }
 
+// stateMuInit guards the lazy creation of RunContext.stateMu, RunContext.outputTail and RunContext.outputBatch
+var stateMuInit sync.Mutex
+
+// stateLock returns the mutex guarding the step results, outputs and env of the run context, which
+// are written by the command handler while the step executor is running. Clones share the mutex.
+func (rc *RunContext) stateLock() *sync.Mutex {
+	stateMuInit.Lock()
+	defer stateMuInit.Unlock()
+	if rc.stateMu == nil {
+		rc.stateMu = &sync.Mutex{}
+	}
+	return rc.stateMu
+}
+
+// newOutputWriter returns the writer for the output of the steps, workflow commands are handled
+// and the other lines are logged, unless Quiet is set, and kept in the tail of the job output
+func (rc *RunContext) newOutputWriter(ctx context.Context) io.Writer {
+	rawLogger := common.Logger(ctx).WithField("raw_output", true)
+	tail := rc.jobOutputTail()
+	batch := rc.jobOutputBatch()
+	return common.NewLimitedLineWriter(rc.Config.MaxLogLineLength, func(s string) bool {
+		// the lines batched so far are logged before the workflow command
+		if batch != nil && isWorkflowCommand(s) {
+			batch.flush()
+		}
+		return true
+	}, rc.commandHandler(ctx), func(s string) bool {
+		tail.add(s)
+		if rc.Config.Quiet {
+			return true
+		}
+		if batch != nil {
+			batch.add(rawLogger, rc.Config.LogOutput, s)
+		} else if rc.Config.LogOutput {
+			rawLogger.Infof("%s", s)
+		} else {
+			rawLogger.Debugf("%s", s)
+		}
+		return true
+	})
+}
+
+// jobOutputBatch returns the batch coalescing the output of the job if LogBufferInterval is set, else nil. It is
+// created with the run context of the job and shared with the clones of its composite actions
+func (rc *RunContext) jobOutputBatch() *outputBatch {
+	if rc.Config == nil || rc.Config.LogBufferInterval <= 0 {
+		return nil
+	}
+	stateMuInit.Lock()
+	defer stateMuInit.Unlock()
+	if rc.outputBatch == nil {
+		rc.outputBatch = &outputBatch{interval: rc.Config.LogBufferInterval}
+	}
+	return rc.outputBatch
+}
+
+// jobOutputTail returns the tail of the output of the job, it is created with the run context of the job
+// and shared with the clones of its composite actions
+func (rc *RunContext) jobOutputTail() *outputTail {
+	stateMuInit.Lock()
+	defer stateMuInit.Unlock()
+	if rc.outputTail == nil {
+		rc.outputTail = &outputTail{size: outputTailSize}
+	}
+	return rc.outputTail
+}
+
+// outputTailSize is the number of lines of the job output dumped when the job times out
+const outputTailSize = 50
+
+// outputTail keeps the last lines written by the steps
+type outputTail struct {
+	mu    sync.Mutex
+	size  int
+	lines []string
+}
+
+func (t *outputTail) add(line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines = append(t.lines, line)
+	if len(t.lines) > t.size {
+		t.lines = t.lines[len(t.lines)-t.size:]
+	}
+}
+
+func (t *outputTail) get() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string{}, t.lines...)
+}
+
+// updateStepResult applies update to the result of the current step while holding the state lock
+func (rc *RunContext) updateStepResult(update func(result *model.StepResult)) {
+	mu := rc.stateLock()
+	mu.Lock()
+	defer mu.Unlock()
+	if result, ok := rc.StepResults[rc.CurrentStep]; ok {
+		update(result)
+	}
+}
+
 func (rc *RunContext) SetActPath(actPath string) {
 	rc.actPath = actPath
 }
@@ -88,34 +209,131 @@ func (rc *RunContext) String() string {
 	return fmt.Sprintf("%s/%s", rc.Run.Workflow.Name, rc.Name)
 }
 
-// GetEnv returns the env for the context
+// GetEnv returns the env for the context, ACT is set to true unless the config or workflow sets it
 func (rc *RunContext) GetEnv() map[string]string {
 	if rc.Env == nil {
-		rc.Env = mergeMaps(rc.Config.Env, rc.Run.Workflow.Env, rc.Run.Job().Environment())
+		workflowEnv := mergeMaps(rc.Run.Workflow.Env, rc.Run.Job().Environment())
+		rc.Env = mergeMaps(rc.Config.Env, workflowEnv)
+		rc.interpolateWorkflowEnv(workflowEnv)
+	}
+	if _, ok := rc.Env["ACT"]; !ok {
+		rc.Env["ACT"] = "true"
 	}
-	rc.Env["ACT"] = "true"
 	return rc.Env
 }
 
+// workflow and job level env may reference contexts like github or secrets,
+// so the values are interpolated before they are exported to the steps
+func (rc *RunContext) interpolateWorkflowEnv(workflowEnv map[string]string) {
+	var ee ExpressionEvaluator
+	for k, v := range workflowEnv {
+		if !strings.Contains(v, "${{") {
+			continue
+		}
+		if ee == nil {
+			ee = rc.NewExpressionEvaluator()
+		}
+		rc.Env[k] = ee.Interpolate(v)
+	}
+}
+
+// containerNamePattern matches the names docker accepts for containers
+var containerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]+$`)
+
 func (rc *RunContext) jobContainerName() string {
+	if rc.containerPoolName != "" {
+		return rc.containerPoolName
+	}
+	if rc.Config.JobContainerName != "" {
+		if name, err := rc.templateJobContainerName(); err == nil {
+			return name
+		}
+	}
 	return createContainerName("act", rc.String())
 }
 
+// templateJobContainerName interpolates the JobContainerName template, it fails if the result isn't a name docker accepts.
+// The jobs of a matrix get the index of their combination appended like their job name, unless the template tells
+// them apart by all values of the matrix or the job-index, else they would remove each other's container
+func (rc *RunContext) templateJobContainerName() (string, error) {
+	name := strings.TrimSpace(rc.NewExpressionEvaluator().Interpolate(rc.Config.JobContainerName))
+	if !containerNamePattern.MatchString(name) {
+		return "", fmt.Errorf("job container name '%s' of template '%s' is invalid, it must start with a letter or digit followed by letters, digits, '_', '.' or '-'", name, rc.Config.JobContainerName)
+	}
+	if rc.jobTotal > 1 && !rc.templateIdentifiesMatrixJob() {
+		name = fmt.Sprintf("%s-%d", name, rc.jobIndex+1)
+	}
+	return name, nil
+}
+
+// templateIdentifiesMatrixJob returns true if the JobContainerName template references the job-index of the strategy
+// or every value of the matrix of the job
+func (rc *RunContext) templateIdentifiesMatrixJob() bool {
+	referenced := make(map[string]bool)
+	for _, match := range embeddedExpressionPattern.FindAllStringSubmatch(rc.Config.JobContainerName, -1) {
+		properties, _ := exprparser.ContextProperties(match[1], "strategy")
+		for _, property := range properties {
+			if strings.EqualFold(property, "job-index") {
+				return true
+			}
+		}
+		properties, _ = exprparser.ContextProperties(match[1], "matrix")
+		for _, property := range properties {
+			referenced[strings.ToLower(property)] = true
+		}
+	}
+	for key := range rc.Matrix {
+		if !referenced[strings.ToLower(key)] {
+			return false
+		}
+	}
+	return true
+}
+
+// usesDockerBackend returns false if the containers are created by a backend registered with container.RegisterContainerBackend
+func (rc *RunContext) usesDockerBackend() bool {
+	return rc.Config.ContainerBackend == "" || rc.Config.ContainerBackend == container.DockerBackend
+}
+
+// remoteDaemonWarning makes sure the socket of a remote daemon is only reported once per process
+var remoteDaemonWarning sync.Once
+
+// dockerHost returns the address of the docker daemon the containers run on
+func (rc *RunContext) dockerHost() string {
+	if rc.Config.DockerHost != "" {
+		return rc.Config.DockerHost
+	}
+	return os.Getenv("DOCKER_HOST")
+}
+
 // Returns the binds and mounts for the container, resolving paths as appopriate
 func (rc *RunContext) GetBindsAndMounts() ([]string, map[string]string) {
 	name := rc.jobContainerName()
 
-	if rc.Config.ContainerDaemonSocket == "" {
-		rc.Config.ContainerDaemonSocket = "/var/run/docker.sock"
+	if rc.Config.ContainerDaemonSocket == "" || rc.Config.ContainerDaemonSocket == container.DefaultDockerSocket {
+		rc.Config.ContainerDaemonSocket = container.DetectDockerSocket(rc.dockerHost())
 	}
 
-	binds := []string{
-		fmt.Sprintf("%s:%s", rc.Config.ContainerDaemonSocket, "/var/run/docker.sock"),
+	binds := []string{}
+	if rc.Config.ContainerDaemonSocket == "-" {
+		log.Debugf("Not mounting the docker daemon socket into the containers")
+	} else if dockerHost := rc.dockerHost(); container.IsRemoteDockerHost(dockerHost) {
+		remoteDaemonWarning.Do(func() {
+			log.Warnf("The docker daemon at %s is remote, its socket isn't mounted into the containers since docker-in-docker through a local socket path doesn't work with a remote daemon", dockerHost)
+		})
+	} else {
+		binds = append(binds, container.DockerSocketBind(rc.Config.ContainerDaemonSocket, rc.containerArchitecture()))
 	}
 
 	mounts := map[string]string{
-		"act-toolcache": "/toolcache",
-		name + "-env":   rc.GetActPath(),
+		name + "-env": rc.GetActPath(),
+	}
+	if rc.Config.IsolateRunnerDirs {
+		mounts[name+"-tmp"] = rc.runnerTemp()
+		mounts[name+"-toolcache"] = rc.runnerToolCache()
+	} else {
+		// the shared volume is mounted where the setup-* actions look for and install their tools, so they persist across runs
+		mounts["act-toolcache"] = rc.runnerToolCache()
 	}
 
 	if rc.Config.BindWorkdir {
@@ -126,27 +344,64 @@ func (rc *RunContext) GetBindsAndMounts() ([]string, map[string]string) {
 		if selinux.GetEnabled() {
 			bindModifiers = ":z"
 		}
-		binds = append(binds, fmt.Sprintf("%s:%s%s", rc.Config.Workdir, rc.ContainerWorkdir(), bindModifiers))
+		binds = append(binds, fmt.Sprintf("%s:%s%s", rc.workspaceDir(), rc.ContainerWorkdir(), bindModifiers))
 	} else {
-		mounts[name] = rc.ContainerWorkdir()
+		mounts[rc.workspaceVolumeName()] = rc.ContainerWorkdir()
 	}
 
 	return binds, mounts
 }
 
+// Paths of RUNNER_TEMP and RUNNER_TOOL_CACHE in the containers unless they are configured
+const (
+	defaultRunnerTemp      = "/tmp"
+	defaultRunnerToolCache = "/opt/hostedtoolcache"
+)
+
+// runnerTemp returns the path of RUNNER_TEMP in the containers
+func (rc *RunContext) runnerTemp() string {
+	if rc.Config.RunnerTemp != "" {
+		return rc.Config.RunnerTemp
+	}
+	return defaultRunnerTemp
+}
+
+// runnerToolCache returns the path of RUNNER_TOOL_CACHE in the containers
+func (rc *RunContext) runnerToolCache() string {
+	if rc.Config.RunnerToolCache != "" {
+		return rc.Config.RunnerToolCache
+	}
+	return defaultRunnerToolCache
+}
+
+// removeRunnerDirVolumes removes the volumes mounted at RUNNER_TEMP and RUNNER_TOOL_CACHE with IsolateRunnerDirs
+func (rc *RunContext) removeRunnerDirVolumes() common.Executor {
+	name := rc.jobContainerName()
+	return container.NewDockerVolumeRemoveExecutor(name+"-tmp", false).
+		Finally(container.NewDockerVolumeRemoveExecutor(name+"-toolcache", false))
+}
+
+// workspaceVolumeName returns the name of the volume holding the workspace if the workdir isn't bound,
+// a configured WorkspaceVolumeName is shared by all runs and never removed
+func (rc *RunContext) workspaceVolumeName() string {
+	if rc.Config.WorkspaceVolumeName != "" {
+		return rc.Config.WorkspaceVolumeName
+	}
+	return rc.jobContainerName()
+}
+
 func (rc *RunContext) startJobContainer() common.Executor {
-	image := rc.platformImage()
+	image, err := rc.platformImage()
+	if err != nil {
+		return common.NewErrorExecutor(err)
+	}
+	dockerfile := rc.platformDockerfile(rc.mappedPlatformImage())
+	if image == "-self-hosted" && rc.Config.RemoteHost != "" {
+		return rc.startRemoteExecutor()
+	}
 	if image == "-self-hosted" {
 		return func(ctx context.Context) error {
-			rawLogger := common.Logger(ctx).WithField("raw_output", true)
-			logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-				if rc.Config.LogOutput {
-					rawLogger.Infof("%s", s)
-				} else {
-					rawLogger.Debugf("%s", s)
-				}
-				return true
-			})
+			logWriter := rc.newOutputWriter(ctx)
 			cacheDir := rc.ActionCacheDir()
 			miscpath := filepath.Join(cacheDir, uuid.New().String())
 			actPath := filepath.Join(miscpath, "act")
@@ -185,7 +440,7 @@ func (rc *RunContext) startJobContainer() common.Executor {
 			}
 
 			return common.NewPipelineExecutor(
-				rc.JobContainer.CopyDir(copyToPath, rc.Config.Workdir+string(filepath.Separator)+".", rc.Config.UseGitIgnore).IfBool(copyWorkspace),
+				rc.JobContainer.CopyDir(copyToPath, rc.Config.Workdir+string(filepath.Separator)+".", rc.Config.UseGitIgnore, rc.copyExcludes()...).IfBool(copyWorkspace),
 				rc.JobContainer.Copy(rc.GetActPath()+"/", &container.FileEntry{
 					Name: "workflow/event.json",
 					Mode: 0644,
@@ -205,51 +460,71 @@ func (rc *RunContext) startJobContainer() common.Executor {
 	hostname := rc.hostname()
 
 	return func(ctx context.Context) error {
-		rawLogger := common.Logger(ctx).WithField("raw_output", true)
-		logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-			if rc.Config.LogOutput {
-				rawLogger.Infof("%s", s)
-			} else {
-				rawLogger.Debugf("%s", s)
+		logWriter := rc.newOutputWriter(ctx)
+
+		platform := rc.containerArchitecture()
+		if err := container.ValidatePlatform(platform); err != nil {
+			return err
+		}
+		if rc.Config.JobContainerName != "" {
+			if _, err := rc.templateJobContainerName(); err != nil {
+				return err
 			}
-			return true
-		})
+		}
+
+		if !common.Dryrun(ctx) && rc.usesDockerBackend() {
+			if err := container.PingDockerDaemon(ctx); err != nil {
+				return err
+			}
+		}
 
 		username, password, err := rc.handleCredentials()
 		if err != nil {
 			return fmt.Errorf("failed to handle credentials: %s", err)
 		}
 
+		rc.acquirePooledContainer(ctx, image, username, password)
+
 		common.Logger(ctx).Infof("\U0001f680  Start image=%s", image)
 		name := rc.jobContainerName()
+		rc.warnIfEmulated(ctx, platform)
 
 		envList := make([]string, 0)
 
-		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TOOL_CACHE", "/opt/hostedtoolcache"))
+		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TOOL_CACHE", rc.runnerToolCache()))
 		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_OS", "Linux"))
-		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TEMP", "/tmp"))
+		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TEMP", rc.runnerTemp()))
 
 		binds, mounts := rc.GetBindsAndMounts()
 
-		rc.JobContainer = container.NewContainer(&container.NewContainerInput{
-			Cmd:         nil,
-			Entrypoint:  []string{"/usr/bin/tail", "-f", "/dev/null"},
-			WorkingDir:  rc.ContainerWorkdir(),
-			Image:       image,
-			Username:    username,
-			Password:    password,
-			Name:        name,
-			Env:         envList,
-			Mounts:      mounts,
-			NetworkMode: "host",
-			Binds:       binds,
-			Stdout:      logWriter,
-			Stderr:      logWriter,
-			Privileged:  rc.Config.Privileged,
-			UsernsMode:  rc.Config.UsernsMode,
-			Platform:    rc.Config.ContainerArchitecture,
-			Hostname:    hostname,
+		rc.JobContainer, err = container.NewContainerWithBackend(rc.Config.ContainerBackend, &container.NewContainerInput{
+			Cmd:            nil,
+			Entrypoint:     []string{"/usr/bin/tail", "-f", "/dev/null"},
+			WorkingDir:     rc.ContainerWorkdir(),
+			Image:          image,
+			Username:       username,
+			Password:       password,
+			Name:           name,
+			Env:            envList,
+			Mounts:         mounts,
+			NetworkMode:    "host",
+			Binds:          binds,
+			Stdout:         logWriter,
+			Stderr:         logWriter,
+			Privileged:     rc.Config.Privileged,
+			UsernsMode:     rc.Config.UsernsMode,
+			User:           rc.Config.ContainerUser,
+			Platform:       platform,
+			StrictPlatform: rc.Config.StrictPlatform,
+			Hostname:       hostname,
+			Init:           rc.Config.ContainerInit,
+			StopTimeout:    rc.Config.ContainerStopTimeout,
+			Idle:           true,
+			Labels:         map[string]string{container.ContainerLabel: "true"},
 		})
+		if err != nil {
+			return err
+		}
 
 		if rc.JobContainer == nil {
 			return errors.New("failed to create Container")
@@ -263,14 +538,19 @@ func (rc *RunContext) startJobContainer() common.Executor {
 		}
 
 		return common.NewPipelineExecutor(
-			rc.JobContainer.Pull(rc.Config.ForcePull),
+			rc.buildPlatformImage(image, dockerfile, platform).IfBool(dockerfile != ""),
+			rc.JobContainer.Pull(rc.forcePull(image) && dockerfile == ""),
 			rc.stopJobContainer(),
-			rc.JobContainer.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
+			rc.JobContainer.Create(rc.containerCapAdd(), rc.Config.ContainerCapDrop),
 			rc.JobContainer.Start(false),
 			rc.JobContainer.UpdateFromImageEnv(&rc.Env),
 			rc.JobContainer.UpdateFromEnv("/etc/environment", &rc.Env),
-			rc.JobContainer.Exec([]string{"mkdir", "-m", "0777", "-p", rc.GetActPath()}, "", rc.Env, "root", ""),
-			rc.JobContainer.CopyDir(copyToPath, rc.Config.Workdir+string(filepath.Separator)+".", rc.Config.UseGitIgnore).IfBool(copyWorkspace),
+			rc.JobContainer.MkdirAll(rc.GetActPath(), 0777),
+			rc.resetPooledContainer().IfBool(rc.containerPoolName != ""),
+			rc.execAsRoot("mkdir", "-p", copyToPath).IfBool(!rc.Config.BindWorkdir && rc.Config.ContainerUser != ""),
+			rc.JobContainer.CopyDir(copyToPath, rc.Config.Workdir+string(filepath.Separator)+".", rc.Config.UseGitIgnore, rc.copyExcludes()...).IfBool(copyWorkspace),
+			// a reused container still has the command files of the previous run, they are truncated
+			// so the first step doesn't pick up its outputs and state
 			rc.JobContainer.Copy(rc.GetActPath()+"/", &container.FileEntry{
 				Name: "workflow/event.json",
 				Mode: 0644,
@@ -283,31 +563,217 @@ func (rc *RunContext) startJobContainer() common.Executor {
 				Name: "workflow/paths.txt",
 				Mode: 0666,
 				Body: "",
+			}, &container.FileEntry{
+				Name: "workflow/outputcmd.txt",
+				Mode: 0666,
+				Body: "",
+			}, &container.FileEntry{
+				Name: "workflow/statecmd.txt",
+				Mode: 0666,
+				Body: "",
 			}),
+			rc.chownToContainerUser().IfBool(rc.Config.ContainerUser != ""),
 		)(ctx)
 	}
 }
+
+// execAsRoot runs a command in the job container as root, regardless of the ContainerUser
+func (rc *RunContext) execAsRoot(cmd ...string) common.Executor {
+	return func(ctx context.Context) error {
+		return rc.JobContainer.Exec(cmd, "", rc.Env, "root", "")(ctx)
+	}
+}
+
+// chownToContainerUser hands the act path and, unless it is bound, the workspace to the ContainerUser, they are
+// created by root when files are copied into the container and the steps couldn't write to them otherwise
+func (rc *RunContext) chownToContainerUser() common.Executor {
+	paths := []string{rc.GetActPath()}
+	if !rc.Config.BindWorkdir {
+		paths = append(paths, rc.ContainerWorkdir())
+	}
+	return rc.execAsRoot(append([]string{"chown", "-R", rc.Config.ContainerUser}, paths...)...)
+}
+
 func (rc *RunContext) execJobContainer(cmd []string, cmdline string, env map[string]string, user, workdir string) common.Executor {
 	return func(ctx context.Context) error {
 		return rc.JobContainer.Exec(cmd, cmdline, env, user, workdir)(ctx)
 	}
 }
 
+// containerCapAdd returns the capabilities added to the containers, ContainerCapAdd extended by the ContainerCapPresets
+func (rc *RunContext) containerCapAdd() []string {
+	presetCaps, err := container.ExpandCapabilityPresets(rc.Config.ContainerCapPresets)
+	if err != nil {
+		log.Warnf("Ignoring capability presets: %v", err)
+		return rc.Config.ContainerCapAdd
+	}
+	capAdd := append([]string{}, rc.Config.ContainerCapAdd...)
+	for _, c := range presetCaps {
+		found := false
+		for _, existing := range capAdd {
+			if strings.EqualFold(existing, c) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			capAdd = append(capAdd, c)
+		}
+	}
+	return capAdd
+}
+
+// forcePull returns whether the image is pulled even if already present, PullPolicies can override ForcePull per image.
+// An image without a tag also matches a policy for its ':latest' tag and the other way around
+func (rc *RunContext) forcePull(image string) bool {
+	if pull, ok := rc.Config.PullPolicies[image]; ok {
+		return pull
+	}
+	alias := image + ":latest"
+	if strings.HasSuffix(image, ":latest") {
+		alias = strings.TrimSuffix(image, ":latest")
+	}
+	if pull, ok := rc.Config.PullPolicies[alias]; ok {
+		return pull
+	}
+	return rc.Config.ForcePull
+}
+
 // stopJobContainer removes the job container (if it exists) and its volume (if it exists) if !rc.Config.ReuseContainers
+// and the container isn't part of the pool
 func (rc *RunContext) stopJobContainer() common.Executor {
 	return func(ctx context.Context) error {
-		if rc.JobContainer != nil && !rc.Config.ReuseContainers {
+		if rc.JobContainer != nil && !rc.Config.ReuseContainers && rc.containerPoolName == "" {
 			return rc.JobContainer.Remove().
-				Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName(), false).Finally(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName()+"-env", false)).If(func(ctx context.Context) bool { return !rc.Local }))(ctx)
+				Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName(), false).IfBool(rc.Config.WorkspaceVolumeName == "").Finally(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName()+"-env", false)).Finally(rc.removeRunnerDirVolumes().IfBool(rc.Config.IsolateRunnerDirs)).If(func(ctx context.Context) bool { return !rc.Local && rc.usesDockerBackend() }))(ctx)
 		}
 		return nil
 	}
 }
 
+// acquirePooledContainer reserves a container of the pool for this job, if all of them
+// are in use the job falls back to a regular container
+func (rc *RunContext) acquirePooledContainer(ctx context.Context, image string, username string, password string) {
+	if rc.Config.ContainerPoolSize <= 0 || rc.containerPoolName != "" {
+		return
+	}
+	key := poolKey(image, rc.containerArchitecture(), rc.Config.Workdir, rc.containerPoolSettings(username, password)...)
+	if name, ok := jobContainerPool.acquire(key, rc.Config.ContainerPoolSize); ok {
+		common.Logger(ctx).Debugf("Using pooled container %s", name)
+		rc.containerPoolKey = key
+		rc.containerPoolName = name
+		return
+	}
+	common.Logger(ctx).Debugf("All %d pooled containers for image %s are in use", rc.Config.ContainerPoolSize, image)
+}
+
+// containerPoolSettings returns the settings besides the image the job container is created with, jobs
+// differing in them, e.g. in the options of their container, can't share a pooled container
+func (rc *RunContext) containerPoolSettings(username string, password string) []string {
+	settings := []string{username, password}
+	c := rc.Run.Job().Container()
+	if c == nil {
+		return settings
+	}
+	ee := rc.NewExpressionEvaluator()
+	settings = append(settings, ee.Interpolate(c.Options))
+	names := make([]string, 0, len(c.Env))
+	for name := range c.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		settings = append(settings, name+"="+ee.Interpolate(c.Env[name]))
+	}
+	return append(settings, c.Volumes...)
+}
+
+// releasePooledContainer returns the container to the pool, it is kept alive for the next job
+func (rc *RunContext) releasePooledContainer() {
+	if rc.containerPoolName == "" {
+		return
+	}
+	jobContainerPool.release(rc.containerPoolKey, rc.containerPoolName)
+	rc.containerPoolKey = ""
+	rc.containerPoolName = ""
+}
+
+// resetPooledContainer removes what a previous job left in the workspace of a pooled container,
+// the workspace is copied again afterwards
+func (rc *RunContext) resetPooledContainer() common.Executor {
+	return func(ctx context.Context) error {
+		if rc.Config.BindWorkdir {
+			return nil
+		}
+		common.Logger(ctx).Debugf("Resetting workspace of pooled container %s", rc.containerPoolName)
+		return rc.JobContainer.Exec([]string{"find", rc.ContainerWorkdir(), "-mindepth", "1", "-delete"}, "", rc.Env, "root", "")(ctx)
+	}
+}
+
+// startRemoteExecutor runs a self-hosted job on the RemoteHost over ssh, the workspace is always
+// copied since it can't be bound and the env is the one of the remote user instead of the one of act
+func (rc *RunContext) startRemoteExecutor() common.Executor {
+	return func(ctx context.Context) error {
+		client, err := container.DialSSH(container.SSHConfig{
+			Host:         rc.Config.RemoteHost,
+			IdentityFile: rc.Config.RemoteIdentityFile,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", rc.Config.RemoteHost, err)
+		}
+		miscpath := "/tmp/act-" + uuid.New().String()
+		executor := &container.SSHExecutor{
+			Client: client,
+			Path:   miscpath + "/hostexecutor",
+			Root:   miscpath,
+			StdOut: rc.newOutputWriter(ctx),
+		}
+		rc.JobContainer = executor
+		rc.SetActPath(miscpath + "/act")
+		rc.Local = true
+
+		env, err := executor.Env(ctx)
+		if err != nil {
+			return err
+		}
+		for k, v := range env {
+			rc.Env[k] = v
+		}
+		rc.Env["RUNNER_TOOL_CACHE"] = miscpath + "/tool_cache"
+		rc.Env["RUNNER_OS"] = "linux"
+		rc.Env["RUNNER_TEMP"] = miscpath + "/tmp"
+
+		copyToPath, copyWorkspace := rc.localCheckoutPath()
+		return common.NewPipelineExecutor(
+			executor.MkdirAll(executor.Path, 0777),
+			executor.MkdirAll(rc.Env["RUNNER_TEMP"], 0777),
+			executor.CopyDir(path.Join(executor.Path, copyToPath), rc.Config.Workdir, rc.Config.UseGitIgnore, rc.copyExcludes()...).IfBool(copyWorkspace),
+			executor.Copy(rc.GetActPath()+"/", &container.FileEntry{
+				Name: "workflow/event.json",
+				Mode: 0644,
+				Body: rc.EventJSON,
+			}, &container.FileEntry{
+				Name: "workflow/envs.txt",
+				Mode: 0666,
+				Body: "",
+			}, &container.FileEntry{
+				Name: "workflow/paths.txt",
+				Mode: 0666,
+				Body: "",
+			}),
+		)(ctx)
+	}
+}
+
 // Prepare the mounts and binds for the worker
 
 // ActionCacheDir is for rc
 func (rc *RunContext) ActionCacheDir() string {
+	return actCacheDir()
+}
+
+// actCacheDir is the directory act caches actions and run numbers in, below XDG_CACHE_HOME
+func actCacheDir() string {
 	var xdgCache string
 	var ok bool
 	if xdgCache, ok = os.LookupEnv("XDG_CACHE_HOME"); !ok || xdgCache == "" {
@@ -339,13 +805,105 @@ func (rc *RunContext) startContainer() common.Executor {
 }
 
 func (rc *RunContext) stopContainer() common.Executor {
-	return rc.stopJobContainer()
+	return common.NewPipelineExecutor(
+		rc.commitJobContainer(),
+		rc.exportJobContainer(),
+		rc.extractWorkspace(),
+	).Finally(rc.stopJobContainer())
+}
+
+// commitJobContainer snapshots the filesystem of the job container into an image
+// if a step failed, so it can be inspected after the container is removed
+func (rc *RunContext) commitJobContainer() common.Executor {
+	return func(ctx context.Context) error {
+		if !rc.Config.CommitOnFailure || rc.JobContainer == nil || rc.Local || common.JobError(ctx) == nil {
+			return nil
+		}
+		logger := common.Logger(ctx)
+		ref := fmt.Sprintf("%s:failure-%s", strings.ToLower(rc.jobContainerName()), time.Now().Format("20060102-150405"))
+		if err := rc.JobContainer.Commit(ctx, ref); err != nil {
+			logger.Errorf("Failed to commit job container: %v", err)
+			return nil
+		}
+		logger.Infof("  \U0001F4F8  Committed job container to image %s, inspect it with 'docker run -it --entrypoint /bin/sh %s'", ref, ref)
+		return nil
+	}
+}
+
+// exportJobContainer writes the filesystem of the job container to a tar in ExportOnFailure
+// if a step failed, it is the flat alternative to CommitOnFailure
+func (rc *RunContext) exportJobContainer() common.Executor {
+	return func(ctx context.Context) error {
+		if rc.Config.ExportOnFailure == "" || rc.JobContainer == nil || rc.Local || common.JobError(ctx) == nil {
+			return nil
+		}
+		logger := common.Logger(ctx)
+		if err := os.MkdirAll(rc.Config.ExportOnFailure, 0755); err != nil {
+			logger.Errorf("Failed to export job container: %v", err)
+			return nil
+		}
+		dest := filepath.Join(rc.Config.ExportOnFailure, fmt.Sprintf("%s-failure-%s.tar", rc.jobContainerName(), time.Now().Format("20060102-150405")))
+		f, err := os.Create(dest)
+		if err != nil {
+			logger.Errorf("Failed to export job container: %v", err)
+			return nil
+		}
+		defer f.Close()
+		if err := rc.JobContainer.Export(ctx, f); err != nil {
+			logger.Errorf("Failed to export job container: %v", err)
+			return nil
+		}
+		logger.Infof("  \U0001F4E6  Exported job container to %s", dest)
+		return nil
+	}
+}
+
+// extractWorkspace copies the workspace from the job container back to the host,
+// without a bind the changes made by the job would be lost when the volume is removed
+func (rc *RunContext) extractWorkspace() common.Executor {
+	return common.Executor(func(ctx context.Context) error {
+		if rc.Config.ExtractWorkspaceTo == "" || rc.Config.BindWorkdir || rc.JobContainer == nil {
+			return nil
+		}
+		if rc.Local {
+			common.Logger(ctx).Debugf("Skipping workspace extraction for self-hosted job")
+			return nil
+		}
+
+		workdir := rc.ContainerWorkdir()
+		common.Logger(ctx).Infof("  \U0001F4E6  Extracting workspace %s to %s", workdir, rc.Config.ExtractWorkspaceTo)
+		archive, err := rc.JobContainer.GetContainerArchive(ctx, workdir)
+		if err != nil {
+			return fmt.Errorf("failed to extract workspace: %w", err)
+		}
+		defer archive.Close()
+
+		return common.ExtractTar(archive, rc.Config.ExtractWorkspaceTo, path.Base(workdir))
+	}).IfNot(common.Dryrun)
 }
 
 func (rc *RunContext) closeContainer() common.Executor {
 	return func(ctx context.Context) error {
 		if rc.JobContainer != nil {
-			return rc.JobContainer.Close()(ctx)
+			return rc.recordJobResult().Finally(rc.JobContainer.Close())(ctx)
+		}
+		return nil
+	}
+}
+
+// recordJobResult writes the result of the job to container.ResultPath of a job container that is kept
+// after the job, so --prune-failed can tell the containers of failed runs apart
+func (rc *RunContext) recordJobResult() common.Executor {
+	return func(ctx context.Context) error {
+		if !rc.Config.ReuseContainers && rc.containerPoolName == "" {
+			return nil
+		}
+		result := rc.Run.Job().Result
+		if common.JobError(ctx) != nil || result != "success" {
+			result = "failure"
+		}
+		if err := rc.JobContainer.WriteFile(container.ResultPath, []byte(result), 0644)(ctx); err != nil {
+			common.Logger(ctx).Warnf("Failed to record the job result in the container: %v", err)
 		}
 		return nil
 	}
@@ -363,30 +921,211 @@ func (rc *RunContext) steps() []*model.Step {
 	return rc.Run.Job().Steps
 }
 
+func (rc *RunContext) timeout() time.Duration {
+	return time.Duration(rc.Run.Job().TimeoutMinutes) * time.Minute
+}
+
+// timeoutShellInput is read by the shell JobTimeoutAction to wait until the container was inspected
+var timeoutShellInput io.Reader = os.Stdin
+
+// onTimeout runs the JobTimeoutAction after the job exceeded its timeout-minutes, the job container
+// is still running so it can be inspected
+func (rc *RunContext) onTimeout() common.Executor {
+	return func(ctx context.Context) error {
+		action := rc.Config.JobTimeoutAction
+		if action == "" || action == JobTimeoutActionNone {
+			return nil
+		}
+		logger := common.Logger(ctx)
+		logger.Errorf("  \u23F0  Last lines of output before the timeout:")
+		for _, line := range rc.jobOutputTail().get() {
+			logger.Errorf("  | %s", strings.TrimRight(line, "\r\n"))
+		}
+		if rc.JobContainer == nil {
+			return nil
+		}
+
+		switch action {
+		case JobTimeoutActionSnapshot:
+			if rc.Local {
+				return nil
+			}
+			ref := fmt.Sprintf("%s:timeout-%s", strings.ToLower(rc.jobContainerName()), time.Now().Format("20060102-150405"))
+			if err := rc.JobContainer.Commit(ctx, ref); err != nil {
+				logger.Errorf("Failed to commit job container: %v", err)
+				return nil
+			}
+			logger.Infof("  \U0001F4F8  Committed job container to image %s, inspect it with 'docker run -it --entrypoint /bin/sh %s'", ref, ref)
+		case JobTimeoutActionShell:
+			if rc.Local {
+				logger.Infof("  \U0001F41A  Inspect the workspace in %s, press Enter to stop the job", rc.ContainerWorkdir())
+			} else {
+				logger.Infof("  \U0001F41A  Inspect the job container with 'docker exec -it %s /bin/sh', press Enter to stop it", rc.jobContainerName())
+			}
+			done := make(chan struct{})
+			go func() {
+				_, _ = bufio.NewReader(timeoutShellInput).ReadString('\n')
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-ctx.Done():
+			}
+		}
+		return nil
+	}
+}
+
 // Executor returns a pipeline executor for all the steps in the job
 func (rc *RunContext) Executor() common.Executor {
-	return newJobExecutor(rc).Finally(func(ctx context.Context) error {
+	return common.NewPipelineExecutor(
+		rc.resetRunState(),
+		rc.validateExecutor(),
+		rc.preRunHook(),
+		newJobExecutor(rc),
+	).Finally(func(ctx context.Context) error {
 		if rc.JobContainer != nil {
-			ctx := context.Background()
+			ctx := container.WithDockerHost(context.Background(), rc.Config.DockerHost)
 			if rc.Config.AutoRemove {
 				log.Infof("Cleaning up container for job %s", rc.JobName)
 				if err := rc.stopJobContainer()(ctx); err != nil {
 					log.Errorf("Error while cleaning container: %v", err)
 				}
 			}
+			defer rc.releasePooledContainer()
 			return rc.JobContainer.Close()(ctx)
 		}
 		return nil
-	}).If(rc.isEnabled)
+	}).Finally(rc.hostHook("post-run", rc.Config.PostRun)).If(rc.isEnabled)
+}
+
+// resetRunState clears the step results, env and path a previous run of the job left in the run context,
+// so they don't leak into the next run when the executor is run again, e.g. with --watch
+func (rc *RunContext) resetRunState() common.Executor {
+	return func(ctx context.Context) error {
+		rc.StepResults = make(map[string]*model.StepResult)
+		rc.Env = nil
+		rc.ExtraPath = nil
+		rc.CurrentStep = ""
+		rc.ExprEval = rc.NewExpressionEvaluator()
+		return nil
+	}
+}
+
+// validateExecutor fails the job before anything is started if StrictSecrets is set and Validate fails
+func (rc *RunContext) validateExecutor() common.Executor {
+	return func(ctx context.Context) error {
+		if !rc.Config.StrictSecrets {
+			return nil
+		}
+		if err := rc.Validate(); err != nil {
+			rc.result("failure")
+			return err
+		}
+		return nil
+	}
+}
+
+// preRunHook runs the PreRun hook, the job fails without running any step if the hook fails
+func (rc *RunContext) preRunHook() common.Executor {
+	return func(ctx context.Context) error {
+		if err := rc.hostHook("pre-run", rc.Config.PreRun)(ctx); err != nil {
+			rc.result("failure")
+			return err
+		}
+		return nil
+	}
+}
+
+// hostHook runs a command on the host in the working directory, it receives the workspace,
+// the job and for the post-run hook the result of the job through the env
+func (rc *RunContext) hostHook(name string, command string) common.Executor {
+	return func(ctx context.Context) error {
+		if command == "" {
+			return nil
+		}
+		logger := common.Logger(ctx)
+		logger.Infof("  \U0001F527  Run %s hook: %s", name, command)
+
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+		} else {
+			cmd = exec.CommandContext(ctx, "sh", "-c", command)
+		}
+		cmd.Dir = rc.Config.Workdir
+		cmd.Env = append(os.Environ(),
+			"GITHUB_WORKSPACE="+rc.Config.Workdir,
+			"GITHUB_JOB="+rc.Run.JobID,
+			"ACT_JOB_NAME="+rc.String(),
+			"ACT_JOB_RESULT="+rc.Run.Job().Result,
+		)
+		rawLogger := logger.WithField("raw_output", true)
+		logWriter := common.NewLineWriter(func(s string) bool {
+			if !rc.Config.Quiet {
+				rawLogger.Infof("%s", s)
+			}
+			return true
+		})
+		cmd.Stdout = logWriter
+		cmd.Stderr = logWriter
+
+		if err := cmd.Run(); err != nil {
+			logger.Errorf("  \u274C  Failure - %s hook", name)
+			return fmt.Errorf("%s hook '%s' failed: %w", name, command, err)
+		}
+		return nil
+	}
+}
+
+func (rc *RunContext) preJobHook() common.Executor {
+	return rc.containerHook("pre-job", rc.Config.JobPreHook)
+}
+
+func (rc *RunContext) postJobHook() common.Executor {
+	return rc.containerHook("post-job", rc.Config.JobPostHook)
+}
+
+// containerHook runs a command in the job container with the env of the job, its output goes to the log
+// of the job like the one of a step. The post-job hook gets the result of the steps in ACT_JOB_RESULT
+func (rc *RunContext) containerHook(name string, command string) common.Executor {
+	return func(ctx context.Context) error {
+		if command == "" || rc.JobContainer == nil {
+			return nil
+		}
+		logger := common.Logger(ctx)
+		logger.Infof("  \U0001F527  Run %s hook: %s", name, command)
+
+		env := rc.withGithubEnv(mergeMaps(rc.GetEnv()))
+		env["ACT_JOB_RESULT"] = "success"
+		if common.JobError(ctx) != nil {
+			env["ACT_JOB_RESULT"] = "failure"
+		}
+		cmd := []string{"sh", "-c", command}
+		if _, isHost := rc.JobContainer.(*container.HostExecutor); isHost && runtime.GOOS == "windows" {
+			cmd = []string{"cmd", "/C", command}
+		}
+		if err := rc.JobContainer.Exec(cmd, "", env, "", "")(ctx); err != nil {
+			logger.Errorf("  \u274C  Failure - %s hook", name)
+			return fmt.Errorf("%s hook '%s' failed: %w", name, command, err)
+		}
+		return nil
+	}
 }
 
 // Executor returns a pipeline executor for all the steps in the job
 func (rc *RunContext) CompositeExecutor() common.Executor {
 	steps := make([]common.Executor, 0)
 
+	explicitIDs := map[string]bool{}
+	for _, step := range rc.Composite.Runs.Steps {
+		if step.ID != "" {
+			explicitIDs[step.ID] = true
+		}
+	}
 	for i, step := range rc.Composite.Runs.Steps {
 		if step.ID == "" {
-			step.ID = fmt.Sprintf("%d", i)
+			step.ID = defaultStepID(i, explicitIDs)
 		}
 		stepcopy := step
 		stepExec := rc.newStepExecutor(&stepcopy)
@@ -409,30 +1148,58 @@ func (rc *RunContext) CompositeExecutor() common.Executor {
 	}
 }
 
+// stepIDs returns the ids the steps set explicitly
+func stepIDs(steps []*model.Step) map[string]bool {
+	ids := map[string]bool{}
+	for _, step := range steps {
+		if step.ID != "" {
+			ids[step.ID] = true
+		}
+	}
+	return ids
+}
+
+// defaultStepID returns the id of a step without one, its index prefixed with underscores
+// until it doesn't collide with the id of another step
+func defaultStepID(i int, explicitIDs map[string]bool) string {
+	id := fmt.Sprintf("%d", i)
+	for explicitIDs[id] {
+		id = "_" + id
+	}
+	return id
+}
+
 func (rc *RunContext) newStepExecutor(step *model.Step) common.Executor {
 	sc := &StepContext{
 		RunContext: rc,
 		Step:       step,
 	}
 	return func(ctx context.Context) error {
+		mu := rc.stateLock()
+		mu.Lock()
 		rc.CurrentStep = sc.Step.ID
 		rc.StepResults[rc.CurrentStep] = &model.StepResult{
 			Outcome:    model.StepStatusSuccess,
 			Conclusion: model.StepStatusSuccess,
 			Outputs:    make(map[string]string),
 		}
+		mu.Unlock()
 
 		runStep, err := sc.isEnabled(ctx)
 		if err != nil {
-			rc.StepResults[rc.CurrentStep].Conclusion = model.StepStatusFailure
-			rc.StepResults[rc.CurrentStep].Outcome = model.StepStatusFailure
+			rc.updateStepResult(func(result *model.StepResult) {
+				result.Conclusion = model.StepStatusFailure
+				result.Outcome = model.StepStatusFailure
+			})
 			return err
 		}
 
 		if !runStep {
 			log.Debugf("Skipping step '%s' due to '%s'", sc.Step.String(), sc.Step.If.Value)
-			rc.StepResults[rc.CurrentStep].Conclusion = model.StepStatusSkipped
-			rc.StepResults[rc.CurrentStep].Outcome = model.StepStatusSkipped
+			rc.updateStepResult(func(result *model.StepResult) {
+				result.Conclusion = model.StepStatusSkipped
+				result.Outcome = model.StepStatusSkipped
+			})
 			return nil
 		}
 
@@ -459,18 +1226,30 @@ func (rc *RunContext) newStepExecutor(step *model.Step) common.Executor {
 		})(ctx)
 
 		err = sc.Executor(ctx)(ctx)
+		rc.jobOutputBatch().flush()
 		if err == nil {
 			common.Logger(ctx).Infof("  \u2705  Success - %s", sc.Step)
 		} else {
 			common.Logger(ctx).Errorf("  \u274C  Failure - %s", sc.Step)
 
-			rc.StepResults[rc.CurrentStep].Outcome = model.StepStatusFailure
-			if sc.Step.ContinueOnError {
+			// a continue-on-error that can't be evaluated doesn't let the step continue, the step still fails
+			// with its outputs and env processed like any other failure
+			continueOnError, evalErr := sc.continueOnError()
+			if evalErr != nil {
+				common.Logger(ctx).Errorf("  \u274C  Error in continue-on-error: expression - %s: %v", sc.Step, evalErr)
+				continueOnError = false
+			}
+			rc.updateStepResult(func(result *model.StepResult) {
+				result.Outcome = model.StepStatusFailure
+				if continueOnError {
+					result.Conclusion = model.StepStatusSuccess
+				} else {
+					result.Conclusion = model.StepStatusFailure
+				}
+			})
+			if continueOnError {
 				common.Logger(ctx).Infof("Failed but continue next step")
 				err = nil
-				rc.StepResults[rc.CurrentStep].Conclusion = model.StepStatusSuccess
-			} else {
-				rc.StepResults[rc.CurrentStep].Conclusion = model.StepStatusFailure
 			}
 		}
 		// Process Runner File Commands
@@ -483,6 +1262,13 @@ func (rc *RunContext) newStepExecutor(step *model.Step) common.Executor {
 		for k, v := range output {
 			rc.setOutput(ctx, map[string]string{"name": k}, v)
 		}
+		err = rc.applyEnvFile(sc.Env["GITHUB_ENV"])(ctx)
+		if err != nil {
+			return err
+		}
+		if rc.Config.PrintStepOutputs {
+			rc.logStepOutputs(ctx)
+		}
 		if orgerr != nil {
 			return orgerr
 		}
@@ -490,7 +1276,153 @@ func (rc *RunContext) newStepExecutor(step *model.Step) common.Executor {
 	}
 }
 
-func (rc *RunContext) platformImage() string {
+// logStepOutputs logs the outputs of the current step sorted by name, secrets in them are masked by the job logger
+func (rc *RunContext) logStepOutputs(ctx context.Context) {
+	logger := common.Logger(ctx)
+	result, ok := rc.getStepsContext()[rc.CurrentStep]
+	if !ok || len(result.Outputs) == 0 {
+		logger.Infof("  \U0001F4E4  No outputs set by %s", rc.CurrentStep)
+		return
+	}
+	names := make([]string, 0, len(result.Outputs))
+	for name := range result.Outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	logger.Infof("  \U0001F4E4  Outputs of %s", rc.CurrentStep)
+	for _, name := range names {
+		logger.Infof("      %s=%s", name, result.Outputs[name])
+	}
+}
+
+// applyEnvFile moves the env the step wrote to the GITHUB_ENV file into the env of the run context
+// and truncates the file, so every line is applied once instead of being re-read by all later steps
+func (rc *RunContext) applyEnvFile(envFile string) common.Executor {
+	return func(ctx context.Context) error {
+		if envFile == "" {
+			return nil
+		}
+		env := map[string]string{}
+		if err := rc.JobContainer.UpdateFromEnv(envFile, &env)(ctx); err != nil {
+			return err
+		}
+		if len(env) == 0 {
+			return nil
+		}
+		mu := rc.stateLock()
+		mu.Lock()
+		if rc.Env == nil {
+			rc.Env = make(map[string]string)
+		}
+		for k, v := range env {
+			rc.Env[k] = v
+		}
+		mu.Unlock()
+		return rc.JobContainer.WriteFile(envFile, []byte{}, 0666)(ctx)
+	}
+}
+
+// ReusableWorkflowSecrets returns the secrets passed to the reusable workflow the job uses, all secrets of
+// the run with `secrets: inherit`, else only the ones the job names
+func (rc *RunContext) ReusableWorkflowSecrets() map[string]string {
+	job := rc.Run.Job()
+	if job.InheritSecrets() {
+		return mergeMaps(rc.Config.Secrets)
+	}
+	secrets := map[string]string{}
+	eval := rc.NewExpressionEvaluator()
+	for name, value := range job.Secrets() {
+		secrets[name] = eval.Interpolate(value)
+	}
+	return secrets
+}
+
+// copyExcludes returns the patterns of the paths that aren't copied with the workspace. The github context
+// is read from the .git of the host, so GITHUB_SHA and GITHUB_REF are set without the .git in the container
+func (rc *RunContext) copyExcludes() []string {
+	if rc.Config.SkipGitDir {
+		return append(append([]string{}, rc.Config.CopyExcludes...), ".git")
+	}
+	return rc.Config.CopyExcludes
+}
+
+// platformDockerfilePrefix marks a platform that is built from a local Dockerfile instead of pulled,
+// e.g. -P ubuntu-latest=Dockerfile:./ci/Dockerfile
+const platformDockerfilePrefix = "Dockerfile:"
+
+// platformImage returns the image of the job, for a platform built from a Dockerfile the tag it is built with
+func (rc *RunContext) platformImage() (string, error) {
+	image := rc.mappedPlatformImage()
+	dockerfile := rc.platformDockerfile(image)
+	if dockerfile == "" {
+		return image, nil
+	}
+	tag, err := dockerfileImageTag(dockerfile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the Dockerfile of %s: %w", rc.String(), err)
+	}
+	return tag, nil
+}
+
+// platformDockerfile returns the path of the Dockerfile the image is built from, relative paths are resolved against Workdir,
+// it returns an empty string for images that are pulled
+func (rc *RunContext) platformDockerfile(image string) string {
+	if !strings.HasPrefix(image, platformDockerfilePrefix) {
+		return ""
+	}
+	dockerfile := strings.TrimPrefix(image, platformDockerfilePrefix)
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(rc.Config.Workdir, dockerfile)
+	}
+	return dockerfile
+}
+
+// dockerfileImageTag returns the tag of the image built from the Dockerfile, it changes with its content
+// so an edited Dockerfile is rebuilt, changes to the files it copies require ForceRebuild
+func dockerfileImageTag(dockerfile string) (string, error) {
+	content, err := os.ReadFile(dockerfile)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(content)
+	return fmt.Sprintf("act-platform:%s", hex.EncodeToString(hash[:])[:16]), nil
+}
+
+// buildPlatformImage builds the image of a platform from its Dockerfile, unless it already exists
+func (rc *RunContext) buildPlatformImage(image string, dockerfile string, platform string) common.Executor {
+	return func(ctx context.Context) error {
+		if !common.Dryrun(ctx) && !rc.Config.ForceRebuild {
+			exists, err := container.ImageExistsLocally(ctx, image, platform)
+			if err != nil {
+				return err
+			}
+			if exists {
+				common.Logger(ctx).Debugf("image '%s' of %s already exists", image, dockerfile)
+				return nil
+			}
+		}
+		return container.BuildImage(ctx, container.BuildInput{
+			ContextDir: filepath.Dir(dockerfile),
+			Dockerfile: filepath.Base(dockerfile),
+			BuildArgs:  rc.containerBuildArgs(),
+			Tags:       []string{image},
+			Platform:   platform,
+		})
+	}
+}
+
+// containerBuildArgs returns the interpolated ContainerBuildArgs, values taken from secrets are masked in the output of the build
+func (rc *RunContext) containerBuildArgs() map[string]string {
+	args := make(map[string]string, len(rc.Config.ContainerBuildArgs))
+	for k, v := range rc.Config.ContainerBuildArgs {
+		args[k] = rc.ExprEval.Interpolate(v)
+	}
+	return args
+}
+
+// mappedPlatformImage returns the image the container of the job or its runs-on labels map to, the env of the
+// workflow and job is in the context of the expressions since ExprEval is created from GetEnv
+func (rc *RunContext) mappedPlatformImage() string {
 	job := rc.Run.Job()
 
 	c := job.Container()
@@ -502,37 +1434,147 @@ func (rc *RunContext) platformImage() string {
 		log.Errorf("'runs-on' key not defined in %s", rc.String())
 	}
 
-	for _, runnerLabel := range job.RunsOn() {
-		platformName := rc.ExprEval.Interpolate(runnerLabel)
+	image, _ := rc.runsOnImage()
+	return image
+}
+
+// runsOnImage returns the image of the first runs-on label mapped in Platforms, if none is mapped it
+// returns the DefaultImage and true
+func (rc *RunContext) runsOnImage() (string, bool) {
+	labels := rc.runsOn()
+	for _, platformName := range labels {
 		image := rc.Config.Platforms[strings.ToLower(platformName)]
 		if image != "" {
-			return image
+			return image, false
 		}
 	}
 
-	return ""
+	// an empty value of a matrix is reported by isEnabled instead of being run with the default
+	if rc.Config.DefaultImage != "" && len(strings.TrimSpace(strings.Join(labels, ""))) > 0 {
+		return rc.Config.DefaultImage, true
+	}
+	return "", false
+}
+
+// runsOn returns the evaluated runs-on labels of the job, an expression like ${{ matrix.runner }}
+// can evaluate to a list of labels
+func (rc *RunContext) runsOn() []string {
+	job := rc.Run.Job()
+	// evaluate a copy, the node is shared by all matrix combinations
+	node := copyYamlNode(&job.RawRunsOn)
+	if err := rc.ExprEval.EvaluateYamlNode(node); err != nil {
+		log.Errorf("Unable to evaluate runs-on of %s: %v", rc.String(), err)
+		return nil
+	}
+	return (&model.Job{RawRunsOn: *node}).RunsOn()
+}
+
+func copyYamlNode(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, content := range node.Content {
+		c.Content[i] = copyYamlNode(content)
+	}
+	return &c
 }
 
 func (rc *RunContext) hostname() string {
+	return rc.containerOption("hostname", "h")
+}
+
+// containerOption returns the value of a flag in the options of the job container
+func (rc *RunContext) containerOption(name string, shorthand string) string {
 	job := rc.Run.Job()
+	if job == nil {
+		return ""
+	}
 	c := job.Container()
 	if c == nil {
 		return ""
 	}
 
 	optionsFlags := pflag.NewFlagSet("container_options", pflag.ContinueOnError)
-	hostname := optionsFlags.StringP("hostname", "h", "", "")
-	optionsArgs, err := shlex.Split(c.Options)
+	optionsFlags.ParseErrorsWhitelist.UnknownFlags = true
+	value := optionsFlags.StringP(name, shorthand, "", "")
+	// the options may use expressions, e.g. a hostname for each matrix combination
+	options := rc.NewExpressionEvaluator().Interpolate(c.Options)
+	optionsArgs, err := shlex.Split(options)
 	if err != nil {
-		log.Warnf("Cannot parse container options: %s", c.Options)
+		log.Warnf("Cannot parse container options: %s", options)
 		return ""
 	}
 	err = optionsFlags.Parse(optionsArgs)
 	if err != nil {
-		log.Warnf("Cannot parse container options: %s", c.Options)
+		log.Warnf("Cannot parse container options: %s", options)
 		return ""
 	}
-	return *hostname
+	return *value
+}
+
+// containerArchitecture resolves the OS/architecture platform of the containers of the job.
+// A '--platform' in the container options wins over the 'container-architecture' matrix value,
+// which wins over the configured ContainerArchitecture and DefaultImageArchitecture
+func (rc *RunContext) containerArchitecture() string {
+	if platform := rc.containerOption("platform", ""); platform != "" {
+		return platform
+	}
+	if platform, ok := rc.Matrix["container-architecture"].(string); ok && platform != "" {
+		return platform
+	}
+	if rc.Config.ContainerArchitecture != "" {
+		return rc.Config.ContainerArchitecture
+	}
+	return rc.Config.DefaultImageArchitecture
+}
+
+// binfmtMiscDir is where the kernel lists the registered binfmt_misc interpreters
+var binfmtMiscDir = "/proc/sys/fs/binfmt_misc"
+
+// qemuArchitectures maps the GOARCH style architecture of a platform to the name qemu uses for it
+var qemuArchitectures = map[string]string{
+	"amd64":   "x86_64",
+	"386":     "i386",
+	"arm64":   "aarch64",
+	"arm":     "arm",
+	"ppc64le": "ppc64le",
+	"s390x":   "s390x",
+	"riscv64": "riscv64",
+}
+
+// binfmtRegistered returns false if the kernel has no qemu interpreter for the architecture of the platform.
+// Docker Desktop ships its own emulation, so this can only be verified on linux hosts
+func binfmtRegistered(platform string) bool {
+	if runtime.GOOS != "linux" {
+		return true
+	}
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 {
+		return true
+	}
+	qemuArch, ok := qemuArchitectures[strings.ToLower(parts[1])]
+	if !ok {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(binfmtMiscDir, "qemu-"+qemuArch))
+	return err == nil
+}
+
+// warnIfEmulated warns if the platform doesn't match the host architecture, in that case the
+// containers run through qemu and are much slower. Without a registered qemu interpreter they fail
+// with cryptic exec errors, so the warning explains how to install one
+func (rc *RunContext) warnIfEmulated(ctx context.Context, platform string) {
+	if platform == "" || rc.Local {
+		return
+	}
+	if native := "linux/" + runtime.GOARCH; strings.EqualFold(platform, native) {
+		return
+	}
+	logger := common.Logger(ctx)
+	logger.Warnf("  \U000026A0  Using platform %s on a %s host, the containers are emulated (qemu) and might be slow or fail", platform, runtime.GOARCH)
+	if !binfmtRegistered(platform) {
+		logger.Errorf("  \u274C  No qemu interpreter for %s is registered with binfmt_misc, containers of this platform won't be able to run. "+
+			"Install the interpreters with 'docker run --privileged --rm tonistiigi/binfmt --install all'", platform)
+	}
 }
 
 func (rc *RunContext) isEnabled(ctx context.Context) bool {
@@ -548,18 +1590,29 @@ func (rc *RunContext) isEnabled(ctx context.Context) bool {
 		return false
 	}
 
-	img := rc.platformImage()
+	img, err := rc.platformImage()
+	if err != nil {
+		// the job runs to fail on starting its container instead of being skipped as unsupported
+		return true
+	}
 	if img == "" {
 		if job.RunsOn() == nil {
 			log.Errorf("'runs-on' key not defined in %s", rc.String())
+		} else if labels := rc.runsOn(); len(strings.TrimSpace(strings.Join(labels, ""))) == 0 {
+			l.Errorf("  \u274C  'runs-on: %s' of %s evaluated to an empty value, check the values of the matrix", strings.Join(job.RunsOn(), ", "), rc.String())
+			return false
 		}
 
-		for _, runnerLabel := range job.RunsOn() {
-			platformName := rc.ExprEval.Interpolate(runnerLabel)
+		for _, platformName := range rc.runsOn() {
 			l.Infof("\U0001F6A7  Skipping unsupported platform -- Try running with `-P %+v=...`", platformName)
 		}
 		return false
 	}
+	if job.Container() == nil {
+		if _, isDefault := rc.runsOnImage(); isDefault {
+			l.Warnf("  \u26A0  'runs-on: %s' isn't mapped to an image, using the default image %s -- Try running with `-P %s=...`", strings.Join(rc.runsOn(), ", "), rc.Config.DefaultImage, rc.runsOn()[0])
+		}
+	}
 	return true
 }
 
@@ -619,8 +1672,28 @@ func (rc *RunContext) getJobContext() *model.JobContext {
 	}
 }
 
+// getStepsContext returns a snapshot of the step results, so the expression evaluator
+// doesn't read them while the command handler is setting outputs
 func (rc *RunContext) getStepsContext() map[string]*model.StepResult {
-	return rc.StepResults
+	mu := rc.stateLock()
+	mu.Lock()
+	defer mu.Unlock()
+	if rc.StepResults == nil {
+		return nil
+	}
+	steps := make(map[string]*model.StepResult, len(rc.StepResults))
+	for id, result := range rc.StepResults {
+		outputs := make(map[string]string, len(result.Outputs))
+		for k, v := range result.Outputs {
+			outputs[k] = v
+		}
+		steps[id] = &model.StepResult{
+			Outputs:    outputs,
+			Conclusion: result.Conclusion,
+			Outcome:    result.Outcome,
+		}
+	}
+	return steps
 }
 
 func (rc *RunContext) getGithubContext() *model.GithubContext {
@@ -628,6 +1701,7 @@ func (rc *RunContext) getGithubContext() *model.GithubContext {
 		Event:            make(map[string]interface{}),
 		EventPath:        rc.GetActPath() + "/workflow/event.json",
 		Workflow:         rc.Run.Workflow.Name,
+		Job:              rc.JobName,
 		RunID:            rc.Config.Env["GITHUB_RUN_ID"],
 		RunNumber:        rc.Config.Env["GITHUB_RUN_NUMBER"],
 		RunAttempt:       rc.Config.Env["GITHUB_RUN_ATTEMPT"],
@@ -644,13 +1718,30 @@ func (rc *RunContext) getGithubContext() *model.GithubContext {
 		RunnerPerflog:    rc.Config.Env["RUNNER_PERFLOG"],
 		RunnerTrackingID: rc.Config.Env["RUNNER_TRACKING_ID"],
 	}
-	if rc.GithubContextBase != nil {
-		err := json.Unmarshal([]byte(*rc.GithubContextBase), ghc)
-		if err == nil {
-			return ghc
+	if rc.Config.RepositoryOwner != "" {
+		ghc.RepositoryOwner = rc.Config.RepositoryOwner
+	}
+	if rc.runNumber != "" {
+		if ghc.RunID == "" {
+			ghc.RunID = rc.runNumber
 		}
+		if ghc.RunNumber == "" {
+			ghc.RunNumber = rc.runNumber
+		}
+	}
+	if rc.GithubContextBase == nil {
+		return applyDefaults(ghc, rc)
 	}
-	return applyDefaults(ghc, rc)
+	// the base augments the computed context, the defaults are derived from its event
+	// and the fields it sets take precedence over the computed ones
+	base := []byte(*rc.GithubContextBase)
+	if err := json.Unmarshal(base, ghc); err != nil {
+		log.Errorf("Unable to Unmarshal github context base '%s': %v", *rc.GithubContextBase, err)
+		return applyDefaults(ghc, rc)
+	}
+	ghc = applyDefaults(ghc, rc)
+	_ = json.Unmarshal(base, ghc)
+	return ghc
 }
 
 func applyDefaults(ghc *model.GithubContext, rc *RunContext) *model.GithubContext {
@@ -692,22 +1783,120 @@ func applyDefaults(ghc *model.GithubContext, rc *RunContext) *model.GithubContex
 	}
 
 	if rc.EventJSON != "" {
-		err = json.Unmarshal([]byte(rc.EventJSON), &ghc.Event)
+		event := make(map[string]interface{})
+		err = json.Unmarshal([]byte(rc.EventJSON), &event)
 		if err != nil {
 			log.Errorf("Unable to Unmarshal event '%s': %v", rc.EventJSON, err)
 		}
+		if ghc.Event == nil {
+			ghc.Event = event
+		}
+		// keys of the event of a GithubContextBase are kept
+		for k, v := range event {
+			if _, ok := ghc.Event[k]; !ok {
+				ghc.Event[k] = v
+			}
+		}
 	}
 
 	if ghc.EventName == "pull_request" {
 		ghc.BaseRef = asString(nestedMapLookup(ghc.Event, "pull_request", "base", "ref"))
 		ghc.HeadRef = asString(nestedMapLookup(ghc.Event, "pull_request", "head", "ref"))
+		rc.derivePullRequestRefs(ghc, repoPath)
 	}
 
+	_, hasRepository := ghc.Event["repository"]
 	ghc.SetRefAndSha(rc.Config.DefaultBranch, repoPath)
+	if !hasRepository && ghc.Repository != "" {
+		ghc.Event["repository"] = eventRepository(ghc.Event["repository"], ghc.Repository, rc.defaultBranch(repoPath))
+	}
+	if ghc.WorkflowRef == "" && ghc.Repository != "" {
+		ghc.WorkflowRef = fmt.Sprintf("%s/%s@%s", ghc.Repository, rc.workflowPath(), ghc.Ref)
+	}
 
 	return ghc
 }
 
+// workflowPath returns the path of the workflow file relative to Workdir, like the .github/workflows/ci.yml
+// of the workflow_ref of GitHub. A workflow outside of Workdir is relative to the WorkflowsPath it is read from
+func (rc *RunContext) workflowPath() string {
+	path := rc.Run.Workflow.Path
+	if path == "" {
+		return rc.Run.Workflow.File
+	}
+	dirs := []string{rc.Config.Workdir}
+	if rc.Config.WorkflowsPath != "" {
+		if fi, err := os.Stat(rc.Config.WorkflowsPath); err == nil && !fi.IsDir() {
+			dirs = append(dirs, filepath.Dir(rc.Config.WorkflowsPath))
+		} else {
+			dirs = append(dirs, rc.Config.WorkflowsPath)
+		}
+	}
+	for _, dir := range dirs {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return rc.Run.Workflow.File
+}
+
+var findGitRef = common.FindGitRef
+
+// derivePullRequestRefs fills in the base and head ref a pull_request event doesn't have, e.g. when it is
+// simulated without an event file: the pull request is from the checked out branch into the default branch
+func (rc *RunContext) derivePullRequestRefs(ghc *model.GithubContext, repoPath string) {
+	if ghc.BaseRef == "" {
+		ghc.BaseRef = rc.defaultBranch(repoPath)
+	}
+	if ghc.HeadRef == "" {
+		ref, err := findGitRef(repoPath)
+		if err != nil {
+			log.Debugf("unable to get the head ref: %v", err)
+		} else if strings.HasPrefix(ref, "refs/heads/") {
+			ghc.HeadRef = strings.TrimPrefix(ref, "refs/heads/")
+		}
+	}
+}
+
+// defaultBranch is the configured default branch, or the default branch of the origin remote
+// of the repository at repoPath. It is empty if neither is known
+func (rc *RunContext) defaultBranch(repoPath string) string {
+	if rc.Config.DefaultBranch != "" {
+		return rc.Config.DefaultBranch
+	}
+	branch, err := common.FindGitDefaultBranch(repoPath)
+	if err != nil {
+		log.Debugf("unable to get the default branch: %v", err)
+		return ""
+	}
+	return branch
+}
+
+// eventRepository synthesizes the repository object of an event that doesn't have one, from the
+// owner/name slug of the repository, so actions reading github.event.repository work without a full event.
+// The default_branch is left out if it is unknown
+func eventRepository(existing interface{}, repository string, defaultBranch string) map[string]interface{} {
+	repo, ok := existing.(map[string]interface{})
+	if !ok {
+		repo = map[string]interface{}{}
+	}
+	owner, name := repository, repository
+	if i := strings.Index(repository, "/"); i >= 0 {
+		owner, name = repository[:i], repository[i+1:]
+	}
+	repo["name"] = name
+	repo["full_name"] = repository
+	repo["owner"] = map[string]interface{}{"login": owner, "name": owner}
+	if defaultBranch != "" {
+		repo["default_branch"] = defaultBranch
+	}
+	return repo
+}
+
 func isLocalCheckout(ghc *model.GithubContext, step *model.Step) bool {
 	if step.Type() == model.StepTypeInvalid {
 		// This will be errored out by the executor later, we need this here to avoid a null panic though
@@ -760,12 +1949,17 @@ func nestedMapLookup(m map[string]interface{}, ks ...string) (rval interface{})
 	}
 }
 
+// withGithubEnv adds the GITHUB_* variables to env, CI is only set to true if env doesn't set it already
+// so a workflow can simulate a run outside of CI
 func (rc *RunContext) withGithubEnv(env map[string]string) map[string]string {
 	github := rc.getGithubContext()
-	env["CI"] = "true"
+	if _, ok := env["CI"]; !ok {
+		env["CI"] = "true"
+	}
 	env["GITHUB_ENV"] = rc.GetActPath() + "/workflow/envs.txt"
 	env["GITHUB_PATH"] = rc.GetActPath() + "/workflow/paths.txt"
 	env["GITHUB_WORKFLOW"] = github.Workflow
+	env["GITHUB_WORKFLOW_REF"] = github.WorkflowRef
 	env["GITHUB_RUN_ID"] = github.RunID
 	env["GITHUB_RUN_NUMBER"] = github.RunNumber
 	env["GITHUB_RUN_ATTEMPT"] = github.RunAttempt
@@ -815,8 +2009,7 @@ func (rc *RunContext) withGithubEnv(env map[string]string) map[string]string {
 
 	job := rc.Run.Job()
 	if job.RunsOn() != nil {
-		for _, runnerLabel := range job.RunsOn() {
-			platformName := rc.ExprEval.Interpolate(runnerLabel)
+		for _, platformName := range rc.runsOn() {
 			if platformName != "" {
 				if platformName == "ubuntu-latest" {
 					// hardcode current ubuntu-latest since we have no way to check that 'on the fly'
@@ -829,6 +2022,18 @@ func (rc *RunContext) withGithubEnv(env map[string]string) map[string]string {
 		}
 	}
 
+	// GITHUB_* variables set explicitly in the config override the computed ones, except the files
+	// of the workflow commands act reads back and the ones the github context already takes from the config
+	for k, v := range rc.Config.Env {
+		switch k {
+		case "GITHUB_ENV", "GITHUB_PATH", "GITHUB_RUN_ID", "GITHUB_RUN_NUMBER", "GITHUB_RUN_ATTEMPT", "GITHUB_REPOSITORY_OWNER", "GITHUB_RETENTION_DAYS":
+			continue
+		}
+		if strings.HasPrefix(k, "GITHUB_") {
+			env[k] = v
+		}
+	}
+
 	return env
 }
 
@@ -839,15 +2044,34 @@ func setActionRuntimeVars(rc *RunContext, env map[string]string) {
 	}
 	env["ACTIONS_RUNTIME_URL"] = actionsRuntimeURL
 
+	// the v4 artifact actions use the results service, which is served by the same artifact server
+	actionsResultsURL := os.Getenv("ACTIONS_RESULTS_URL")
+	if actionsResultsURL == "" {
+		actionsResultsURL = actionsRuntimeURL
+	}
+	env["ACTIONS_RESULTS_URL"] = actionsResultsURL
+
 	actionsRuntimeToken := os.Getenv("ACTIONS_RUNTIME_TOKEN")
 	if actionsRuntimeToken == "" {
-		actionsRuntimeToken = "token"
+		actionsRuntimeToken = runtimeToken(rc.getGithubContext().RunID, rc.Run.JobID)
 	}
 	env["ACTIONS_RUNTIME_TOKEN"] = actionsRuntimeToken
 }
 
+// runtimeToken creates an unsigned JWT, the v4 artifact actions read the ids of the run
+// and the job from the 'Actions.Results' scope of the token
+func runtimeToken(runID string, jobID string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	header := encode([]byte(`{"alg":"none","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]interface{}{
+		"scp": fmt.Sprintf("Actions.Results:%s:%s", runID, jobID),
+		"iss": "act",
+	})
+	return header + "." + encode(claims) + ".act"
+}
+
 func (rc *RunContext) localCheckoutPath() (string, bool) {
-	if rc.Config.ForceRemoteCheckout {
+	if rc.Config.ForceRemoteCheckout || rc.Config.SkipCheckout {
 		return "", false
 	}
 	ghContext := rc.getGithubContext()
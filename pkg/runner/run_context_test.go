@@ -2,13 +2,19 @@ package runner
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
 	"runtime"
 	"sort"
 	"strings"
 	"testing"
 
+	"github.com/ankit-arora/act/pkg/common"
+	"github.com/ankit-arora/act/pkg/container"
+	"github.com/ankit-arora/act/pkg/container/containertest"
 	"github.com/ankit-arora/act/pkg/model"
 
 	log "github.com/sirupsen/logrus"
@@ -292,6 +298,47 @@ func TestRunContext_GetBindsAndMounts(t *testing.T) {
 			}
 		}
 	}
+
+	t.Run("WorkspaceVolumeName", func(t *testing.T) {
+		rc := &RunContext{
+			Name: "TestRCName",
+			Run: &model.Run{
+				Workflow: &model.Workflow{
+					Name: "TestWorkflowName",
+				},
+			},
+			Config: &Config{
+				Workdir:             "/mnt/linux",
+				WorkspaceVolumeName: "my-workspace",
+			},
+		}
+		_, gotmount := rc.GetBindsAndMounts()
+		assert.Equal(t, rc.ContainerWorkdir(), gotmount["my-workspace"])
+		assert.NotContains(t, gotmount, rc.jobContainerName())
+	})
+
+	t.Run("IsolateRunnerDirs", func(t *testing.T) {
+		rc := &RunContext{
+			Name: "TestRCName",
+			Run: &model.Run{
+				Workflow: &model.Workflow{
+					Name: "TestWorkflowName",
+				},
+			},
+			Config: &Config{
+				Workdir: "/mnt/linux",
+			},
+		}
+		_, gotmount := rc.GetBindsAndMounts()
+		assert.Equal(t, "/opt/hostedtoolcache", gotmount["act-toolcache"])
+
+		rc.Config.IsolateRunnerDirs = true
+		rc.Config.RunnerTemp = "/runner/tmp"
+		_, gotmount = rc.GetBindsAndMounts()
+		assert.NotContains(t, gotmount, "act-toolcache")
+		assert.Equal(t, "/runner/tmp", gotmount[rc.jobContainerName()+"-tmp"])
+		assert.Equal(t, "/opt/hostedtoolcache", gotmount[rc.jobContainerName()+"-toolcache"])
+	})
 }
 
 func TestGetGitHubContext(t *testing.T) {
@@ -484,3 +531,982 @@ if: always()`, ""),
 	rc.Run.JobID = "job2"
 	assertObject.True(rc.isEnabled(context.Background()))
 }
+
+func TestRunContextGetEnvInterpolation(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{
+			Workdir: ".",
+			Env: map[string]string{
+				"CLI_ENV": "${{ secrets.MY_SECRET }}",
+			},
+			Secrets: map[string]string{
+				"MY_SECRET": "top-secret",
+			},
+		},
+		Run: &model.Run{
+			JobID: "job1",
+			Workflow: &model.Workflow{
+				Name: "test-workflow",
+				Env: map[string]string{
+					"WORKFLOW_SECRET": "${{ secrets.MY_SECRET }}",
+					"WORKFLOW_NAME":   "${{ github.workflow }}",
+				},
+				Jobs: map[string]*model.Job{
+					"job1": createJob(t, `runs-on: ubuntu-latest
+env:
+  JOB_SECRET: prefix-${{ secrets.MY_SECRET }}`, ""),
+				},
+			},
+		},
+	}
+
+	env := rc.GetEnv()
+	assert.Equal(t, "top-secret", env["WORKFLOW_SECRET"])
+	assert.Equal(t, "test-workflow", env["WORKFLOW_NAME"])
+	assert.Equal(t, "prefix-top-secret", env["JOB_SECRET"])
+	// values passed in from the cli are not expressions
+	assert.Equal(t, "${{ secrets.MY_SECRET }}", env["CLI_ENV"])
+}
+
+func TestRunContextDefaultEnvOverride(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	env := rc.withGithubEnv(rc.GetEnv())
+	assert.Equal(t, "true", env["ACT"])
+	assert.Equal(t, "true", env["CI"])
+
+	rc = createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Env = nil
+	rc.Run.Workflow.Env = map[string]string{
+		"ACT": "false",
+		"CI":  "false",
+	}
+	env = rc.withGithubEnv(rc.GetEnv())
+	assert.Equal(t, "false", env["ACT"])
+	assert.Equal(t, "false", env["CI"])
+}
+
+func TestRunContextGithubEnvOverride(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Config.Env = map[string]string{
+		"GITHUB_REF":  "refs/heads/release",
+		"GITHUB_PATH": "/tmp/paths.txt",
+	}
+	rc.Env = nil
+	env := rc.withGithubEnv(rc.GetEnv())
+	assert.Equal(t, "refs/heads/release", env["GITHUB_REF"])
+	assert.Equal(t, rc.GetActPath()+"/workflow/paths.txt", env["GITHUB_PATH"])
+	assert.Equal(t, "true", env["GITHUB_ACTIONS"])
+}
+
+func TestRunContextPlatformImageFromEnv(t *testing.T) {
+	for _, container := range []string{
+		"container:\n  image: ${{ env.MY_IMAGE }}",
+		"container: ${{ env.MY_IMAGE }}",
+	} {
+		rc := &RunContext{
+			Config: &Config{
+				Workdir: ".",
+			},
+			Run: &model.Run{
+				JobID: "job1",
+				Workflow: &model.Workflow{
+					Name: "test-workflow",
+					Env: map[string]string{
+						"MY_IMAGE": "node:16-buster-slim",
+					},
+					Jobs: map[string]*model.Job{
+						"job1": createJob(t, "runs-on: ubuntu-latest\n"+container, ""),
+					},
+				},
+			},
+		}
+		rc.ExprEval = rc.NewExpressionEvaluator()
+
+		assert.Equal(t, "node:16-buster-slim", platformImage(t, rc), container)
+	}
+}
+
+func TestRunContextPlatformImageRunsOnObject(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, "runs-on:\n  group: large-runners\n  labels: [self-hosted, ubuntu-latest]", ""),
+	})
+	assert.Equal(t, "ubuntu-latest", platformImage(t, rc))
+	assert.True(t, rc.isEnabled(context.Background()))
+}
+
+func TestRunContextLocalCheckoutPath(t *testing.T) {
+	workflow := `runs-on: ubuntu-latest
+steps:
+  - uses: actions/checkout@v2
+    with:
+      path: src`
+
+	for _, tt := range []struct {
+		name          string
+		config        *Config
+		path          string
+		copyWorkspace bool
+	}{
+		{"copy", &Config{Workdir: "."}, "src", true},
+		{"force remote checkout", &Config{Workdir: ".", ForceRemoteCheckout: true}, "", false},
+		{"skip checkout", &Config{Workdir: ".", SkipCheckout: true}, "", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &RunContext{
+				Config: tt.config,
+				Run: &model.Run{
+					JobID: "job1",
+					Workflow: &model.Workflow{
+						Jobs: map[string]*model.Job{
+							"job1": createJob(t, workflow, ""),
+						},
+					},
+				},
+			}
+			path, copyWorkspace := rc.localCheckoutPath()
+			assert.Equal(t, tt.path, path)
+			assert.Equal(t, tt.copyWorkspace, copyWorkspace)
+		})
+	}
+}
+
+func TestRunContextVars(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{
+			Workdir: ".",
+			Vars: map[string]string{
+				"MY_VAR": "my-value",
+			},
+		},
+		Env: map[string]string{},
+		Run: &model.Run{
+			JobID: "job1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, "value is my-value", rc.NewExpressionEvaluator().Interpolate("value is ${{ vars.MY_VAR }}"))
+}
+
+func TestRunContextInterpolateOutputs(t *testing.T) {
+	job := createJob(t, `runs-on: ubuntu-latest
+outputs:
+  from-step: ${{ steps.last.outputs.name }}
+  static: value`, "")
+	rc := &RunContext{
+		Config: &Config{Workdir: "."},
+		Env:    map[string]string{},
+		Run: &model.Run{
+			JobID: "job1",
+			Workflow: &model.Workflow{
+				Jobs: map[string]*model.Job{
+					"job1": job,
+				},
+			},
+		},
+		StepResults: map[string]*model.StepResult{
+			"last": {
+				Outputs: map[string]string{
+					"name": "from-last-step",
+				},
+			},
+		},
+	}
+
+	err := rc.interpolateOutputs()(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "from-last-step", job.Outputs["from-step"])
+	assert.Equal(t, "value", job.Outputs["static"])
+}
+
+func TestRunContextContainerArchitecture(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	assert.Equal(t, "", rc.containerArchitecture())
+
+	rc.Config.DefaultImageArchitecture = "linux/arm64"
+	assert.Equal(t, "linux/arm64", rc.containerArchitecture())
+
+	rc.Config.ContainerArchitecture = "linux/amd64"
+	assert.Equal(t, "linux/amd64", rc.containerArchitecture())
+
+	rc.Matrix = map[string]interface{}{"container-architecture": "linux/arm/v7"}
+	assert.Equal(t, "linux/arm/v7", rc.containerArchitecture())
+
+	rc.Run.Workflow.Jobs["job1"] = createJob(t, `
+runs-on: ubuntu-latest
+container:
+  image: node:16
+  options: --cpus 1 --platform linux/s390x`, "")
+	assert.Equal(t, "linux/s390x", rc.containerArchitecture())
+}
+
+func TestRunContextBinfmtRegistered(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("binfmt_misc is only checked on linux")
+	}
+	dir := t.TempDir()
+	defer func(old string) { binfmtMiscDir = old }(binfmtMiscDir)
+	binfmtMiscDir = dir
+
+	assert.False(t, binfmtRegistered("linux/amd64"))
+	assert.True(t, binfmtRegistered("linux/mips64"))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "qemu-x86_64"), []byte("enabled"), 0644))
+	assert.True(t, binfmtRegistered("linux/amd64"))
+	assert.False(t, binfmtRegistered("linux/arm/v7"))
+}
+
+func TestRunContextForcePull(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{
+			ForcePull: true,
+			PullPolicies: map[string]bool{
+				"node:16":         false,
+				"ghcr.io/org/app": true,
+			},
+		},
+	}
+	assert.False(t, rc.forcePull("node:16"))
+	assert.True(t, rc.forcePull("ubuntu:latest"))
+	assert.True(t, rc.forcePull("ghcr.io/org/app:latest"))
+
+	rc.Config.ForcePull = false
+	assert.False(t, rc.forcePull("ubuntu:latest"))
+	assert.True(t, rc.forcePull("ghcr.io/org/app"))
+}
+
+func TestRunContextRuntimeToken(t *testing.T) {
+	parts := strings.Split(runtimeToken("42", "build"), ".")
+	assert.Len(t, parts, 3)
+
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	assert.NoError(t, err)
+	assert.Contains(t, string(claims), `"scp":"Actions.Results:42:build"`)
+}
+
+func TestRunContextHostHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands are run with sh")
+	}
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, "success"),
+	})
+	rc.Config.Workdir = t.TempDir()
+	ctx := context.Background()
+
+	assert.NoError(t, rc.hostHook("post-run", "")(ctx))
+	assert.NoError(t, rc.hostHook("post-run", `echo "$GITHUB_JOB $ACT_JOB_RESULT" > hook.txt`)(ctx))
+	content, err := os.ReadFile(filepath.Join(rc.Config.Workdir, "hook.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "job1 success\n", string(content))
+
+	rc.Config.PreRun = "exit 3"
+	err = rc.preRunHook()(ctx)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "pre-run hook 'exit 3' failed")
+	assert.Equal(t, "failure", rc.Run.Job().Result)
+}
+
+func TestRunContextContainerWorkdir(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Config.Workdir = "/home/user/project"
+	rc.Config.ContainerWorkdir = "/github/workspace"
+
+	assert.Equal(t, "/github/workspace", rc.ContainerWorkdir())
+	assert.Equal(t, "/github/workspace", rc.getGithubContext().Workspace)
+	assert.Equal(t, "/github/workspace", rc.withGithubEnv(map[string]string{})["GITHUB_WORKSPACE"])
+
+	_, mounts := rc.GetBindsAndMounts()
+	assert.Equal(t, "/github/workspace", mounts[rc.jobContainerName()])
+
+	rc.Config.BindWorkdir = true
+	binds, _ := rc.GetBindsAndMounts()
+	assert.Contains(t, strings.Join(binds, ","), "/home/user/project:/github/workspace")
+
+	rc.Local = true
+	assert.Equal(t, "/home/user/project", rc.ContainerWorkdir())
+}
+
+func TestRunContextBindWorkdirSubpath(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Config.Workdir = "/home/user/monorepo"
+	rc.Config.BindWorkdir = true
+	rc.Config.BindWorkdirSubpath = "services/api"
+
+	binds, _ := rc.GetBindsAndMounts()
+	assert.Contains(t, strings.Join(binds, ","), "/home/user/monorepo/services/api:/home/user/monorepo/services/api")
+	assert.Equal(t, "/home/user/monorepo/services/api", rc.withGithubEnv(map[string]string{})["GITHUB_WORKSPACE"])
+
+	rc.Config.ContainerWorkdir = "/github/workspace"
+	binds, _ = rc.GetBindsAndMounts()
+	assert.Contains(t, strings.Join(binds, ","), "/home/user/monorepo/services/api:/github/workspace")
+	assert.Equal(t, "/github/workspace", rc.withGithubEnv(map[string]string{})["GITHUB_WORKSPACE"])
+
+	rc.Config.BindWorkdir = false
+	assert.Equal(t, "/home/user/monorepo", rc.workspaceDir(), "the subpath only applies to a bound workdir")
+}
+
+func TestRunContextRemoteDockerHostBinds(t *testing.T) {
+	rc := &RunContext{
+		Name: "TestRCName",
+		Run: &model.Run{
+			Workflow: &model.Workflow{
+				Name: "TestWorkflowName",
+			},
+		},
+		Config: &Config{
+			Workdir:    "/mnt/linux",
+			DockerHost: "unix:///var/run/docker.sock",
+		},
+	}
+	binds, _ := rc.GetBindsAndMounts()
+	assert.Contains(t, binds, "/var/run/docker.sock:/var/run/docker.sock")
+
+	rc.Config.DockerHost = "tcp://docker:2376"
+	binds, _ = rc.GetBindsAndMounts()
+	assert.NotContains(t, binds, "/var/run/docker.sock:/var/run/docker.sock")
+}
+
+func TestRunContextDisabledDaemonSocketBind(t *testing.T) {
+	rc := &RunContext{
+		Name: "TestRCName",
+		Run: &model.Run{
+			Workflow: &model.Workflow{
+				Name: "TestWorkflowName",
+			},
+		},
+		Config: &Config{
+			Workdir:               "/mnt/linux",
+			ContainerDaemonSocket: "-",
+		},
+	}
+	binds, _ := rc.GetBindsAndMounts()
+	for _, bind := range binds {
+		assert.NotContains(t, bind, "docker.sock")
+	}
+}
+
+func TestRunContextContainerCapAdd(t *testing.T) {
+	rc := &RunContext{
+		Config: &Config{
+			ContainerCapAdd:     []string{"sys_ptrace"},
+			ContainerCapPresets: []string{"debug", "network"},
+		},
+	}
+	assert.Equal(t, []string{"sys_ptrace", "NET_ADMIN", "NET_RAW"}, rc.containerCapAdd())
+
+	rc.Config.ContainerCapPresets = []string{"unknown"}
+	assert.Equal(t, []string{"sys_ptrace"}, rc.containerCapAdd())
+}
+
+func TestRunContextApplyEnvFile(t *testing.T) {
+	fake := containertest.New()
+	fake.EnvFiles["/var/run/act/workflow/envs.txt"] = map[string]string{"FOO": "bar"}
+	rc := &RunContext{
+		Env:          map[string]string{"FOO": "foo", "KEEP": "me"},
+		JobContainer: fake,
+	}
+
+	assert.NoError(t, rc.applyEnvFile("/var/run/act/workflow/envs.txt")(context.Background()))
+	assert.Equal(t, map[string]string{"FOO": "bar", "KEEP": "me"}, rc.Env)
+	assert.Equal(t, []byte{}, fake.Files["/var/run/act/workflow/envs.txt"])
+	assert.Equal(t, []string{"UpdateFromEnv", "WriteFile"}, fake.Calls)
+}
+
+func TestRunContextOnTimeout(t *testing.T) {
+	newRunContext := func(action string) (*RunContext, *containertest.FakeContainer) {
+		fake := containertest.New()
+		rc := &RunContext{
+			Name:   "job",
+			Config: &Config{JobTimeoutAction: action},
+			Run: &model.Run{
+				JobID: "job",
+				Workflow: &model.Workflow{
+					Name: "workflow",
+					Jobs: map[string]*model.Job{"job": {}},
+				},
+			},
+			JobContainer: fake,
+		}
+		rc.jobOutputTail().add("still running\n")
+		return rc, fake
+	}
+
+	rc, fake := newRunContext(JobTimeoutActionNone)
+	assert.NoError(t, rc.onTimeout()(context.Background()))
+	assert.Empty(t, fake.Calls)
+
+	rc, fake = newRunContext(JobTimeoutActionSnapshot)
+	assert.NoError(t, rc.onTimeout()(context.Background()))
+	assert.Len(t, fake.Commits, 1)
+	assert.Contains(t, fake.Commits[0], ":timeout-")
+
+	timeoutShellInput = strings.NewReader("\n")
+	defer func() { timeoutShellInput = os.Stdin }()
+	rc, fake = newRunContext(JobTimeoutActionShell)
+	assert.NoError(t, rc.onTimeout()(context.Background()))
+	assert.Empty(t, fake.Commits)
+}
+
+func TestDefaultStepID(t *testing.T) {
+	steps := []*model.Step{{}, {ID: "0"}, {ID: "_0"}, {}}
+	explicitIDs := stepIDs(steps)
+
+	assert.Equal(t, "__0", defaultStepID(0, explicitIDs))
+	assert.Equal(t, "3", defaultStepID(3, explicitIDs))
+}
+
+func TestRunContextCopyExcludes(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Config.CopyExcludes = []string{"node_modules"}
+	assert.Equal(t, []string{"node_modules"}, rc.copyExcludes())
+
+	rc.Config.SkipGitDir = true
+	assert.Equal(t, []string{"node_modules", ".git"}, rc.copyExcludes())
+	assert.Equal(t, []string{"node_modules"}, rc.Config.CopyExcludes)
+}
+
+func TestRunContextReusableWorkflowSecrets(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `uses: ./.github/workflows/deploy.yml
+secrets: inherit`, ""),
+	})
+	rc.Config.Secrets = map[string]string{"DEPLOY_TOKEN": "token", "OTHER": "other"}
+	assert.Equal(t, map[string]string{"DEPLOY_TOKEN": "token", "OTHER": "other"}, rc.ReusableWorkflowSecrets())
+
+	rc = createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `uses: ./.github/workflows/deploy.yml
+secrets:
+  token: ${{ secrets.DEPLOY_TOKEN }}`, ""),
+	})
+	rc.Config.Secrets = map[string]string{"DEPLOY_TOKEN": "token", "OTHER": "other"}
+	assert.Equal(t, map[string]string{"token": "token"}, rc.ReusableWorkflowSecrets())
+}
+
+func TestRunContextHandleCredentialsFromSecrets(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest
+container: registry.example.com/private/image:latest`, ""),
+	})
+	rc.Config.Secrets = map[string]string{"DOCKER_USERNAME": "user", "DOCKER_PASSWORD": "pass"}
+
+	username, password, err := rc.handleCredentials()
+	assert.NoError(t, err)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+}
+
+func TestRunContextInvalidContainerArchitecture(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Config.ContainerArchitecture = "linux/x86"
+
+	err := rc.startJobContainer()(context.Background())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid container architecture 'linux/x86'")
+	}
+	assert.Nil(t, rc.JobContainer)
+}
+
+func TestRunContextJobHooks(t *testing.T) {
+	fake := containertest.New()
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.JobContainer = fake
+	rc.Env["FOO"] = "bar"
+
+	ctx := common.WithJobErrorContainer(context.Background())
+	assert.NoError(t, rc.preJobHook()(ctx))
+	assert.Empty(t, fake.ExecCalls)
+
+	rc.Config.JobPreHook = "./pre.sh"
+	rc.Config.JobPostHook = "./post.sh"
+	assert.NoError(t, rc.preJobHook()(ctx))
+	common.SetJobError(ctx, fmt.Errorf("step failed"))
+	assert.NoError(t, rc.postJobHook()(ctx))
+
+	assert.Equal(t, []string{"sh -c ./pre.sh", "sh -c ./post.sh"}, fake.ExecCommands())
+	assert.Equal(t, "bar", fake.ExecCalls[0].Env["FOO"])
+	assert.Equal(t, "success", fake.ExecCalls[0].Env["ACT_JOB_RESULT"])
+	assert.Equal(t, "failure", fake.ExecCalls[1].Env["ACT_JOB_RESULT"])
+
+	fake.ExecHandler = func(call containertest.ExecCall) error {
+		return fmt.Errorf("exit code 1")
+	}
+	err := rc.preJobHook()(ctx)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "pre-job hook './pre.sh' failed")
+	}
+}
+
+func TestRunContextActorAndRepositoryOwner(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Config.Env = map[string]string{"GITHUB_REPOSITORY_OWNER": "env-owner"}
+	rc.Config.Actor = "octocat"
+
+	env := rc.withGithubEnv(map[string]string{})
+	assert.Equal(t, "octocat", env["GITHUB_ACTOR"])
+	assert.Equal(t, "env-owner", env["GITHUB_REPOSITORY_OWNER"])
+
+	rc.Config.RepositoryOwner = "my-org"
+	env = rc.withGithubEnv(map[string]string{})
+	assert.Equal(t, "my-org", env["GITHUB_REPOSITORY_OWNER"])
+	assert.Equal(t, "my-org", rc.getGithubContext().RepositoryOwner)
+}
+
+func TestEventRepository(t *testing.T) {
+	repo := eventRepository(nil, "nektos/act", "main")
+	assert.Equal(t, map[string]interface{}{
+		"name":           "act",
+		"full_name":      "nektos/act",
+		"owner":          map[string]interface{}{"login": "nektos", "name": "nektos"},
+		"default_branch": "main",
+	}, repo)
+
+	repo = eventRepository(map[string]interface{}{"default_branch": "develop"}, "nektos/act", "")
+	assert.Equal(t, "develop", repo["default_branch"], "the default branch set from --defaultbranch is kept")
+
+	repo = eventRepository(nil, "nektos/act", "")
+	assert.NotContains(t, repo, "default_branch", "an unknown default branch isn't made up")
+}
+
+func TestRunContextEventRepositoryDefaultBranch(t *testing.T) {
+	workdir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(workdir, ".git"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, ".git", "config"), []byte("[remote \"origin\"]\n\turl = https://github.com/nektos/act.git\n"), 0644))
+
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Config.Workdir = workdir
+	rc.Config.EventName = "push"
+	rc.EventJSON = `{"ref": "refs/heads/feature"}`
+
+	repo, ok := rc.getGithubContext().Event["repository"].(map[string]interface{})
+	if assert.True(t, ok) {
+		assert.Equal(t, "nektos/act", repo["full_name"])
+		assert.NotContains(t, repo, "default_branch", "the repository has no origin/HEAD")
+	}
+
+	rc.Config.DefaultBranch = "main"
+	repo, ok = rc.getGithubContext().Event["repository"].(map[string]interface{})
+	if assert.True(t, ok) {
+		assert.Equal(t, "main", repo["default_branch"])
+	}
+}
+
+func TestRunContextLogStepOutputs(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.CurrentStep = "build"
+	rc.StepResults = map[string]*model.StepResult{
+		"build": {Outputs: map[string]string{"version": "1.2.3", "artifact": "act.tgz"}},
+	}
+	logger, hook := test.NewNullLogger()
+	rc.logStepOutputs(common.WithLogger(context.Background(), logger))
+
+	messages := []string{}
+	for _, entry := range hook.AllEntries() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{
+		"  \U0001F4E4  Outputs of build",
+		"      artifact=act.tgz",
+		"      version=1.2.3",
+	}, messages)
+}
+
+func TestRunContextQuietOutput(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Config.LogOutput = false
+	rc.Config.Quiet = true
+	rc.CurrentStep = "build"
+	rc.StepResults = map[string]*model.StepResult{
+		"build": {Outputs: map[string]string{}},
+	}
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(log.DebugLevel)
+
+	w := rc.newOutputWriter(common.WithLogger(context.Background(), logger))
+	_, err := w.Write([]byte("compiling\n::set-output name=version::1.2.3\n"))
+	assert.NoError(t, err)
+
+	for _, entry := range hook.AllEntries() {
+		assert.NotEqual(t, true, entry.Data["raw_output"], entry.Message)
+	}
+	assert.Equal(t, "1.2.3", rc.StepResults["build"].Outputs["version"])
+}
+
+// platformImage returns the image of the job of rc, failing the test if it can't be determined
+func platformImage(t *testing.T, rc *RunContext) string {
+	image, err := rc.platformImage()
+	assert.NoError(t, err)
+	return image
+}
+
+func TestRunContextPlatformImageDockerfile(t *testing.T) {
+	dir := t.TempDir()
+	dockerfile := filepath.Join(dir, "ci", "Dockerfile")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(dockerfile), 0755))
+	assert.NoError(t, os.WriteFile(dockerfile, []byte("FROM ubuntu:22.04\n"), 0600))
+
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Config.Workdir = dir
+	rc.Config.Platforms["ubuntu-latest"] = "Dockerfile:ci/Dockerfile"
+
+	assert.Equal(t, dockerfile, rc.platformDockerfile(rc.mappedPlatformImage()))
+	image := platformImage(t, rc)
+	assert.Regexp(t, `^act-platform:[0-9a-f]{16}$`, image)
+	assert.Equal(t, image, platformImage(t, rc))
+
+	assert.NoError(t, os.WriteFile(dockerfile, []byte("FROM ubuntu:20.04\n"), 0600))
+	assert.NotEqual(t, image, platformImage(t, rc))
+
+	rc.Config.Platforms["ubuntu-latest"] = "Dockerfile:" + filepath.Join(dir, "missing")
+	_, err := rc.platformImage()
+	assert.Error(t, err)
+	// the job isn't skipped as unsupported platform, it fails when its container is started
+	assert.True(t, rc.isEnabled(context.Background()))
+	assert.Error(t, rc.startJobContainer()(context.Background()))
+
+	rc.Config.Platforms["ubuntu-latest"] = "node:16-buster-slim"
+	assert.Equal(t, "", rc.platformDockerfile(rc.mappedPlatformImage()))
+	assert.Equal(t, "node:16-buster-slim", platformImage(t, rc))
+}
+
+func TestRunContextContainerBuildArgs(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Config.Secrets = map[string]string{"NPM_TOKEN": "s3cr3t"}
+	rc.Config.ContainerBuildArgs = map[string]string{
+		"NPM_TOKEN": "${{ secrets.NPM_TOKEN }}",
+		"VERSION":   "1.2.3",
+	}
+	rc.ExprEval = rc.NewExpressionEvaluator()
+
+	assert.Equal(t, map[string]string{
+		"NPM_TOKEN": "s3cr3t",
+		"VERSION":   "1.2.3",
+	}, rc.containerBuildArgs())
+}
+
+func TestRunContextPlatformImageMatrixRunsOn(t *testing.T) {
+	job := createJob(t, `runs-on: ${{ matrix.runner }}
+strategy:
+  matrix:
+    runner:
+      - ubuntu-latest
+      - windows-latest
+      - [self-hosted, linux]
+`, "")
+	runner := &runnerImpl{config: &Config{
+		Workdir: ".",
+		Platforms: map[string]string{
+			"ubuntu-latest":  "node:16-buster-slim",
+			"windows-latest": "-self-hosted",
+			"linux":          "catthehacker/ubuntu:act-latest",
+		},
+	}}
+	run := &model.Run{
+		JobID: "job1",
+		Workflow: &model.Workflow{
+			Name: "test-workflow",
+			Jobs: map[string]*model.Job{"job1": job},
+		},
+	}
+
+	var images []string
+	for _, matrix := range job.GetMatrixes() {
+		rc := runner.newRunContext(run, matrix)
+		images = append(images, platformImage(t, rc))
+	}
+	assert.Equal(t, []string{"node:16-buster-slim", "-self-hosted", "catthehacker/ubuntu:act-latest"}, images)
+}
+
+func TestRunContextIsEnabledEmptyMatrixRunsOn(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		matrix map[string]interface{}
+		msg    string
+	}{
+		{"empty string", map[string]interface{}{"os": ""}, "evaluated to an empty value"},
+		{"empty list", map[string]interface{}{"os": []interface{}{}}, "evaluated to an empty value"},
+		{"missing", map[string]interface{}{}, "evaluated to an empty value"},
+		{"unmapped", map[string]interface{}{"os": "macos-latest"}, "Skipping unsupported platform"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := createIfTestRunContext(map[string]*model.Job{
+				"job1": createJob(t, `runs-on: ${{ matrix.os }}`, ""),
+			})
+			rc.Matrix = tt.matrix
+			rc.ExprEval = rc.NewExpressionEvaluator()
+
+			logger, hook := test.NewNullLogger()
+			assert.False(t, rc.isEnabled(common.WithLogger(context.Background(), logger)))
+			assert.Contains(t, hook.LastEntry().Message, tt.msg)
+		})
+	}
+}
+
+func TestRunContextStrategyJobIndex(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.ExprEval = rc.NewExpressionEvaluator()
+	assert.Equal(t, "0/1", rc.ExprEval.Interpolate("${{ strategy.job-index }}/${{ strategy.job-total }}"))
+
+	rc.jobIndex = 2
+	rc.jobTotal = 4
+	rc.ExprEval = rc.NewExpressionEvaluator()
+	assert.Equal(t, "2/4", rc.ExprEval.Interpolate("${{ strategy.job-index }}/${{ strategy.job-total }}"))
+}
+
+func TestRunContextDefaultImage(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: [self-hosted, gpu]`, ""),
+	})
+	rc.Config.DefaultImage = "node:16-buster-slim"
+
+	logger, hook := test.NewNullLogger()
+	assert.True(t, rc.isEnabled(common.WithLogger(context.Background(), logger)))
+	assert.Equal(t, "node:16-buster-slim", platformImage(t, rc))
+	assert.Contains(t, hook.LastEntry().Message, "using the default image node:16-buster-slim")
+
+	// a mapped label takes precedence over the default
+	rc.Config.Platforms["gpu"] = "nvidia/cuda"
+	hook.Reset()
+	assert.True(t, rc.isEnabled(common.WithLogger(context.Background(), logger)))
+	assert.Equal(t, "nvidia/cuda", platformImage(t, rc))
+	assert.Nil(t, hook.LastEntry())
+}
+
+func TestRunContextGithubContextBaseMerge(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Config.Actor = "octocat"
+	rc.Config.EventName = "push"
+	rc.EventJSON = `{"ref": "refs/heads/main", "deleted": false, "after": "abc123", "head_commit": {"message": "fix"}}`
+	base := `{"actor": "base-actor", "run_id": "42", "event": {"ref": "refs/heads/release"}}`
+	rc.GithubContextBase = &base
+
+	ghc := rc.getGithubContext()
+	// set by the base
+	assert.Equal(t, "base-actor", ghc.Actor)
+	assert.Equal(t, "42", ghc.RunID)
+	assert.Equal(t, "refs/heads/release", ghc.Event["ref"])
+	// computed
+	assert.Equal(t, "1", ghc.RunNumber)
+	assert.Equal(t, "test-workflow", ghc.Workflow)
+	assert.Equal(t, "push", ghc.EventName)
+	assert.Equal(t, "refs/heads/release", ghc.Ref)
+	assert.Equal(t, "abc123", ghc.Sha)
+	assert.Equal(t, map[string]interface{}{"message": "fix"}, ghc.Event["head_commit"])
+
+	invalid := `{`
+	rc.GithubContextBase = &invalid
+	assert.Equal(t, "octocat", rc.getGithubContext().Actor)
+}
+
+func TestRunContextHostnameInterpolation(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest
+container:
+  image: node:16-buster-slim
+  options: --hostname ${{ matrix.host }}-${{ env.DOMAIN }} --memory 1g`, ""),
+	})
+	rc.Matrix = map[string]interface{}{"host": "web"}
+	rc.Env["DOMAIN"] = "example"
+
+	assert.Equal(t, "web-example", rc.hostname())
+
+	rc.Matrix = map[string]interface{}{"host": "db"}
+	assert.Equal(t, "db-example", rc.hostname())
+}
+
+func TestRunContextJobContainerNameTemplate(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Name = "job1"
+	rc.JobName = "job1"
+	assert.Equal(t, "act-test-workflow-job1", rc.jobContainerName())
+
+	rc.Config.Env = map[string]string{"GITHUB_RUN_ID": "42"}
+	rc.Config.JobContainerName = "act-${{ github.run_id }}-${{ github.job }}-${{ matrix.os }}"
+	rc.Matrix = map[string]interface{}{"os": "ubuntu"}
+	name, err := rc.templateJobContainerName()
+	assert.NoError(t, err)
+	assert.Equal(t, "act-42-job1-ubuntu", name)
+	assert.Equal(t, name, rc.jobContainerName())
+
+	rc.Matrix = map[string]interface{}{"os": "ubuntu 20.04"}
+	_, err = rc.templateJobContainerName()
+	assert.Error(t, err)
+	assert.Equal(t, "act-test-workflow-job1", rc.jobContainerName())
+
+	// the jobs of a matrix get unique names even if the template doesn't tell them apart
+	rc.jobIndex, rc.jobTotal = 1, 4
+	rc.Matrix = map[string]interface{}{"os": "ubuntu", "node": 16}
+	assert.Equal(t, "act-42-job1-ubuntu-2", rc.jobContainerName())
+	rc.Config.JobContainerName = "act-${{ matrix.node }}-${{ matrix.OS }}"
+	assert.Equal(t, "act-16-ubuntu", rc.jobContainerName())
+	rc.Config.JobContainerName = "act-${{ github.job }}-${{ strategy.job-index }}"
+	assert.Equal(t, "act-job1-1", rc.jobContainerName())
+}
+
+func TestRunContextContainerUser(t *testing.T) {
+	fake := containertest.New()
+	container.RegisterContainerBackend("container-user-test", fake.Factory())
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Name = "job1"
+	rc.Config.ContainerBackend = "container-user-test"
+	rc.Config.ContainerWorkdir = "/workspace"
+	rc.Config.ContainerUser = "1000:1000"
+
+	assert.NoError(t, rc.startJobContainer()(context.Background()))
+	assert.Equal(t, "1000:1000", fake.Input.User)
+	assert.Equal(t, []string{
+		"mkdir -p /workspace",
+		"chown -R 1000:1000 /var/run/act /workspace",
+	}, fake.ExecCommands())
+	for _, call := range fake.ExecCalls {
+		assert.Equal(t, "root", call.User)
+	}
+
+	fake = containertest.New()
+	container.RegisterContainerBackend("container-user-test", fake.Factory())
+	rc.Config.ContainerUser = ""
+	rc.Config.BindWorkdir = true
+	assert.NoError(t, rc.startJobContainer()(context.Background()))
+	assert.Empty(t, fake.Input.User)
+	assert.Empty(t, fake.ExecCalls)
+}
+
+func TestNewInvalidContainerUser(t *testing.T) {
+	_, err := New(&Config{ContainerUser: "1000:1000"})
+	assert.NoError(t, err)
+
+	_, err = New(&Config{ContainerUser: "1000 1000"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid container user '1000 1000'")
+	}
+}
+
+func TestRunContextPullRequestRefs(t *testing.T) {
+	defer func() { findGitRef = common.FindGitRef }()
+	findGitRef = func(file string) (string, error) {
+		return "refs/heads/feature", nil
+	}
+
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Config.EventName = "pull_request"
+	rc.Config.DefaultBranch = "main"
+
+	ghc := rc.getGithubContext()
+	assert.Equal(t, "main", ghc.BaseRef)
+	assert.Equal(t, "feature", ghc.HeadRef)
+	env := rc.withGithubEnv(map[string]string{})
+	assert.Equal(t, "main", env["GITHUB_BASE_REF"])
+	assert.Equal(t, "feature", env["GITHUB_HEAD_REF"])
+
+	rc.EventJSON = `{"pull_request": {"base": {"ref": "release"}, "head": {"ref": "fix"}}}`
+	ghc = rc.getGithubContext()
+	assert.Equal(t, "release", ghc.BaseRef)
+	assert.Equal(t, "fix", ghc.HeadRef)
+
+	findGitRef = func(file string) (string, error) {
+		return "refs/tags/v1", nil
+	}
+	rc.EventJSON = ""
+	ghc = rc.getGithubContext()
+	assert.Empty(t, ghc.HeadRef)
+}
+
+func TestRunContextResetRunState(t *testing.T) {
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest
+env:
+  JOB_ENV: job`, ""),
+	})
+	rc.StepResults = map[string]*model.StepResult{"stale": {Outputs: map[string]string{"value": "stale"}}}
+	rc.Env["STALE"] = "stale"
+	rc.ExtraPath = []string{"/stale/bin"}
+	rc.CurrentStep = "stale"
+
+	assert.NoError(t, rc.resetRunState()(context.Background()))
+	assert.Empty(t, rc.StepResults)
+	assert.Empty(t, rc.ExtraPath)
+	assert.Empty(t, rc.CurrentStep)
+	assert.NotContains(t, rc.Env, "STALE")
+	assert.Equal(t, "job", rc.Env["JOB_ENV"])
+	assert.Equal(t, "", rc.ExprEval.Interpolate("${{ env.STALE }}"))
+}
+
+func TestRunContextContinueOnErrorMatrix(t *testing.T) {
+	fake := containertest.New()
+	container.RegisterContainerBackend("continue-on-error-test", fake.Factory())
+	fake.ExecHandler = func(call containertest.ExecCall) error {
+		return fmt.Errorf("exit code 1")
+	}
+	fake.EnvFiles["/var/run/act/workflow/outputcmd.txt"] = map[string]string{"value": "partial"}
+
+	for _, tt := range []struct {
+		continueOnError string
+		matrix          map[string]interface{}
+		result          string
+	}{
+		{"${{ matrix.experimental }}", map[string]interface{}{"experimental": true}, "success"},
+		{"${{ matrix.experimental }}", map[string]interface{}{"experimental": false}, "failure"},
+		{"${{ fromJSON(matrix.experimental) }}", map[string]interface{}{"experimental": "{"}, "failure"},
+	} {
+		rc := createIfTestRunContext(map[string]*model.Job{
+			"job1": createJob(t, fmt.Sprintf(`runs-on: ubuntu-latest
+steps:
+  - id: flaky
+    run: exit 1
+    continue-on-error: %s`, tt.continueOnError), ""),
+		})
+		rc.Config.ContainerBackend = "continue-on-error-test"
+		rc.Matrix = tt.matrix
+
+		assert.NoError(t, rc.Executor()(common.WithJobErrorContainer(context.Background())))
+		assert.Equal(t, tt.result, rc.Run.Job().Result, "%s with %v", tt.continueOnError, tt.matrix)
+		if assert.Contains(t, rc.StepResults, "flaky") {
+			assert.Equal(t, model.StepStatusFailure, rc.StepResults["flaky"].Outcome)
+			assert.Equal(t, "partial", rc.StepResults["flaky"].Outputs["value"], "the outputs of the failed step are processed")
+		}
+	}
+}
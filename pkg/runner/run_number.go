@@ -0,0 +1,117 @@
+package runner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ankit-arora/act/pkg/common"
+	"github.com/ankit-arora/act/pkg/model"
+)
+
+var runNumberNameRegex = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// runNumbers hands out the run number of the workflows of a run, it is incremented once per workflow
+// and invocation of act in a counter file under dir, keyed by the repository and the workflow file
+type runNumbers struct {
+	mu      sync.Mutex
+	dir     string
+	numbers map[*model.Workflow]string
+}
+
+func newRunNumbers(dir string) *runNumbers {
+	return &runNumbers{
+		dir:     dir,
+		numbers: map[*model.Workflow]string{},
+	}
+}
+
+// get returns the run number of the workflow, the counter is only incremented by the first call
+func (r *runNumbers) get(config *Config, workflow *model.Workflow) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if number, ok := r.numbers[workflow]; ok {
+		return number, nil
+	}
+
+	repo, err := common.FindGithubRepo(config.Workdir, config.GitHubInstance)
+	if err != nil {
+		if repo, err = filepath.Abs(config.Workdir); err != nil {
+			return "", err
+		}
+	}
+	number, err := incrementRunNumber(filepath.Join(r.dir, runNumberNameRegex.ReplaceAllString(repo+"_"+workflow.File, "_")))
+	if err != nil {
+		return "", err
+	}
+	r.numbers[workflow] = strconv.Itoa(number)
+	return r.numbers[workflow], nil
+}
+
+// lookup returns the run number get returned for the workflow, it is empty if the counter wasn't incremented yet
+func (r *runNumbers) lookup(workflow *model.Workflow) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.numbers[workflow]
+}
+
+// runNumberLockTimeout is how long incrementRunNumber waits for another process updating the same counter
+var runNumberLockTimeout = 10 * time.Second
+
+// incrementRunNumber increments the counter in counterFile and returns its new value, a missing file starts at 1.
+// The counter is locked while it is updated, so processes started at the same time get different numbers
+func incrementRunNumber(counterFile string) (int, error) {
+	if err := os.MkdirAll(filepath.Dir(counterFile), 0755); err != nil {
+		return 0, err
+	}
+	unlock, err := lockRunNumber(counterFile)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	number := 0
+	content, err := ioutil.ReadFile(counterFile)
+	if err == nil {
+		if number, err = strconv.Atoi(strings.TrimSpace(string(content))); err != nil {
+			return 0, fmt.Errorf("invalid run number in %s: %w", counterFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+	number++
+
+	if err := ioutil.WriteFile(counterFile, []byte(strconv.Itoa(number)+"\n"), 0644); err != nil {
+		return 0, err
+	}
+	return number, nil
+}
+
+// lockRunNumber holds the lock file of the counter until the returned function is called, the lock file of a
+// process that was killed while holding it has to be removed by hand
+func lockRunNumber(counterFile string) (func(), error) {
+	lockFile := counterFile + ".lock"
+	deadline := time.Now().Add(runNumberLockTimeout)
+	for {
+		f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() {
+				_ = os.Remove(lockFile)
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s, remove it if no other act process is running", lockFile)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
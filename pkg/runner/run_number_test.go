@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ankit-arora/act/pkg/model"
+)
+
+func TestIncrementRunNumber(t *testing.T) {
+	counterFile := filepath.Join(t.TempDir(), "counters", "repo_workflow")
+
+	number, err := incrementRunNumber(counterFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, number)
+
+	number, err = incrementRunNumber(counterFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, number)
+
+	assert.NoError(t, ioutil.WriteFile(counterFile, []byte("garbage"), 0644))
+	_, err = incrementRunNumber(counterFile)
+	assert.Error(t, err)
+}
+
+func TestIncrementRunNumberLock(t *testing.T) {
+	counterFile := filepath.Join(t.TempDir(), "repo_workflow")
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	numbers := map[int]bool{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			number, err := incrementRunNumber(counterFile)
+			assert.NoError(t, err)
+			mu.Lock()
+			numbers[number] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, numbers, 10, "every update got its own number")
+	assert.NoFileExists(t, counterFile+".lock")
+
+	defer func(timeout time.Duration) { runNumberLockTimeout = timeout }(runNumberLockTimeout)
+	runNumberLockTimeout = 20 * time.Millisecond
+	assert.NoError(t, ioutil.WriteFile(counterFile+".lock", nil, 0644))
+	_, err := incrementRunNumber(counterFile)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "timed out waiting for")
+	}
+}
+
+func TestRunNumbers(t *testing.T) {
+	config := &Config{Workdir: t.TempDir()}
+	build := &model.Workflow{File: "build.yml"}
+	deploy := &model.Workflow{File: "deploy.yml"}
+	dir := t.TempDir()
+
+	numbers := newRunNumbers(dir)
+	assert.Empty(t, numbers.lookup(build))
+	for _, expected := range []string{"1", "1"} {
+		number, err := numbers.get(config, build)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, number, "the counter is incremented once per invocation")
+	}
+	assert.Equal(t, "1", numbers.lookup(build))
+	number, err := numbers.get(config, deploy)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", number, "every workflow has its own counter")
+
+	number, err = newRunNumbers(dir).get(config, build)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", number)
+
+	rc := &RunContext{
+		Config:    &Config{Env: map[string]string{}},
+		Run:       &model.Run{Workflow: build},
+		runNumber: number,
+	}
+	ghc := rc.getGithubContext()
+	assert.Equal(t, "2", ghc.RunID)
+	assert.Equal(t, "2", ghc.RunNumber)
+
+	rc.Config.Env["GITHUB_RUN_NUMBER"] = "42"
+	assert.Equal(t, "42", rc.getGithubContext().RunNumber)
+}
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/ankit-arora/act/pkg/common"
 	"github.com/ankit-arora/act/pkg/container"
@@ -18,42 +19,104 @@ import (
 // Runner provides capabilities to run GitHub actions
 type Runner interface {
 	NewPlanExecutor(plan *model.Plan) common.Executor
+	PlanSteps(plan *model.Plan) []PlannedJob
 }
 
 // Config contains the config for a new runner
 type Config struct {
 	Actor                     string                       // the user that triggered the event
+	RepositoryOwner           string                       // owner of the repository, overrides GITHUB_REPOSITORY_OWNER and the owner of the git remote
 	Workdir                   string                       // path to working directory
+	WorkflowsPath             string                       // path to the workflow file(s), independent of Workdir, empty uses the .github/workflows directory of Workdir
+	ContainerWorkdir          string                       // path of the workspace inside the containers, defaults to the equivalent of Workdir
 	BindWorkdir               bool                         // bind the workdir to the job container
+	BindWorkdirSubpath        string                       // subdirectory of the workdir bound as the workspace instead of the workdir, relative to Workdir
 	EventName                 string                       // name of event to run
 	EventPath                 string                       // path to JSON file to use for event.json in containers
+	Inputs                    map[string]string            // inputs of the workflow_dispatch event, they take precedence over the ones of InputsFile
+	InputsFile                string                       // path to a JSON file of inputs of the workflow_dispatch event, converted to the types the workflow declares
 	DefaultBranch             string                       // name of the main branch for this repository
 	ReuseContainers           bool                         // reuse containers to maintain state
+	ContainerPoolSize         int                          // number of warm job containers kept alive per image between runs, they are reset instead of recreated
+	JobContainerName          string                       // template of the job container names, e.g. act-${{ github.run_id }}-${{ github.job }}-${{ matrix.os }}, empty uses act-<workflow>-<job>, matrix jobs the template doesn't tell apart get their index appended
 	ForcePull                 bool                         // force pulling of the image, even if already present
+	PullPolicies              map[string]bool              // per image override of ForcePull, keyed by image reference
 	ForceRebuild              bool                         // force rebuilding local docker image action
+	ContainerBuildArgs        map[string]string            // build args of the images of docker actions and Dockerfile platforms, the values are interpolated so they can reference secrets
 	LogOutput                 bool                         // log the output from docker run
+	LogPrefixJobName          bool                         // prefix the output of the steps with the job name in colored terminals too, to tell apart parallel matrix jobs
+	Quiet                     bool                         // drop the output of the steps and hooks instead of logging it, even at debug level, workflow commands are still processed
+	PrintStepOutputs          bool                         // log the outputs of every step once it completed, to debug empty steps.<id>.outputs references
+	MaxLogLineLength          int                          // lines of output longer than this are truncated, 0 doesn't limit them
+	LogBufferInterval         time.Duration                // lines of output written within this interval are logged together, 0 logs every line on its own
 	Env                       map[string]string            // env for containers
 	Secrets                   map[string]string            // list of secrets
 	InsecureSecrets           bool                         // switch hiding output when printing to terminal
+	StrictSecrets             bool                         // fail a job before it starts if its expressions reference secrets or env variables that aren't provided
+	Vars                      map[string]string            // list of configuration variables, unlike secrets they aren't masked
 	Platforms                 map[string]string            // list of platforms
+	DefaultImage              string                       // image of the jobs none of whose runs-on labels are in Platforms, empty skips them
 	Privileged                bool                         // use privileged mode
 	UsernsMode                string                       // user namespace to use
+	ContainerUser             string                       // user[:group] the steps of the job containers run as, e.g. 1000:1000 so files created in a bound workdir belong to the host user, empty uses the user of the image
 	ContainerArchitecture     string                       // Desired OS/architecture platform for running containers
-	ContainerDaemonSocket     string                       // Path to Docker daemon socket
+	StrictPlatform            bool                         // fail if an image isn't available for the requested platform instead of falling back to its native platform
+	DefaultImageArchitecture  string                       // OS/architecture platform used if neither the job nor ContainerArchitecture request one, empty uses the native architecture of the daemon
+	ContainerDaemonSocket     string                       // Path to Docker daemon socket, "-" doesn't mount it into the containers
+	ContainerBackend          string                       // name of the backend creating the containers, registered with container.RegisterContainerBackend, defaults to docker
+	DockerHost                string                       // address of the docker daemon, overrides DOCKER_HOST
 	UseGitIgnore              bool                         // controls if paths in .gitignore should not be copied into container, default true
+	CopyExcludes              []string                     // patterns in .gitignore syntax of paths that aren't copied into the container with the workspace, e.g. .git
+	SkipGitDir                bool                         // don't copy .git with the workspace, the commit is still known from GITHUB_SHA and GITHUB_REF but git commands fail
 	GitHubInstance            string                       // GitHub instance to use, default "github.com"
 	GitHubServerUrl           string                       // GitHub server url to use
 	GitHubApiServerUrl        string                       // GitHub api server url to use
 	GitHubGraphQlApiServerUrl string                       // GitHub graphql server url to use
+	GithubComActions          []string                     // patterns of actions fetched from github.com instead of the GitHub Enterprise Server, e.g. actions/*
+	GithubComActionsToken     string                       // token to fetch GithubComActions with, the GITHUB_TOKEN of the server isn't sent to github.com
 	ContainerCapAdd           []string                     // list of kernel capabilities to add to the containers
+	ContainerCapPresets       []string                     // named sets of kernel capabilities to add to the containers, see container.CapabilityPresets
 	ContainerCapDrop          []string                     // list of kernel capabilities to remove from the containers
+	ContainerInit             bool                         // run an init process in the containers that reaps zombie processes
+	ContainerStopTimeout      time.Duration                // time the containers have to exit after SIGTERM before they are killed on removal, 0 uses the default of 10s
 	AutoRemove                bool                         // controls if the container is automatically removed upon workflow completion
 	ArtifactServerPath        string                       // the path where the artifact server stores uploads
-	ArtifactServerPort        string                       // the port the artifact server binds to
+	ArtifactServerPort        string                       // the port the artifact server is bound to, the actions are told this port
 	CompositeRestrictions     *model.CompositeRestrictions // describes which features are available in composite actions
+	ValidateActions           bool                         // warn about mistakes in the metadata of the actions when they are loaded, see model.Action.Validate
+	SkipCheckout              bool                         // assume the workspace is already present, neither copy it nor run a local actions/checkout
+	RunnerTemp                string                       // path of RUNNER_TEMP in the containers, defaults to /tmp
+	RunnerToolCache           string                       // path of RUNNER_TOOL_CACHE in the containers, defaults to /opt/hostedtoolcache
+	IsolateRunnerDirs         bool                         // mount volumes of the job at RunnerTemp and RunnerToolCache instead of sharing the act-toolcache volume, they are removed with the job container
+	CacheToolInstalls         []string                     // toolchains like node@16 installed into the shared tool cache before the jobs run, see toolInstallActions
+	ActionRunsOn              string                       // runs-on label of the job of a single action, run with --action or to install CacheToolInstalls
+	WorkspaceVolumeName       string                       // name of a volume that holds the workspace across runs if the workdir isn't bound, it isn't removed at the end of a job
+	PreRun                    string                       // command run on the host before each job, the job fails if it fails
+	PostRun                   string                       // command run on the host after each job, even if it failed
+	JobPreHook                string                       // command run in the job container before the first step, the steps are skipped if it fails
+	JobPostHook               string                       // command run in the job container after the last step, even if a step failed
+	RemoteHost                string                       // user@host[:port] self-hosted jobs are run on over ssh instead of on this machine, its key must be in ~/.ssh/known_hosts
+	RemoteIdentityFile        string                       // private key to log in to RemoteHost with, the keys of the ssh-agent are tried as well
+	ExtractWorkspaceTo        string                       // host path the workspace is copied to at the end of a job, if the workdir isn't bound
+	RemoveImagesAfterRun      bool                         // remove the images pulled during the run once it completes, images that already existed are kept
+	CommitOnFailure           bool                         // snapshot the job container into an image if a step failed
+	ExportOnFailure           string                       // host directory the filesystem of the job container is exported to as a tar if a step failed
+	JobTimeoutAction          string                       // what to do with the job container when a job exceeds its timeout-minutes: none, snapshot or shell
+	ConcurrencyLock           ConcurrencyLock              // holds the concurrency groups of the workflows across processes, nil only serializes the jobs of a run, see NewFileConcurrencyLock
+	IncrementRunNumber        bool                         // give every invocation a new GITHUB_RUN_ID and GITHUB_RUN_NUMBER from a counter per repository and workflow in the cache dir, instead of 1
 	ForceRemoteCheckout       bool
 }
 
+// containerUserPattern matches the user[:group] names and ids docker accepts for --user
+var containerUserPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+(:[a-zA-Z0-9_.-]+)?$`)
+
+// Actions run by a job that exceeded its timeout-minutes, before its container is stopped
+const (
+	JobTimeoutActionNone     = "none"     // stop the container
+	JobTimeoutActionSnapshot = "snapshot" // dump the last lines of output and commit the container into an image
+	JobTimeoutActionShell    = "shell"    // dump the last lines of output and wait until the container was inspected
+)
+
 // Resolves the equivalent host path inside the container
 // This is required for windows and WSL 2 to translate things like C:\Users\Myproject to /mnt/users/Myproject
 // For use in docker volumes and binds
@@ -66,6 +129,13 @@ func (rc *RunContext) containerPath(path string) string {
 				return he.Path
 			}
 		}
+		if se, ok := rc.JobContainer.(*container.SSHExecutor); ok {
+			if bp, err := filepath.Rel(rc.Config.Workdir, path); err == nil && bp == "." {
+				return se.Path
+			} else if err == nil && !strings.HasPrefix(bp, "..") {
+				return se.Path + "/" + filepath.ToSlash(bp)
+			}
+		}
 		return path
 	}
 	if runtime.GOOS == "windows" && strings.Contains(path, "/") {
@@ -98,21 +168,52 @@ func (rc *RunContext) containerPath(path string) string {
 	return result
 }
 
-// Resolves the equivalent host path inside the container
-// This is required for windows and WSL 2 to translate things like C:\Users\Myproject to /mnt/users/Myproject
+// ContainerWorkdir returns the path of the workspace inside the container, it is the configured
+// ContainerWorkdir or else the equivalent of the host workspace. Self-hosted jobs ignore ContainerWorkdir
 func (rc *RunContext) ContainerWorkdir() string {
-	return rc.containerPath(rc.Config.Workdir)
+	if rc.Config.ContainerWorkdir != "" && !rc.Local {
+		return rc.Config.ContainerWorkdir
+	}
+	return rc.containerPath(rc.workspaceDir())
+}
+
+// workspaceDir returns the host directory of the workspace, it is the BindWorkdirSubpath of the workdir
+// if the workdir is bound, so the job runs as if the subdirectory was the root of the repository
+func (rc *RunContext) workspaceDir() string {
+	if rc.Config.BindWorkdir && rc.Config.BindWorkdirSubpath != "" {
+		return filepath.Join(rc.Config.Workdir, rc.Config.BindWorkdirSubpath)
+	}
+	return rc.Config.Workdir
 }
 
 type runnerImpl struct {
-	config    *Config
-	eventJSON string
+	config     *Config
+	eventJSON  string
+	inputs     map[string]string
+	runNumbers *runNumbers
+}
+
+// NewWorkflowPlanner returns the planner of the workflows at the WorkflowsPath of the config, the workflows don't have
+// to be below Workdir, e.g. to test generated workflows before they are committed
+func NewWorkflowPlanner(config *Config, noWorkflowRecurse bool) (model.WorkflowPlanner, error) {
+	path := config.WorkflowsPath
+	if path == "" {
+		path = filepath.Join(config.Workdir, ".github", "workflows")
+	}
+	return model.NewWorkflowPlanner(path, noWorkflowRecurse)
 }
 
 // New Creates a new Runner
 func New(runnerConfig *Config) (Runner, error) {
 	runner := &runnerImpl{
-		config: runnerConfig,
+		config:     runnerConfig,
+		runNumbers: newRunNumbers(filepath.Join(actCacheDir(), "run-numbers")),
+	}
+
+	switch runnerConfig.JobTimeoutAction {
+	case "", JobTimeoutActionNone, JobTimeoutActionSnapshot, JobTimeoutActionShell:
+	default:
+		return nil, fmt.Errorf("unknown job timeout action '%s', expected one of none, snapshot or shell", runnerConfig.JobTimeoutAction)
 	}
 
 	runner.eventJSON = "{}"
@@ -124,10 +225,33 @@ func New(runnerConfig *Config) (Runner, error) {
 		}
 		runner.eventJSON = string(eventJSONBytes)
 	}
+
+	if runnerConfig.ContainerUser != "" && !containerUserPattern.MatchString(runnerConfig.ContainerUser) {
+		return nil, fmt.Errorf("invalid container user '%s', expected user[:group], e.g. 1000:1000", runnerConfig.ContainerUser)
+	}
+
+	for _, tool := range runnerConfig.CacheToolInstalls {
+		if _, _, err := parseToolInstall(tool); err != nil {
+			return nil, err
+		}
+	}
+	if len(runnerConfig.CacheToolInstalls) > 0 && runnerConfig.ActionRunsOn == "" {
+		return nil, fmt.Errorf("the runs-on label to install the toolchains to cache on is missing, see ActionRunsOn")
+	}
+
+	inputs, err := MergeInputs(runnerConfig.InputsFile, runnerConfig.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	runner.inputs = inputs
 	return runner, nil
 }
 
 func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
+	if err := runner.validateInputs(plan); err != nil {
+		return common.NewErrorExecutor(err)
+	}
+
 	maxJobNameLen := 0
 	stagePipeline := make([]common.Executor, 0)
 	for i := range plan.Stages {
@@ -157,6 +281,8 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 				b := 0
 				for i, matrix := range matrixes {
 					rc := runner.newRunContext(run, matrix)
+					rc.jobIndex = i
+					rc.jobTotal = len(matrixes)
 					rc.JobName = rc.Name
 					if len(matrixes) > 1 {
 						rc.Name = fmt.Sprintf("%s-%d", rc.Name, i+1)
@@ -166,7 +292,11 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 					}
 					stageExecutor = append(stageExecutor, func(ctx context.Context) error {
 						jobName := fmt.Sprintf("%-*s", maxJobNameLen, rc.String())
-						return rc.Executor().Finally(func(ctx context.Context) error {
+						ctx = WithJobLogger(ctx, jobName, rc.Config.Secrets, rc.Config.InsecureSecrets)
+						if rc.Config.LogPrefixJobName {
+							ctx = WithJobNamePrefix(ctx)
+						}
+						return rc.withConcurrency(rc.Executor()).Finally(func(ctx context.Context) error {
 							isLastRunningContainer := func(currentStage int, currentRun int) bool {
 								return currentStage == len(plan.Stages)-1 && currentRun == len(stage.Runs)-1
 							}
@@ -179,7 +309,7 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 							}
 
 							return nil
-						})(common.WithJobErrorContainer(WithJobLogger(ctx, jobName, rc.Config.Secrets, rc.Config.InsecureSecrets)))
+						})(common.WithJobErrorContainer(ctx))
 					})
 					b++
 					if b == maxParallel {
@@ -193,7 +323,85 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 		})
 	}
 
-	return common.NewPipelineExecutor(stagePipeline...).Then(handleFailure(plan))
+	pipeline := resetJobs(plan).
+		Then(runner.incrementRunNumbers(plan).IfBool(runner.config.IncrementRunNumber && runner.runNumbers != nil)).
+		Then(runner.prewarmToolCache().IfBool(len(runner.config.CacheToolInstalls) > 0)).
+		Then(common.NewPipelineExecutor(stagePipeline...)).
+		Then(handleFailure(plan))
+	return func(ctx context.Context) error {
+		ctx = container.WithDockerHost(ctx, runner.config.DockerHost)
+		ctx = withDeprecatedInputWarnings(ctx)
+		if !runner.config.RemoveImagesAfterRun {
+			return pipeline(ctx)
+		}
+		ctx = container.WithPulledImages(ctx)
+		return pipeline.Finally(removePulledImages)(ctx)
+	}
+}
+
+// incrementRunNumbers increments the run numbers of the workflows of the plan when it is run, so listing or
+// graphing the plan doesn't use up a run number
+func (runner *runnerImpl) incrementRunNumbers(plan *model.Plan) common.Executor {
+	return func(ctx context.Context) error {
+		for _, stage := range plan.Stages {
+			for _, run := range stage.Runs {
+				if _, err := runner.runNumbers.get(runner.config, run.Workflow); err != nil {
+					common.Logger(ctx).Warnf("Unable to increment the run number of %s: %v", run.Workflow.File, err)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// validateInputs checks the inputs of the workflow_dispatch event against the workflows of the plan,
+// so missing or mistyped inputs fail the run before any job is started
+func (runner *runnerImpl) validateInputs(plan *model.Plan) error {
+	if runner.config.EventName != "workflow_dispatch" {
+		return nil
+	}
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			if _, err := workflowDispatchInputs(run.Workflow, runner.inputs); err != nil {
+				return fmt.Errorf("invalid inputs of %s: %w", run.Workflow.File, err)
+			}
+		}
+	}
+	return nil
+}
+
+// removePulledImages removes the images pulled during the run, failures are only logged
+// since images still used by kept containers can't be removed
+func removePulledImages(ctx context.Context) error {
+	for _, image := range container.PulledImages(ctx) {
+		log.Infof("Removing pulled image %s", image)
+		if _, err := container.RemoveImage(ctx, image, false, false); err != nil {
+			log.Warnf("Failed to remove image %s: %v", image, err)
+		}
+	}
+	return nil
+}
+
+// resetJobs returns an executor restoring the result and the outputs of the jobs of the plan, running a job
+// sets them on the workflow, so a plan run again, e.g. with --watch, would see the state of the previous run
+func resetJobs(plan *model.Plan) common.Executor {
+	outputs := make(map[*model.Job]map[string]string)
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			job := run.Job()
+			if job == nil {
+				continue
+			}
+			outputs[job] = mergeMaps(job.Outputs)
+		}
+	}
+	return func(ctx context.Context) error {
+		for job, jobOutputs := range outputs {
+			job.Result = ""
+			job.Outputs = mergeMaps(jobOutputs)
+		}
+		return nil
+	}
 }
 
 func handleFailure(plan *model.Plan) common.Executor {
@@ -216,6 +424,20 @@ func (runner *runnerImpl) newRunContext(run *model.Run, matrix map[string]interf
 		EventJSON:   runner.eventJSON,
 		StepResults: make(map[string]*model.StepResult),
 		Matrix:      matrix,
+		outputTail:  &outputTail{size: outputTailSize},
+	}
+	if runner.config.LogBufferInterval > 0 {
+		rc.outputBatch = &outputBatch{interval: runner.config.LogBufferInterval}
+	}
+	if runner.config.IncrementRunNumber && runner.runNumbers != nil {
+		rc.runNumber = runner.runNumbers.lookup(run.Workflow)
+	}
+	if runner.config.EventName == "workflow_dispatch" {
+		inputs, err := workflowDispatchInputs(run.Workflow, runner.inputs)
+		if err != nil {
+			log.Errorf("Error while evaluating the inputs of %s: %v", run.Workflow.File, err)
+		}
+		rc.Inputs = inputs
 	}
 	rc.ExprEval = rc.NewExpressionEvaluator()
 	rc.Name = rc.ExprEval.Interpolate(run.String())
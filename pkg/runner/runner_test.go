@@ -9,10 +9,13 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	log "github.com/sirupsen/logrus"
 	assert "github.com/stretchr/testify/assert"
 
+	"github.com/ankit-arora/act/pkg/container"
+	"github.com/ankit-arora/act/pkg/container/containertest"
 	"github.com/ankit-arora/act/pkg/model"
 )
 
@@ -129,6 +132,7 @@ func TestRunEvent(t *testing.T) {
 		{"testdata", "if-env-act", "push", "", platforms, ""},
 		{"testdata", "env-and-path", "push", "", platforms, ""},
 		{"testdata", "outputs", "push", "", platforms, ""},
+		{"testdata", "outputs-file", "push", "", platforms, ""},
 		{"testdata", "steps-context/conclusion", "push", "", platforms, ""},
 		{"testdata", "steps-context/outcome", "push", "", platforms, ""},
 		{"testdata", "job-status-check", "push", "job 'fail' failed", platforms, ""},
@@ -231,6 +235,37 @@ func TestRunEventPullRequest(t *testing.T) {
 	assert.Nil(t, err, workflowPath)
 }
 
+func TestRunEventToolCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	log.SetLevel(log.DebugLevel)
+	ctx := context.Background()
+
+	workdir, err := filepath.Abs("testdata")
+	assert.Nil(t, err)
+
+	// a tool installed into the tool cache by the first run is found by the second one
+	env := map[string]string{"TOOL_CACHE_MARKER": uuid.New().String()}
+	for _, workflowPath := range []string{"tool-cache/install", "tool-cache/verify"} {
+		runnerConfig := &Config{
+			Workdir:   workdir,
+			EventName: "push",
+			Platforms: map[string]string{"ubuntu-latest": baseImage},
+			Env:       env,
+		}
+		runner, err := New(runnerConfig)
+		assert.Nil(t, err, workflowPath)
+
+		planner, err := model.NewWorkflowPlanner(fmt.Sprintf("testdata/%s", workflowPath), true)
+		assert.Nil(t, err, workflowPath)
+
+		err = runner.NewPlanExecutor(planner.PlanEvent("push"))(ctx)
+		assert.Nil(t, err, workflowPath)
+	}
+}
+
 func TestContainerPath(t *testing.T) {
 	type containerPathJob struct {
 		destinationPath string
@@ -293,3 +328,216 @@ func TestContainerPath(t *testing.T) {
 		}
 	}
 }
+
+func TestRunnerPlanSteps(t *testing.T) {
+	planner, err := model.NewWorkflowPlanner("testdata/planned-steps/push.yml", true)
+	assert.NoError(t, err)
+
+	r, err := New(&Config{EventName: "push", Workdir: "testdata", IncrementRunNumber: true})
+	assert.NoError(t, err)
+	dir := t.TempDir()
+	r.(*runnerImpl).runNumbers = newRunNumbers(dir)
+
+	jobs := r.PlanSteps(planner.PlanEvent("push"))
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, files, "planning the steps doesn't increment the run number")
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, "test", jobs[0].JobID)
+	assert.Equal(t, []PlannedStep{
+		{ID: "0", Uses: "actions/checkout@v2", Enabled: StepEnabled},
+		{ID: "build", Name: "Build", Run: "make ...", If: "github.event_name == 'push'", Enabled: StepEnabled},
+		{ID: "2", Run: "make deploy", If: "github.event_name == 'release'", Enabled: StepDisabled},
+		{ID: "3", Run: "echo failed", If: "failure()", Enabled: StepEnabledRuntime},
+	}, jobs[0].Steps)
+}
+
+func TestRunnerReusedRunState(t *testing.T) {
+	fake := containertest.New()
+	container.RegisterContainerBackend("reuse-test", fake.Factory())
+
+	planner, err := model.NewWorkflowPlanner("testdata/reuse-outputs", true)
+	assert.NoError(t, err)
+	plan := planner.PlanEvent("push")
+
+	workdir, err := filepath.Abs("testdata/reuse-outputs")
+	assert.NoError(t, err)
+	r, err := New(&Config{
+		Workdir:          workdir,
+		EventName:        "push",
+		Platforms:        map[string]string{"ubuntu-latest": baseImage},
+		ReuseContainers:  true,
+		ContainerBackend: "reuse-test",
+	})
+	assert.NoError(t, err)
+	executor := r.NewPlanExecutor(plan)
+	job := plan.Stages[0].Runs[0].Job()
+	outputFile := "/var/run/act/workflow/outputcmd.txt"
+	envFile := "/var/run/act/workflow/envs.txt"
+
+	fake.EnvFiles[outputFile] = map[string]string{"value": "first"}
+	fake.EnvFiles[envFile] = map[string]string{"FROM_ENV": "first"}
+	assert.NoError(t, executor(context.Background()))
+	assert.Equal(t, "first", job.Outputs["value"])
+	assert.Equal(t, "first", job.Outputs["from-env"])
+	assert.Equal(t, "success", job.Result)
+
+	// the env written to GITHUB_ENV by the first run must not leak into the next one
+	delete(fake.EnvFiles, envFile)
+	fake.EnvFiles[outputFile] = map[string]string{"value": "second"}
+	fake.ExecHandler = func(call containertest.ExecCall) error {
+		return fmt.Errorf("exit code 1")
+	}
+	assert.Error(t, executor(context.Background()))
+	assert.Equal(t, "second", job.Outputs["value"])
+	assert.Empty(t, job.Outputs["from-env"])
+	assert.Equal(t, "failure", job.Result)
+
+	// neither must the step results of an earlier run
+	fake.ExecHandler = nil
+	delete(fake.EnvFiles, outputFile)
+	assert.NoError(t, executor(context.Background()))
+	assert.Empty(t, job.Outputs["value"])
+	assert.Empty(t, job.Outputs["from-env"])
+	assert.Equal(t, "success", job.Result)
+
+	fake.EnvFiles[outputFile] = map[string]string{"value": "third"}
+	assert.NoError(t, executor(context.Background()))
+	assert.Equal(t, "third", job.Outputs["value"])
+	assert.Equal(t, "success", job.Result)
+	assert.Empty(t, fake.Files[outputFile])
+}
+
+func TestRunnerInvalidDispatchInputs(t *testing.T) {
+	fake := containertest.New()
+	container.RegisterContainerBackend("dispatch-inputs-test", fake.Factory())
+
+	planner, err := model.NewWorkflowPlanner("testdata/dispatch-inputs", true)
+	assert.NoError(t, err)
+	plan := planner.PlanEvent("workflow_dispatch")
+
+	workdir, err := filepath.Abs("testdata/dispatch-inputs")
+	assert.NoError(t, err)
+	for _, inputs := range []map[string]string{{}, {"name": "act", "retries": "many"}} {
+		r, err := New(&Config{
+			Workdir:          workdir,
+			EventName:        "workflow_dispatch",
+			Platforms:        map[string]string{"ubuntu-latest": baseImage},
+			Inputs:           inputs,
+			ContainerBackend: "dispatch-inputs-test",
+		})
+		assert.NoError(t, err)
+		err = r.NewPlanExecutor(plan)(context.Background())
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "invalid inputs of dispatch.yml")
+		}
+	}
+	assert.Empty(t, fake.ExecCalls)
+}
+
+func TestRunnerIncrementRunNumber(t *testing.T) {
+	fake := containertest.New()
+	container.RegisterContainerBackend("run-number-test", fake.Factory())
+
+	planner, err := model.NewWorkflowPlanner("testdata/reuse-outputs", true)
+	assert.NoError(t, err)
+	plan := planner.PlanEvent("push")
+
+	workdir, err := filepath.Abs("testdata/reuse-outputs")
+	assert.NoError(t, err)
+	r, err := New(&Config{
+		Workdir:            workdir,
+		EventName:          "push",
+		Platforms:          map[string]string{"ubuntu-latest": baseImage},
+		ContainerBackend:   "run-number-test",
+		IncrementRunNumber: true,
+	})
+	assert.NoError(t, err)
+	dir := t.TempDir()
+	r.(*runnerImpl).runNumbers = newRunNumbers(dir)
+
+	executor := r.NewPlanExecutor(plan)
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, files, "the run number is only incremented when the plan runs")
+
+	assert.NoError(t, executor(context.Background()))
+	files, err = os.ReadDir(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+		assert.NoError(t, err)
+		assert.Equal(t, "1\n", string(content))
+	}
+}
+
+func TestNewWorkflowPlannerWorkflowsPath(t *testing.T) {
+	workflowsPath, err := filepath.Abs("testdata/generated-workflows")
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		workdir      string
+		workflowPath string
+	}{
+		{t.TempDir(), "ci.yml"},
+		{filepath.Dir(workflowsPath), "generated-workflows/ci.yml"},
+	} {
+		config := &Config{
+			Workdir:       tt.workdir,
+			WorkflowsPath: workflowsPath,
+			EventName:     "push",
+			Platforms:     map[string]string{"ubuntu-latest": baseImage},
+		}
+		planner, err := NewWorkflowPlanner(config, true)
+		assert.NoError(t, err)
+		plan := planner.PlanEvent("push")
+		if !assert.Len(t, plan.Stages, 1) {
+			continue
+		}
+		run := plan.Stages[0].Runs[0]
+		assert.Equal(t, filepath.Join(workflowsPath, "ci.yml"), run.Workflow.Path)
+
+		r, err := New(config)
+		assert.NoError(t, err)
+		rc := r.(*runnerImpl).newRunContext(run, nil)
+		assert.Equal(t, "generated-ci", rc.getGithubContext().Workflow)
+		assert.Equal(t, tt.workflowPath, rc.workflowPath())
+	}
+}
+
+func TestRunnerRecordJobResult(t *testing.T) {
+	fake := containertest.New()
+	container.RegisterContainerBackend("job-result-test", fake.Factory())
+
+	planner, err := model.NewWorkflowPlanner("testdata/reuse-outputs", true)
+	assert.NoError(t, err)
+	plan := planner.PlanEvent("push")
+
+	workdir, err := filepath.Abs("testdata/reuse-outputs")
+	assert.NoError(t, err)
+	config := &Config{
+		Workdir:          workdir,
+		EventName:        "push",
+		Platforms:        map[string]string{"ubuntu-latest": baseImage},
+		ContainerBackend: "job-result-test",
+	}
+	r, err := New(config)
+	assert.NoError(t, err)
+	assert.NoError(t, r.NewPlanExecutor(plan)(context.Background()))
+	assert.Equal(t, "true", fake.Input.Labels[container.ContainerLabel])
+	assert.NotContains(t, fake.Files, container.ResultPath, "a removed container needs no result")
+
+	config.ReuseContainers = true
+	r, err = New(config)
+	assert.NoError(t, err)
+	executor := r.NewPlanExecutor(plan)
+	fake.ExecHandler = func(call containertest.ExecCall) error {
+		return fmt.Errorf("exit code 1")
+	}
+	assert.Error(t, executor(context.Background()))
+	assert.Equal(t, "failure", string(fake.Files[container.ResultPath]))
+
+	fake.ExecHandler = nil
+	assert.NoError(t, executor(context.Background()))
+	assert.Equal(t, "success", string(fake.Files[container.ResultPath]))
+}
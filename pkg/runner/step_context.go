@@ -11,7 +11,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/kballard/go-shellquote"
 	"github.com/pkg/errors"
@@ -63,7 +65,7 @@ func (sc *StepContext) Executor(ctx context.Context) common.Executor {
 		)
 
 	case model.StepTypeUsesActionLocal:
-		actionDir := filepath.Join(rc.Config.Workdir, step.Uses)
+		actionDir := filepath.Join(rc.workspaceDir(), step.Uses)
 
 		localReader := func(ctx context.Context) actionyamlReader {
 			_, cpath := sc.getContainerActionPaths(sc.Step, path.Join(actionDir, ""), sc.RunContext)
@@ -97,6 +99,18 @@ func (sc *StepContext) Executor(ctx context.Context) common.Executor {
 		}
 
 		github := rc.getGithubContext()
+		token := github.Token
+		if remoteAction.URL != "https://github.com" && remoteAction.matches(rc.Config.GithubComActions) {
+			log.Debugf("Fetching %s from github.com instead of %s", step.Uses, remoteAction.URL)
+			remoteAction.URL = "https://github.com"
+			token = rc.Config.GithubComActionsToken
+		}
+		if rc.Config.SkipCheckout && remoteAction.IsCheckout() && isLocalCheckout(github, step) {
+			return func(ctx context.Context) error {
+				common.Logger(ctx).Debugf("Skipping local actions/checkout because the workspace is expected to be present")
+				return nil
+			}
+		}
 		if !rc.Config.ForceRemoteCheckout && remoteAction.IsCheckout() && isLocalCheckout(github, step) {
 			return func(ctx context.Context) error {
 				common.Logger(ctx).Debugf("Skipping local actions/checkout because workdir was already copied")
@@ -109,7 +123,7 @@ func (sc *StepContext) Executor(ctx context.Context) common.Executor {
 			URL:   remoteAction.CloneURL(),
 			Ref:   remoteAction.Ref,
 			Dir:   actionDir,
-			Token: github.Token,
+			Token: token,
 		})
 		var ntErr common.Executor
 		if err := gitClone(ctx); err != nil {
@@ -196,6 +210,14 @@ func (sc *StepContext) isEnabled(ctx context.Context) (bool, error) {
 	return runStep, nil
 }
 
+// continueOnError evaluates the continue-on-error of the step, it may be an expression like ${{ matrix.experimental }}
+func (sc *StepContext) continueOnError() (bool, error) {
+	if strings.TrimSpace(sc.Step.ContinueOnError) == "" {
+		return false, nil
+	}
+	return EvalBool(sc.RunContext.ExprEval, sc.Step.ContinueOnError)
+}
+
 func (sc *StepContext) setupEnv(ctx context.Context) (ExpressionEvaluator, error) {
 	rc := sc.RunContext
 	sc.Env = sc.mergeEnv()
@@ -204,16 +226,12 @@ func (sc *StepContext) setupEnv(ctx context.Context) (ExpressionEvaluator, error
 		if err != nil {
 			return nil, err
 		}
-		err = rc.JobContainer.UpdateFromEnv(sc.Env["GITHUB_ENV"], &sc.Env)(ctx)
-		if err != nil {
-			return nil, err
-		}
-		err = rc.JobContainer.UpdateFromPath(&sc.Env)(ctx)
-		if err != nil {
-			return nil, err
-		}
 	}
 	sc.Env = mergeMaps(sc.Env, sc.Step.GetEnv()) // step env should not be overwritten
+	// the directories added to GITHUB_PATH by the previous steps are prepended even if the step sets its own PATH
+	if err := rc.JobContainer.UpdateFromPath(&sc.Env)(ctx); err != nil {
+		return nil, err
+	}
 	evaluator := sc.NewExpressionEvaluator()
 	sc.interpolateEnv(evaluator)
 
@@ -252,6 +270,10 @@ func (sc *StepContext) setupShell() {
 		step.Shell = rc.Run.Workflow.Defaults.Run.Shell
 	}
 
+	if step.Shell == "" {
+		step.Shell = rc.defaultShell()
+	}
+
 	// current GitHub Runner behaviour is that default is `sh`,
 	// but if it's not container it validates with `which` command
 	// if `bash` is available, and provides `bash` if it is
@@ -264,6 +286,15 @@ func (sc *StepContext) setupShell() {
 	}
 }
 
+// defaultShell returns the shell of run steps that don't set one, PowerShell if the job runs on Windows
+// like on the GitHub hosted runners, else the empty shell that ShellCommand runs with bash
+func (rc *RunContext) defaultShell() string {
+	if strings.EqualFold(rc.Env["RUNNER_OS"], "windows") {
+		return "pwsh"
+	}
+	return ""
+}
+
 func getScriptName(rc *RunContext, step *model.Step) string {
 	scriptName := step.ID
 	for rcs := rc; rcs.Parent != nil; rcs = rcs.Parent {
@@ -357,44 +388,43 @@ func (sc *StepContext) setupShellCommandExecutor() common.Executor {
 func (sc *StepContext) newStepContainer(ctx context.Context, image string, cmd []string, entrypoint []string) container.Container {
 	rc := sc.RunContext
 	step := sc.Step
-	rawLogger := common.Logger(ctx).WithField("raw_output", true)
-	logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
-		if rc.Config.LogOutput {
-			rawLogger.Infof("%s", s)
-		} else {
-			rawLogger.Debugf("%s", s)
-		}
-		return true
-	})
+	logWriter := rc.newOutputWriter(ctx)
 	envList := make([]string, 0)
 	for k, v := range sc.Env {
 		envList = append(envList, fmt.Sprintf("%s=%s", k, v))
 	}
 
-	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TOOL_CACHE", "/opt/hostedtoolcache"))
+	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TOOL_CACHE", rc.runnerToolCache()))
 	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_OS", "Linux"))
-	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TEMP", "/tmp"))
+	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TEMP", rc.runnerTemp()))
 
 	binds, mounts := rc.GetBindsAndMounts()
 
-	stepContainer := container.NewContainer(&container.NewContainerInput{
-		Cmd:         cmd,
-		Entrypoint:  entrypoint,
-		WorkingDir:  rc.ContainerWorkdir(),
-		Image:       image,
-		Username:    rc.Config.Secrets["DOCKER_USERNAME"],
-		Password:    rc.Config.Secrets["DOCKER_PASSWORD"],
-		Name:        createContainerName(rc.jobContainerName(), step.ID),
-		Env:         envList,
-		Mounts:      mounts,
-		NetworkMode: fmt.Sprintf("container:%s", rc.jobContainerName()),
-		Binds:       binds,
-		Stdout:      logWriter,
-		Stderr:      logWriter,
-		Privileged:  rc.Config.Privileged,
-		UsernsMode:  rc.Config.UsernsMode,
-		Platform:    rc.Config.ContainerArchitecture,
+	stepContainer, err := container.NewContainerWithBackend(rc.Config.ContainerBackend, &container.NewContainerInput{
+		Cmd:            cmd,
+		Entrypoint:     entrypoint,
+		WorkingDir:     rc.ContainerWorkdir(),
+		Image:          image,
+		Username:       rc.Config.Secrets["DOCKER_USERNAME"],
+		Password:       rc.Config.Secrets["DOCKER_PASSWORD"],
+		Name:           createContainerName(rc.jobContainerName(), step.ID),
+		Env:            envList,
+		Mounts:         mounts,
+		NetworkMode:    fmt.Sprintf("container:%s", rc.jobContainerName()),
+		Binds:          binds,
+		Stdout:         logWriter,
+		Stderr:         logWriter,
+		Privileged:     rc.Config.Privileged,
+		UsernsMode:     rc.Config.UsernsMode,
+		Platform:       rc.containerArchitecture(),
+		StrictPlatform: rc.Config.StrictPlatform,
+		Init:           rc.Config.ContainerInit,
+		StopTimeout:    rc.Config.ContainerStopTimeout,
 	})
+	if err != nil {
+		common.Logger(ctx).Error(err)
+		return nil
+	}
 	return stepContainer
 }
 
@@ -415,9 +445,9 @@ func (sc *StepContext) runUsesContainer() common.Executor {
 		}
 
 		return common.NewPipelineExecutor(
-			stepContainer.Pull(rc.Config.ForcePull),
+			stepContainer.Pull(rc.forcePull(image)),
 			stepContainer.Remove().IfBool(!rc.Config.ReuseContainers),
-			stepContainer.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
+			stepContainer.Create(rc.containerCapAdd(), rc.Config.ContainerCapDrop),
 			stepContainer.Start(true),
 		).Finally(
 			stepContainer.Remove().IfBool(!rc.Config.ReuseContainers),
@@ -448,7 +478,7 @@ func (sc *StepContext) getContainerActionPaths(step *model.Step, actionDir strin
 	actionName := ""
 	containerActionDir := "."
 	if step.Type() != model.StepTypeUsesActionRemote {
-		actionName = getOsSafeRelativePath(actionDir, rc.Config.Workdir)
+		actionName = getOsSafeRelativePath(actionDir, rc.workspaceDir())
 		containerActionDir = rc.ContainerWorkdir() + "/" + actionName
 		actionName = "./" + actionName
 	} else if step.Type() == model.StepTypeUsesActionRemote {
@@ -482,6 +512,10 @@ func (sc *StepContext) runAction(actionDir string, actionPath string, actionRepo
 		rc.ActionRepository = actionRepository
 		action := sc.Action
 		log.Debugf("About to run action %v", action)
+		if err := sc.validateInputs(action); err != nil {
+			return err
+		}
+		sc.warnDeprecatedInputs(ctx, action)
 		sc.populateEnvsFromInput(action, rc)
 		actionLocation := ""
 		if actionPath != "" {
@@ -583,13 +617,14 @@ func (sc *StepContext) execAsDocker(ctx context.Context, action *model.Action, a
 			basedir = containerLocation
 		}
 		contextDir := filepath.Join(basedir, action.Runs.Main)
+		platform := rc.containerArchitecture()
 
 		anyArchExists, err := container.ImageExistsLocally(ctx, image, "any")
 		if err != nil {
 			return err
 		}
 
-		correctArchExists, err := container.ImageExistsLocally(ctx, image, rc.Config.ContainerArchitecture)
+		correctArchExists, err := container.ImageExistsLocally(ctx, image, platform)
 		if err != nil {
 			return err
 		}
@@ -605,19 +640,20 @@ func (sc *StepContext) execAsDocker(ctx context.Context, action *model.Action, a
 		}
 
 		if !correctArchExists || rc.Config.ForceRebuild {
-			log.Debugf("image '%s' for architecture '%s' will be built from context '%s", image, rc.Config.ContainerArchitecture, contextDir)
+			log.Debugf("image '%s' for architecture '%s' will be built from context '%s", image, platform, contextDir)
 			var actionContainer container.Container
 			if localAction {
 				actionContainer = sc.RunContext.JobContainer
 			}
 			prepImage = container.NewDockerBuildExecutor(container.NewDockerBuildExecutorInput{
 				ContextDir: contextDir,
+				BuildArgs:  rc.containerBuildArgs(),
 				ImageTag:   image,
 				Container:  actionContainer,
-				Platform:   rc.Config.ContainerArchitecture,
+				Platform:   platform,
 			})
 		} else {
-			log.Debugf("image '%s' for architecture '%s' already exists", image, rc.Config.ContainerArchitecture)
+			log.Debugf("image '%s' for architecture '%s' already exists", image, platform)
 		}
 	}
 	eval := sc.NewExpressionEvaluator()
@@ -646,26 +682,42 @@ func (sc *StepContext) execAsDocker(ctx context.Context, action *model.Action, a
 	}
 	return common.NewPipelineExecutor(
 		prepImage,
-		stepContainer.Pull(rc.Config.ForcePull),
+		stepContainer.Pull(rc.forcePull(image)),
 		stepContainer.Remove().IfBool(!rc.Config.ReuseContainers),
-		stepContainer.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
+		stepContainer.Create(rc.containerCapAdd(), rc.Config.ContainerCapDrop),
 		stepContainer.Start(true),
 	).Finally(
 		stepContainer.Remove().IfBool(!rc.Config.ReuseContainers),
 	).Finally(stepContainer.Close())(ctx)
 }
 
+// maxCompositeActionDepth is the number of composite actions GitHub allows to be nested
+const maxCompositeActionDepth = 9
+
 func (sc *StepContext) execAsComposite(ctx context.Context, step *model.Step, _ string, rc *RunContext, containerActionDir string, actionName string, _ string, action *model.Action, maybeCopyToActionDir func() error) error {
+	depth := 1
+	for p := rc.Parent; p != nil; p = p.Parent {
+		depth++
+	}
+	if depth > maxCompositeActionDepth {
+		return fmt.Errorf("composite action '%s' exceeds the maximum nesting depth of %d", actionName, maxCompositeActionDepth)
+	}
+
 	err := maybeCopyToActionDir()
 
 	if err != nil {
 		return err
 	}
 	// Disable some features of composite actions, only for feature parity with github
-	for _, compositeStep := range action.Runs.Steps {
+	compositeSteps := make([]*model.Step, 0, len(action.Runs.Steps))
+	for i, compositeStep := range action.Runs.Steps {
 		if err := compositeStep.Validate(rc.Config.CompositeRestrictions); err != nil {
 			return err
 		}
+		compositeSteps = append(compositeSteps, &action.Runs.Steps[i])
+	}
+	if ids := model.DuplicateStepIDs(compositeSteps); len(ids) > 0 {
+		return fmt.Errorf("composite action '%s' has more than one step with id '%s'", actionName, ids[0])
 	}
 	inputs := make(map[string]interface{})
 	eval := sc.RunContext.NewExpressionEvaluator()
@@ -684,14 +736,16 @@ func (sc *StepContext) execAsComposite(ctx context.Context, step *model.Step, _
 	backup := *rc
 	defer func() { *rc = backup }()
 	*rc = *rc.Clone()
-	scriptName := backup.CurrentStep
-	for rcs := &backup; rcs.Parent != nil; rcs = rcs.Parent {
-		scriptName = fmt.Sprintf("%s-composite-%s", rcs.Parent.CurrentStep, scriptName)
-	}
 	compositerc := rc
+	// the parent chain only keeps the step of every level, it is used for the
+	// nesting depth and to create unique script names for the composite steps
 	compositerc.Parent = &RunContext{
-		CurrentStep: scriptName,
+		CurrentStep: backup.CurrentStep,
+		Parent:      backup.Parent,
 	}
+	// the steps of the composite action write to the output tail and batch of the job
+	compositerc.outputTail = backup.jobOutputTail()
+	compositerc.outputBatch = backup.jobOutputBatch()
 	// Workaround end
 	compositerc.Composite = action
 	envToEvaluate := mergeMaps(compositerc.Env, step.Environment())
@@ -728,6 +782,64 @@ func (sc *StepContext) execAsComposite(ctx context.Context, step *model.Step, _
 	return err
 }
 
+// validateInputs fails if a required input of the action has no default and isn't provided by the step
+func (sc *StepContext) validateInputs(action *model.Action) error {
+	missing := make([]string, 0)
+	for inputID, input := range action.Inputs {
+		if !input.Required || input.Default != "" {
+			continue
+		}
+		provided := false
+		for k := range sc.Step.With {
+			if strings.EqualFold(k, inputID) {
+				provided = true
+				break
+			}
+		}
+		if !provided {
+			missing = append(missing, inputID)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("input required and not supplied for '%s': %s", sc.Step.Uses, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+type deprecatedInputWarningsContextKey string
+
+const deprecatedInputWarningsContextKeyVal = deprecatedInputWarningsContextKey("deprecated.input.warnings")
+
+// withDeprecatedInputWarnings returns a context that keeps the inputs a deprecation warning was logged for, a plan
+// executor starts every run with a new one so each run, e.g. with --watch, warns again
+func withDeprecatedInputWarnings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deprecatedInputWarningsContextKeyVal, &sync.Map{})
+}
+
+// warnDeprecatedInputs logs the deprecationMessage of every input provided by the step, once per run, action and
+// input. Without the warnings of a run in the context every step warns
+func (sc *StepContext) warnDeprecatedInputs(ctx context.Context, action *model.Action) {
+	warnings, ok := ctx.Value(deprecatedInputWarningsContextKeyVal).(*sync.Map)
+	if !ok {
+		warnings = &sync.Map{}
+	}
+	for inputID, input := range action.Inputs {
+		if input.DeprecationMessage == "" {
+			continue
+		}
+		for k := range sc.Step.With {
+			if !strings.EqualFold(k, inputID) {
+				continue
+			}
+			if _, warned := warnings.LoadOrStore(sc.Step.Uses+"#"+strings.ToLower(inputID), true); !warned {
+				common.Logger(ctx).Warnf("Input '%s' has been deprecated with message: %s", inputID, input.DeprecationMessage)
+			}
+			break
+		}
+	}
+}
+
 func (sc *StepContext) populateEnvsFromInput(action *model.Action, rc *RunContext) {
 	for inputID, input := range action.Inputs {
 		envKey := regexp.MustCompile("[^A-Z0-9-]").ReplaceAllString(strings.ToUpper(inputID), "_")
@@ -750,6 +862,16 @@ func (ra *remoteAction) CloneURL() string {
 	return fmt.Sprintf("%s/%s/%s", ra.URL, ra.Org, ra.Repo)
 }
 
+// matches returns true if the org/repo of the action matches one of the patterns, e.g. actions/* or actions/checkout
+func (ra *remoteAction) matches(patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, ra.Org+"/"+ra.Repo); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (ra *remoteAction) IsCheckout() bool {
 	if ra.Org == "actions" && ra.Repo == "checkout" {
 		return true
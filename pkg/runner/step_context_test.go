@@ -2,13 +2,23 @@ package runner
 
 import (
 	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 
 	"github.com/ankit-arora/act/pkg/common"
+	"github.com/ankit-arora/act/pkg/container"
+	"github.com/ankit-arora/act/pkg/container/containertest"
 	"github.com/ankit-arora/act/pkg/model"
 )
 
@@ -114,3 +124,305 @@ func TestStepContextIsEnabled(t *testing.T) {
 	}
 	assertObject.True(sc.isEnabled(context.Background()))
 }
+
+func TestStepContextIsEnabledContexts(t *testing.T) {
+	tables := []struct {
+		name string
+		cond string
+	}{
+		{"needs-outputs", "needs.build.outputs.version == '1.0'"},
+		{"needs-result", "needs.build.result == 'success'"},
+		{"matrix", "matrix.os == 'linux'"},
+		{"steps", "steps.first.outputs.ok == 'yes'"},
+		{"env", "env.FOO == 'bar'"},
+		{"github", "github.workflow == 'workflow1'"},
+		{"job", "job.status == 'success'"},
+	}
+
+	for _, table := range tables {
+		t.Run(table.name, func(t *testing.T) {
+			sc := createIfTestStepContext(t, "if: "+table.cond)
+			rc := sc.RunContext
+			rc.Run.Workflow.Jobs["build"] = createJob(t, `runs-on: ubuntu-latest`, "success")
+			rc.Run.Workflow.Jobs["build"].Outputs = map[string]string{"version": "1.0"}
+			rc.Run.Workflow.Jobs["job1"] = createJob(t, `runs-on: ubuntu-latest
+needs: build`, "")
+			rc.Matrix = map[string]interface{}{"os": "linux"}
+			rc.Env["FOO"] = "bar"
+			rc.StepResults["first"] = &model.StepResult{
+				Outputs:    map[string]string{"ok": "yes"},
+				Conclusion: model.StepStatusSuccess,
+			}
+
+			enabled, err := sc.isEnabled(context.Background())
+			assert.NoError(t, err)
+			assert.True(t, enabled)
+		})
+	}
+}
+
+func TestStepContextSetupWorkingDirectory(t *testing.T) {
+	sc := createIfTestStepContext(t, "working-directory: ${{ github.workspace }}/sub")
+	sc.RunContext.Config.ContainerWorkdir = "/github/workspace"
+	sc.RunContext.ExprEval = sc.RunContext.NewExpressionEvaluator()
+
+	sc.setupWorkingDirectory()
+	assert.Equal(t, "/github/workspace/sub", sc.Step.WorkingDirectory)
+}
+
+func TestStepContextDefaultShell(t *testing.T) {
+	tables := []struct {
+		input     string
+		runnerOS  string
+		container bool
+		shell     string
+	}{
+		{"run: echo hi", "linux", false, ""},
+		{"run: echo hi", "Linux", true, "sh"},
+		{"run: echo hi", "windows", false, "pwsh"},
+		{"run: echo hi", "Windows", false, "pwsh"},
+		{"run: echo hi\nshell: cmd", "windows", false, "cmd"},
+		{"run: echo hi\nshell: bash", "windows", false, "bash"},
+	}
+
+	for _, table := range tables {
+		sc := createIfTestStepContext(t, table.input)
+		if table.container {
+			sc.RunContext.Run.Workflow.Jobs["job1"] = createJob(t, "runs-on: ubuntu-latest\ncontainer: node:16", "")
+		}
+		sc.RunContext.Env["RUNNER_OS"] = table.runnerOS
+		sc.RunContext.ExprEval = sc.RunContext.NewExpressionEvaluator()
+
+		sc.setupShell()
+		assert.Equal(t, table.shell, sc.Step.Shell, "%s on %s", table.input, table.runnerOS)
+	}
+}
+
+func TestStepContextLocalCompositeAction(t *testing.T) {
+	for _, workdir := range []string{"testdata", "testdata/"} {
+		t.Run(workdir, func(t *testing.T) {
+			sc := createIfTestStepContext(t, "uses: ./uses-composite/composite_action")
+			sc.RunContext.Config.Workdir = workdir
+			assert.Equal(t, model.StepTypeUsesActionLocal, sc.Step.Type())
+
+			actionDir := filepath.Join(workdir, sc.Step.Uses)
+			actionName, containerActionDir := sc.getContainerActionPaths(sc.Step, path.Join(actionDir, ""), sc.RunContext)
+			assert.Equal(t, "./uses-composite/composite_action", actionName)
+			assert.Equal(t, sc.RunContext.ContainerWorkdir()+"/uses-composite/composite_action", containerActionDir)
+
+			action, err := sc.readAction(sc.Step, actionDir, "", func(filename string) (io.Reader, io.Closer, error) {
+				f, err := os.Open(filepath.Join(actionDir, filename))
+				return f, f, err
+			}, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, model.ActionRunsUsing(model.ActionRunsUsingComposite), action.Runs.Using)
+			assert.NotEmpty(t, action.Runs.Steps)
+		})
+	}
+}
+
+func TestStepContextCompositeNestingDepth(t *testing.T) {
+	sc := createIfTestStepContext(t, "uses: ./composite")
+	rc := sc.RunContext
+	for i := 0; i < maxCompositeActionDepth; i++ {
+		rc.Parent = &RunContext{
+			CurrentStep: "step",
+			Parent:      rc.Parent,
+		}
+	}
+	action := &model.Action{
+		Runs: model.ActionRuns{
+			Using: model.ActionRunsUsingComposite,
+		},
+	}
+
+	err := sc.execAsComposite(context.Background(), sc.Step, "", rc, "", "./composite", "", action, func() error { return nil })
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "maximum nesting depth")
+}
+
+func TestStepContextValidateInputs(t *testing.T) {
+	action := &model.Action{
+		Inputs: map[string]model.Input{
+			"required":              {Required: true},
+			"required_with_default": {Required: true, Default: "default"},
+			"optional":              {},
+		},
+	}
+
+	sc := createIfTestStepContext(t, `
+uses: ./action
+with:
+  REQUIRED: value`)
+	assert.NoError(t, sc.validateInputs(action))
+
+	sc = createIfTestStepContext(t, `
+uses: ./action
+with:
+  optional: value`)
+	err := sc.validateInputs(action)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "required")
+	assert.NotContains(t, err.Error(), "required_with_default")
+}
+
+func TestStepContextWarnDeprecatedInputs(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	ctx := withDeprecatedInputWarnings(common.WithLogger(context.Background(), logger))
+
+	action := &model.Action{
+		Inputs: map[string]model.Input{
+			"old": {DeprecationMessage: "use 'new' instead"},
+			"new": {},
+		},
+	}
+	sc := createIfTestStepContext(t, `
+uses: ./deprecated-input-action
+with:
+  old: value
+  new: value`)
+
+	sc.warnDeprecatedInputs(ctx, action)
+	sc.warnDeprecatedInputs(ctx, action)
+
+	assert.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, log.WarnLevel, hook.LastEntry().Level)
+	assert.Equal(t, "Input 'old' has been deprecated with message: use 'new' instead", hook.LastEntry().Message)
+
+	// the next run warns again
+	ctx = withDeprecatedInputWarnings(ctx)
+	sc.warnDeprecatedInputs(ctx, action)
+	sc.warnDeprecatedInputs(ctx, action)
+	assert.Len(t, hook.AllEntries(), 2)
+}
+
+func TestRemoteActionMatches(t *testing.T) {
+	patterns := []string{"actions/*", "docker/build-push-action"}
+	for uses, expected := range map[string]bool{
+		"actions/checkout@v2":                 true,
+		"actions/setup-node/sub@v2":           true,
+		"docker/build-push-action@v2":         true,
+		"docker/login-action@v1":              false,
+		"my-enterprise/internal-action@main":  false,
+		"my-enterprise/actions-mirror@v1.0.0": false,
+	} {
+		assert.Equal(t, expected, newRemoteAction(uses).matches(patterns), uses)
+	}
+	assert.False(t, newRemoteAction("actions/checkout@v2").matches(nil))
+}
+
+func TestStepContextContinueOnError(t *testing.T) {
+	for _, table := range []struct {
+		step     string
+		matrix   map[string]interface{}
+		expected bool
+	}{
+		{"run: make", nil, false},
+		{"continue-on-error: true", nil, true},
+		{"continue-on-error: false", nil, false},
+		{"continue-on-error: ${{ matrix.experimental }}", map[string]interface{}{"experimental": true}, true},
+		{"continue-on-error: ${{ matrix.experimental }}", map[string]interface{}{"experimental": false}, false},
+		{"continue-on-error: ${{ matrix.node == 'nightly' }}", map[string]interface{}{"node": "nightly"}, true},
+		{"continue-on-error: ${{ matrix.node == 'nightly' }}", map[string]interface{}{"node": "16"}, false},
+	} {
+		sc := createIfTestStepContext(t, table.step)
+		sc.RunContext.Matrix = table.matrix
+		sc.RunContext.ExprEval = sc.RunContext.NewExpressionEvaluator()
+
+		continueOnError, err := sc.continueOnError()
+		assert.NoError(t, err, table.step)
+		assert.Equal(t, table.expected, continueOnError, "%s with %v", table.step, table.matrix)
+	}
+}
+
+func TestStepContextSetupEnvGithubPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the steps run with sh")
+	}
+	ctx := common.WithLogger(context.Background(), log.New())
+	dir := t.TempDir()
+
+	sc := createIfTestStepContext(t, "run: echo")
+	rc := sc.RunContext
+	rc.JobContainer = &container.HostExecutor{Path: dir, StdOut: io.Discard}
+	rc.SetActPath(filepath.Join(dir, "act"))
+	rc.ExprEval = rc.NewExpressionEvaluator()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "act", "workflow"), 0777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "act", "workflow", "paths.txt"), nil, 0666))
+
+	// step 1 adds a directory to GITHUB_PATH
+	_, err := sc.setupEnv(ctx)
+	require.NoError(t, err)
+	require.NoError(t, rc.JobContainer.Exec([]string{"sh", "-c", `echo /opt/step1/bin >> "$GITHUB_PATH"`}, "", sc.Env, "", "")(ctx))
+
+	// step 2 sets its own PATH, the directory is still prepended
+	sc2 := createIfTestStepContext(t, `
+env:
+  PATH: /step2/bin:/usr/bin:/bin
+run: echo`)
+	sc2.RunContext = rc
+	_, err = sc2.setupEnv(ctx)
+	require.NoError(t, err)
+	require.NoError(t, rc.JobContainer.Exec([]string{"sh", "-c", `echo "$PATH" > path.txt`}, "", sc2.Env, "", "")(ctx))
+
+	content, err := os.ReadFile(filepath.Join(dir, "path.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "/opt/step1/bin:/step2/bin:/usr/bin:/bin\n", string(content))
+}
+
+func TestStepContextCompositeSharesJobOutputTail(t *testing.T) {
+	sc := createIfTestStepContext(t, "uses: ./composite")
+	rc := sc.RunContext
+	rc.ExprEval = rc.NewExpressionEvaluator()
+	fake := containertest.New()
+	rc.JobContainer = fake
+	tail := rc.jobOutputTail()
+
+	// a step container of the composite action gets its output writer from the run context of the composite action
+	fake.ExecHandler = func(call containertest.ExecCall) error {
+		_, err := rc.newOutputWriter(common.WithLogger(context.Background(), log.New())).Write([]byte("from the composite step\n"))
+		return err
+	}
+	action := &model.Action{
+		Runs: model.ActionRuns{
+			Using: model.ActionRunsUsingComposite,
+			Steps: []model.Step{{ID: "inner", Run: "echo from the composite step", Shell: "bash"}},
+		},
+	}
+
+	ctx := common.WithJobErrorContainer(common.WithLogger(context.Background(), log.New()))
+	err := sc.execAsComposite(ctx, sc.Step, "", rc, "", "./composite", "", action, func() error { return nil })
+	assert.NoError(t, err)
+	assert.Len(t, fake.ExecCalls, 1)
+	assert.Contains(t, tail.get(), "from the composite step\n")
+	assert.Same(t, tail, rc.jobOutputTail())
+}
+
+func TestStepContextCompositeSharesJobOutputBatch(t *testing.T) {
+	sc := createIfTestStepContext(t, "uses: ./composite")
+	rc := sc.RunContext
+	rc.Config.LogBufferInterval = time.Hour
+	rc.ExprEval = rc.NewExpressionEvaluator()
+	fake := containertest.New()
+	rc.JobContainer = fake
+	batch := rc.jobOutputBatch()
+
+	var compositeBatch *outputBatch
+	fake.ExecHandler = func(call containertest.ExecCall) error {
+		compositeBatch = rc.jobOutputBatch()
+		return nil
+	}
+	action := &model.Action{
+		Runs: model.ActionRuns{
+			Using: model.ActionRunsUsingComposite,
+			Steps: []model.Step{{ID: "inner", Run: "echo from the composite step", Shell: "bash"}},
+		},
+	}
+
+	ctx := common.WithJobErrorContainer(common.WithLogger(context.Background(), log.New()))
+	err := sc.execAsComposite(ctx, sc.Step, "", rc, "", "./composite", "", action, func() error { return nil })
+	assert.NoError(t, err)
+	assert.NotNil(t, batch)
+	assert.Same(t, batch, compositeBatch)
+	assert.Same(t, batch, rc.jobOutputBatch())
+}
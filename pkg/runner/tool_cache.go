@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ankit-arora/act/pkg/common"
+	"github.com/ankit-arora/act/pkg/model"
+)
+
+// toolInstallAction is the setup action installing a toolchain into the tool cache
+type toolInstallAction struct {
+	uses  string // the setup action
+	input string // the input of the setup action taking the version
+}
+
+// toolInstallActions are the toolchains CacheToolInstalls can prewarm the tool cache with
+var toolInstallActions = map[string]toolInstallAction{
+	"go":     {uses: "actions/setup-go@v3", input: "go-version"},
+	"node":   {uses: "actions/setup-node@v3", input: "node-version"},
+	"python": {uses: "actions/setup-python@v4", input: "python-version"},
+}
+
+// parseToolInstall returns the setup action and its inputs installing a toolchain like node@16
+func parseToolInstall(tool string) (string, map[string]string, error) {
+	parts := strings.SplitN(tool, "@", 2)
+	action, ok := toolInstallActions[parts[0]]
+	if !ok {
+		names := make([]string, 0, len(toolInstallActions))
+		for name := range toolInstallActions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", nil, fmt.Errorf("unknown toolchain '%s' to cache, expected one of %s", parts[0], strings.Join(names, ", "))
+	}
+	if len(parts) != 2 || parts[1] == "" {
+		return "", nil, fmt.Errorf("toolchain '%s' to cache is missing a version, e.g. %s@<version>", tool, parts[0])
+	}
+	return action.uses, map[string]string{action.input: parts[1]}, nil
+}
+
+// prewarmToolCache runs the setup action of every toolchain of CacheToolInstalls in a throwaway job container
+// before the jobs run, they install into the shared act-toolcache volume so the jobs find them instead of downloading them
+func (runner *runnerImpl) prewarmToolCache() common.Executor {
+	config := *runner.config
+	config.CacheToolInstalls = nil
+	config.IsolateRunnerDirs = false
+	config.ReuseContainers = false
+	config.ContainerPoolSize = 0
+	config.SkipCheckout = true
+	config.RemoveImagesAfterRun = false
+	config.IncrementRunNumber = false
+	config.PreRun = ""
+	config.PostRun = ""
+	config.JobPreHook = ""
+	config.JobPostHook = ""
+	config.ExtractWorkspaceTo = ""
+	config.CommitOnFailure = false
+	config.ExportOnFailure = ""
+	prewarmRunner := &runnerImpl{
+		config:    &config,
+		eventJSON: "{}",
+	}
+
+	executors := make([]common.Executor, 0, len(runner.config.CacheToolInstalls))
+	for _, tool := range runner.config.CacheToolInstalls {
+		tool := tool
+		executors = append(executors, func(ctx context.Context) error {
+			uses, with, err := parseToolInstall(tool)
+			if err != nil {
+				return err
+			}
+			common.Logger(ctx).Infof("Prewarming the tool cache with %s", tool)
+			plan := model.NewSingleActionPlanner(uses, with, config.ActionRunsOn, config.EventName).PlanJob(model.SingleActionJobID)
+			if err := prewarmRunner.NewPlanExecutor(plan)(ctx); err != nil {
+				return fmt.Errorf("failed to prewarm the tool cache with %s: %w", tool, err)
+			}
+			return nil
+		})
+	}
+	return common.NewPipelineExecutor(executors...)
+}
@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseToolInstall(t *testing.T) {
+	uses, with, err := parseToolInstall("node@16")
+	assert.NoError(t, err)
+	assert.Equal(t, "actions/setup-node@v3", uses)
+	assert.Equal(t, map[string]string{"node-version": "16"}, with)
+
+	uses, with, err = parseToolInstall("python@3.10")
+	assert.NoError(t, err)
+	assert.Equal(t, "actions/setup-python@v4", uses)
+	assert.Equal(t, map[string]string{"python-version": "3.10"}, with)
+
+	_, _, err = parseToolInstall("go")
+	assert.EqualError(t, err, "toolchain 'go' to cache is missing a version, e.g. go@<version>")
+
+	_, _, err = parseToolInstall("ruby@3")
+	assert.EqualError(t, err, "unknown toolchain 'ruby' to cache, expected one of go, node, python")
+
+	_, err = New(&Config{CacheToolInstalls: []string{"node@16", "ruby@3"}, ActionRunsOn: "ubuntu-latest"})
+	assert.Error(t, err)
+
+	_, err = New(&Config{CacheToolInstalls: []string{"node@16"}})
+	assert.Error(t, err)
+	_, err = New(&Config{CacheToolInstalls: []string{"node@16"}, ActionRunsOn: "ubuntu-latest"})
+	assert.NoError(t, err)
+}
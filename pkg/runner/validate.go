@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ankit-arora/act/pkg/exprparser"
+)
+
+var embeddedExpressionPattern = regexp.MustCompile(`\$\{\{(.*?)\}\}`)
+
+// Validate checks that every secret and env variable referenced by the expressions of the job
+// is provided, it returns an error listing the missing names. An env variable only referenced after a step
+// that may write it to GITHUB_ENV is unknown until the job runs, it is only warned about
+func (rc *RunContext) Validate() error {
+	secrets, env, laterEnv := rc.jobReferences()
+
+	job := rc.Run.Job()
+	provided := mergeMaps(rc.Run.Workflow.Env, job.Environment(), rc.GetEnv())
+	for _, step := range job.Steps {
+		provided = mergeMaps(provided, step.Environment())
+	}
+
+	missing := make([]string, 0)
+	for _, name := range secrets {
+		if !hasKeyFold(rc.Config.Secrets, name) {
+			missing = append(missing, "secrets."+name)
+		}
+	}
+	for _, name := range env {
+		if !hasKeyFold(provided, name) {
+			missing = append(missing, "env."+name)
+		}
+	}
+	for _, name := range laterEnv {
+		if !hasKeyFold(provided, name) {
+			log.Warnf("Job '%s' references env.%s, which is only set if an earlier step writes it to GITHUB_ENV", rc.JobName, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("job '%s' references missing values: %s", rc.JobName, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// jobReferences returns the sorted names of the secrets and env variables referenced by the job, and of the
+// env variables only referenced after a step that may write them to GITHUB_ENV
+func (rc *RunContext) jobReferences() ([]string, []string, []string) {
+	job := rc.Run.Job()
+	secrets := make(map[string]bool)
+	env := make(map[string]bool)
+	laterEnv := make(map[string]bool)
+	envWritten := false
+	collect := func(conditions []string, values []string) {
+		expressions := conditions
+		for _, value := range values {
+			for _, match := range embeddedExpressionPattern.FindAllStringSubmatch(value, -1) {
+				expressions = append(expressions, match[1])
+			}
+		}
+		for _, expression := range expressions {
+			if strings.TrimSpace(expression) == "" {
+				continue
+			}
+			// an invalid expression is reported by the validation of the workflow
+			names, _ := exprparser.ContextProperties(expression, "secrets")
+			for _, name := range names {
+				secrets[name] = true
+			}
+			names, _ = exprparser.ContextProperties(expression, "env")
+			for _, name := range names {
+				if envWritten {
+					laterEnv[name] = true
+				} else {
+					env[name] = true
+				}
+			}
+		}
+	}
+
+	values := nodeValues(&job.Env)
+	values = append(values, nodeValues(&job.RawContainer)...)
+	collect([]string{job.If.Value}, values)
+	for _, step := range job.Steps {
+		values := []string{step.Name, step.Uses, step.Run, step.WorkingDirectory, step.Shell, step.ContinueOnError}
+		values = append(values, nodeValues(&step.Env)...)
+		for _, with := range step.With {
+			values = append(values, with)
+		}
+		collect([]string{step.If.Value}, values)
+		// actions and scripts writing to GITHUB_ENV set env variables for the later steps
+		if step.Uses != "" || strings.Contains(step.Run, "GITHUB_ENV") {
+			envWritten = true
+		}
+	}
+	outputs := make([]string, 0, len(job.Outputs))
+	for _, output := range job.Outputs {
+		outputs = append(outputs, output)
+	}
+	collect(nil, outputs)
+
+	for name := range env {
+		delete(laterEnv, name)
+	}
+	return sortedKeys(secrets), sortedKeys(env), sortedKeys(laterEnv)
+}
+
+// nodeValues returns all scalar values of a yaml node
+func nodeValues(node *yaml.Node) []string {
+	if node.Kind == yaml.ScalarNode {
+		return []string{node.Value}
+	}
+	values := make([]string, 0)
+	for _, content := range node.Content {
+		values = append(values, nodeValues(content)...)
+	}
+	return values
+}
+
+// hasKeyFold looks up the key case insensitive, like the expression evaluator does
+func hasKeyFold(m map[string]string, key string) bool {
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
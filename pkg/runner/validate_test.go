@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/ankit-arora/act/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunContextValidate(t *testing.T) {
+	job := createJob(t, `runs-on: ubuntu-latest
+if: secrets.DEPLOY_KEY != ''
+env:
+  TOKEN: ${{ secrets.token }}
+steps:
+- run: echo ${{ env.TOKEN }} ${{ env.STEP_VAR }} $NOT_AN_EXPRESSION
+  env:
+    STEP_VAR: ${{ secrets['npm-token'] }}
+- uses: actions/setup-node@v3
+  if: env.MISSING == 'x'
+  with:
+    registry: ${{ secrets.REGISTRY }}
+- run: echo ${{ env.FROM_ACTION }} ${{ format('secrets.LITERAL {0}', github.env) }} ${{ env.TOKEN }}
+`, "")
+	rc := createIfTestRunContext(map[string]*model.Job{"job1": job})
+	rc.JobName = "job1"
+
+	secrets, env, laterEnv := rc.jobReferences()
+	assert.Equal(t, []string{"DEPLOY_KEY", "REGISTRY", "npm-token", "token"}, secrets)
+	assert.Equal(t, []string{"MISSING", "STEP_VAR", "TOKEN"}, env)
+	// the action may set FROM_ACTION through GITHUB_ENV, so it isn't missing
+	assert.Equal(t, []string{"FROM_ACTION"}, laterEnv)
+
+	rc.Config.Secrets = map[string]string{"DEPLOY_KEY": "key", "TOKEN": "t"}
+	err := rc.Validate()
+	assert.EqualError(t, err, "job 'job1' references missing values: secrets.REGISTRY, secrets.npm-token, env.MISSING")
+
+	rc.Config.Secrets["REGISTRY"] = "r"
+	rc.Config.Secrets["NPM-TOKEN"] = "n"
+	rc.Config.Env = map[string]string{"MISSING": "x"}
+	rc.Env = nil
+	assert.NoError(t, rc.Validate())
+}